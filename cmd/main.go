@@ -7,6 +7,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"sync"
@@ -20,9 +21,12 @@ import (
 	"bitmex-api/pkg/authmiddleware/appauth"
 	"bitmex-api/pkg/config"
 	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/notify"
 	"bitmex-api/pkg/store"
 )
 
+const grpcStopTimeout = 10 * time.Second
+
 // @title           CRM System API
 // @version         1.0
 // @description     All handlers for the CRM System API
@@ -65,7 +69,15 @@ func main() {
 
 	middleware := appauth.NewAuthMiddleware(storeDB, atKey, rtKey)
 
-	apiServer := api.NewServer(ctx, &conf.Server, storeDB, middleware, &wg)
+	// email notifications are off without SMTP host
+	var notifier notify.Notifier
+	if conf.SMTP.Host != "" {
+		notifier = notify.NewSMTP(&conf.SMTP)
+	}
+
+	mailer := notify.NewMailer(notifier, &conf.SMTP)
+
+	apiServer := api.NewServer(ctx, &conf.Server, storeDB, middleware, mailer, &wg)
 
 	logger.Infof("Start api: %s", time.Now())
 
@@ -83,6 +95,21 @@ func main() {
 		}
 	}()
 
+	go func() {
+		listener, err := net.Listen("tcp", conf.Server.GRPCPort)
+		if err != nil {
+			runErr <- fmt.Errorf("can't listen grpc port: %w", err)
+
+			return
+		}
+
+		logger.Infof("gRPC API server start listen on: %s", conf.Server.GRPCPort)
+		err = apiServer.GRPC.Serve(listener)
+		if err != nil {
+			runErr <- fmt.Errorf("can't start grpc server: %w", err)
+		}
+	}()
+
 	select {
 	case err = <-runErr:
 		cancel()
@@ -96,6 +123,8 @@ func main() {
 		logger.Infof("Received signal: %v. Running graceful shutdown...", s)
 		ctx := context.Background()
 
+		apiServer.StopGRPC(grpcStopTimeout)
+
 		err = apiServer.Shutdown(ctx)
 		if err != nil {
 			logger.Infof("Can't shutdown API server: %s", err)
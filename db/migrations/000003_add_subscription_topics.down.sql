@@ -0,0 +1,2 @@
+alter table users
+    drop column subscription_topics;
\ No newline at end of file
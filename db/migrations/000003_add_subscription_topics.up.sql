@@ -0,0 +1,2 @@
+alter table users
+    add column subscription_topics text[];
\ No newline at end of file
@@ -0,0 +1 @@
+drop table trades;
\ No newline at end of file
@@ -0,0 +1,23 @@
+create table trades
+(
+    id               bigserial not null
+        primary key,
+    symbol           text      not null,
+    side             text,
+    size             bigint,
+    price            double precision,
+    tick_direction   text,
+    trd_match_id     text
+        constraint uq_trades_trd_match_id
+            unique,
+    gross_value      bigint,
+    home_notional    double precision,
+    foreign_notional double precision,
+    timestamp        timestamptz not null
+);
+
+create index idx_trades_symbol_timestamp_id
+    on trades (symbol, timestamp, id);
+
+create index idx_trades_timestamp
+    on trades (timestamp);
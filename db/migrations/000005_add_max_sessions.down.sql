@@ -0,0 +1,2 @@
+alter table users
+    drop column max_sessions;
\ No newline at end of file
@@ -0,0 +1,2 @@
+alter table users
+    add column max_sessions integer;
\ No newline at end of file
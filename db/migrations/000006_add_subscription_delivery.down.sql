@@ -0,0 +1,2 @@
+alter table users
+    drop column subscription_delivery;
\ No newline at end of file
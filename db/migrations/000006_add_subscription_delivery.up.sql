@@ -0,0 +1,2 @@
+alter table users
+    add column subscription_delivery jsonb not null default '{"mode": "realtime"}';
\ No newline at end of file
@@ -0,0 +1,2 @@
+drop table alert_events;
+drop table alerts;
\ No newline at end of file
@@ -0,0 +1,40 @@
+create table alerts
+(
+    id           uuid        not null
+        primary key,
+    user_id      uuid        not null
+        constraint fk_alerts_auth_user
+            references auth_users
+            on delete cascade,
+    symbol       text        not null,
+    condition    text        not null,
+    price        double precision,
+    percent      double precision,
+    window_ms    bigint,
+    repeat       boolean     not null default false,
+    active       boolean     not null default true,
+    created_at   timestamptz not null,
+    triggered_at timestamptz
+);
+
+create index idx_alerts_user_id
+    on alerts (user_id);
+
+create table alert_events
+(
+    id              bigserial   not null
+        primary key,
+    alert_id        uuid        not null,
+    user_id         uuid        not null
+        constraint fk_alert_events_auth_user
+            references auth_users
+            on delete cascade,
+    symbol          text        not null,
+    condition       text        not null,
+    price           double precision,
+    reference_price double precision,
+    timestamp       timestamptz not null
+);
+
+create index idx_alert_events_user_id_timestamp
+    on alert_events (user_id, timestamp);
\ No newline at end of file
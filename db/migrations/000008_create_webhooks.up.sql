@@ -0,0 +1,45 @@
+create table webhooks
+(
+    id                uuid        not null
+        primary key,
+    user_id           uuid        not null
+        constraint fk_webhooks_auth_user
+            references auth_users
+            on delete cascade,
+    url               text        not null,
+    secret            text        not null,
+    events            text[]      not null,
+    symbols           text[],
+    trade_interval_ms bigint      not null default 0,
+    active            boolean     not null default true,
+    created_at        timestamptz not null
+);
+
+create index idx_webhooks_user_id
+    on webhooks (user_id);
+
+create table webhook_deliveries
+(
+    id               uuid        not null
+        primary key,
+    webhook_id       uuid        not null
+        constraint fk_webhook_deliveries_webhook
+            references webhooks
+            on delete cascade,
+    user_id          uuid        not null,
+    event            text        not null,
+    payload          jsonb       not null,
+    status           text        not null,
+    attempts         integer     not null default 0,
+    last_status_code integer     not null default 0,
+    last_error       text        not null default '',
+    created_at       timestamptz not null,
+    delivered_at     timestamptz,
+    next_attempt_at  timestamptz
+);
+
+create index idx_webhook_deliveries_webhook_id_created_at
+    on webhook_deliveries (webhook_id, created_at);
+
+create index idx_webhook_deliveries_user_id_status
+    on webhook_deliveries (user_id, status);
\ No newline at end of file
@@ -0,0 +1,8 @@
+drop index idx_users_email_token_hash;
+
+alter table users
+    drop column email,
+    drop column email_verified,
+    drop column email_alerts,
+    drop column email_token_hash,
+    drop column email_token_expires_at;
\ No newline at end of file
@@ -0,0 +1,9 @@
+alter table users
+    add column email                  text,
+    add column email_verified         boolean not null default false,
+    add column email_alerts           boolean not null default false,
+    add column email_token_hash       text,
+    add column email_token_expires_at timestamptz;
+
+create index idx_users_email_token_hash
+    on users (email_token_hash);
\ No newline at end of file
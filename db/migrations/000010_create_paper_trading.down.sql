@@ -0,0 +1,4 @@
+drop table paper_positions;
+drop table paper_fills;
+drop table paper_orders;
+drop table paper_accounts;
\ No newline at end of file
@@ -0,0 +1,72 @@
+create table paper_accounts
+(
+    user_id    uuid             not null
+        primary key
+        constraint fk_paper_accounts_auth_user
+            references auth_users
+            on delete cascade,
+    balance    double precision not null,
+    created_at timestamptz      not null,
+    updated_at timestamptz      not null
+);
+
+create table paper_orders
+(
+    id              uuid             not null
+        primary key,
+    user_id         uuid             not null
+        constraint fk_paper_orders_auth_user
+            references auth_users
+            on delete cascade,
+    symbol          text             not null,
+    side            text             not null,
+    type            text             not null,
+    quantity        double precision not null,
+    price           double precision not null default 0,
+    stop_price      double precision not null default 0,
+    filled_quantity double precision not null default 0,
+    avg_fill_price  double precision not null default 0,
+    status          text             not null,
+    reason          text             not null default '',
+    created_at      timestamptz      not null,
+    updated_at      timestamptz      not null
+);
+
+create index idx_paper_orders_user_id_created_at
+    on paper_orders (user_id, created_at);
+
+create index idx_paper_orders_status
+    on paper_orders (status);
+
+create table paper_fills
+(
+    id        bigserial        not null
+        primary key,
+    order_id  uuid             not null
+        constraint fk_paper_fills_paper_order
+            references paper_orders
+            on delete cascade,
+    user_id   uuid             not null,
+    symbol    text             not null,
+    side      text             not null,
+    quantity  double precision not null,
+    price     double precision not null,
+    timestamp timestamptz      not null
+);
+
+create index idx_paper_fills_user_id_timestamp
+    on paper_fills (user_id, timestamp);
+
+create table paper_positions
+(
+    user_id      uuid             not null
+        constraint fk_paper_positions_auth_user
+            references auth_users
+            on delete cascade,
+    symbol       text             not null,
+    quantity     double precision not null default 0,
+    avg_price    double precision not null default 0,
+    realized_pnl double precision not null default 0,
+    updated_at   timestamptz      not null,
+    primary key (user_id, symbol)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+drop table watchlists;
\ No newline at end of file
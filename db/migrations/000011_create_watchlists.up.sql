@@ -0,0 +1,21 @@
+create table watchlists
+(
+    id          uuid        not null
+        primary key,
+    user_id     uuid        not null
+        constraint fk_watchlists_auth_user
+            references auth_users
+            on delete cascade,
+    name        text        not null,
+    symbols     text[]      not null default '{}',
+    position    integer     not null default 0,
+    share_token text,
+    created_at  timestamptz not null,
+    updated_at  timestamptz not null
+);
+
+create unique index idx_watchlists_user_id_name
+    on watchlists (user_id, name);
+
+create unique index idx_watchlists_share_token
+    on watchlists (share_token);
\ No newline at end of file
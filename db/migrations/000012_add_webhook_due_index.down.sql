@@ -0,0 +1 @@
+drop index idx_webhook_deliveries_due_next_attempt_at;
\ No newline at end of file
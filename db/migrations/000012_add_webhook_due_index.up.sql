@@ -0,0 +1,3 @@
+create index idx_webhook_deliveries_due_next_attempt_at
+    on webhook_deliveries (next_attempt_at)
+    where status in ('pending', 'retrying');
\ No newline at end of file
@@ -20,8 +20,8 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/bit-mex/subscription": {
-            "patch": {
+        "/api/v1/alerts": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -31,17 +31,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Alerts"
                 ],
-                "summary": "subscribe or unsubscribe on bitMex price update",
+                "summary": "get price alerts of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Alert"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "crossesAbove and crossesBelow fire when trade price crosses price,\nmovesPercent fires when trade price moves by percent either way within windowMs (up to 24h),\nfired alerts are sent to /connect and /bit-mex/stream as {\"type\": \"alert\", \"data\": {...}},\nalert without repeat is deactivated once it fires",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "create price alert",
                 "parameters": [
                     {
-                        "description": "Subscription Request",
-                        "name": "User",
+                        "description": "Alert",
+                        "name": "Alert",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/subscription.Request"
+                            "$ref": "#/definitions/alert.Request"
                         }
                     }
                 ],
@@ -49,7 +81,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/subscription.Response"
+                            "$ref": "#/definitions/model.Alert"
                         }
                     },
                     "400": {
@@ -61,31 +93,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/change-password": {
-            "patch": {
+        "/api/v1/alerts/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Alerts"
                 ],
-                "summary": "user change password",
+                "summary": "get fired price alerts of the user, the newest first",
                 "parameters": [
                     {
-                        "description": "Change Password",
-                        "name": "ChangePassword",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.ChangePassword"
-                        }
+                        "type": "integer",
+                        "description": "Number of alerts up to 1000, 100 by default",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/authmiddleware.Tokens"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AlertEvent"
+                            }
                         }
                     },
                     "400": {
@@ -97,59 +134,73 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/login": {
-            "post": {
+        "/api/v1/alerts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Alerts"
                 ],
-                "summary": "user login",
+                "summary": "get price alert",
                 "parameters": [
                     {
-                        "description": "User Info",
-                        "name": "userInfo",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AuthUser"
-                        }
+                        "type": "string",
+                        "description": "Alert ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/authmiddleware.Tokens"
+                            "$ref": "#/definitions/model.Alert"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/refresh": {
-            "post": {
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "active defaults to true, so updated alert is armed again",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Alerts"
                 ],
-                "summary": "user refresh token",
+                "summary": "replace rule of price alert",
                 "parameters": [
                     {
-                        "description": "Tokens",
-                        "name": "token",
+                        "type": "string",
+                        "description": "Alert ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Alert",
+                        "name": "Alert",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/authmiddleware.Tokens"
+                            "$ref": "#/definitions/alert.Request"
                         }
                     }
                 ],
@@ -157,7 +208,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/authmiddleware.Tokens"
+                            "$ref": "#/definitions/model.Alert"
                         }
                     },
                     "400": {
@@ -165,41 +216,97 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
                     }
                 }
-            }
-        },
-        "/api/v1/registration": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "available user roles: ADMIN/BASE",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Alerts"
                 ],
-                "summary": "user registration",
+                "summary": "delete price alert, its history is kept",
                 "parameters": [
                     {
-                        "description": "User Info",
-                        "name": "userInfo",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Alert ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AuthUser"
+                            "$ref": "#/definitions/model.Alert"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
                         }
                     }
+                }
+            }
+        },
+        "/api/v1/bit-mex/candles/{symbol}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "bars are sorted from oldest to newest, the last one may be still open",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "BitMex"
+                ],
+                "summary": "get recent OHLCV bars",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol",
+                        "name": "symbol",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bar interval: 1m, 5m, 15m, 1h or 1d, 1m by default",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of bars, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/auth.RegistrationResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/candle.Bar"
+                            }
                         }
                     },
                     "400": {
@@ -211,25 +318,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/user": {
+        "/api/v1/bit-mex/order-book/{symbol}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "book is mirrored on the first request, until then 503 is returned",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "BitMex"
+                ],
+                "summary": "get order book snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol",
+                        "name": "symbol",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Levels per side, whole book when 0",
+                        "name": "depth",
+                        "in": "query"
+                    }
                 ],
-                "summary": "get user info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/depth.Snapshot"
                         }
                     },
                     "400": {
@@ -237,11 +360,56 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/user/update-info": {
+        "/api/v1/bit-mex/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "data of every event is the same frame /connect sends, subscriptions are managed with REST API only.\nevent id is sessionId:sequence, reconnect with Last-Event-ID header to resume the session\nand get the events after it, the session waits for reconnect for SSE_REPLAY_TTL.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "BitMex"
+                ],
+                "summary": "stream price updates with Server-Sent Events",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Payload version, 1 or 2",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/bit-mex/subscription": {
             "patch": {
                 "security": [
                     {
@@ -254,15 +422,15 @@ const docTemplate = `{
                 "tags": [
                     "User"
                 ],
-                "summary": "update user info",
+                "summary": "subscribe or unsubscribe on bitMex price update",
                 "parameters": [
                     {
-                        "description": "User",
+                        "description": "Subscription Request",
                         "name": "User",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/subscription.Request"
                         }
                     }
                 ],
@@ -270,7 +438,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/subscription.Response"
                         }
                     },
                     "400": {
@@ -281,62 +449,2011 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth.RegistrationResponse": {
-            "type": "object",
-            "properties": {
-                "status": {
-                    "type": "string"
-                }
-            }
-        },
-        "authmiddleware.Tokens": {
-            "type": "object",
-            "properties": {
-                "accessToken": {
-                    "type": "string"
-                },
-                "refreshToken": {
-                    "type": "string"
-                }
-            }
         },
-        "errors.UIResponseErrorBadRequest": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "message": {
-                    "type": "string",
-                    "example": "request invalid body"
+        "/api/v1/bit-mex/trades": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "trades are sorted by timestamp, pass nextCursor of the response to get the next page",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "BitMex"
+                ],
+                "summary": "get stored trades history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol",
+                        "name": "symbol",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 start, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 end, exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size up to 1000, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Page"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
                 }
             }
         },
-        "model.AuthUser": {
-            "type": "object",
-            "properties": {
-                "password": {
-                    "type": "string"
-                },
-                "role": {
+        "/api/v1/change-password": {
+            "patch": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "user change password",
+                "parameters": [
+                    {
+                        "description": "Change Password",
+                        "name": "ChangePassword",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ChangePassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/authmiddleware.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/email/verify": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "verify email of the user with the token of verification link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Verification token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/email.Status"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/login": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "user login",
+                "parameters": [
+                    {
+                        "description": "User Info",
+                        "name": "userInfo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AuthUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/authmiddleware.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/paper/account": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "account is created with PAPER_INITIAL_BALANCE on the first use",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper trading"
+                ],
+                "summary": "get paper balance of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaperAccount"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/paper/account/reset": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "open orders are cancelled, positions are removed and balance is set to PAPER_INITIAL_BALANCE",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper trading"
+                ],
+                "summary": "reset paper account of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaperAccount"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/paper/fills": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper trading"
+                ],
+                "summary": "get fills of paper orders of the user, the newest first",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of fills up to 1000, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.PaperFill"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/paper/orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper trading"
+                ],
+                "summary": "get paper orders of the user, the newest first",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only orders waiting for fills",
+                        "name": "open",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of orders up to 1000, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.PaperOrder"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "market order fills at the price of the next trade of the symbol,\nlimit order fills at the trade price once it is at price or better,\nstop order becomes market order once trade price reaches stopPrice, above it for buy and below for sell,\norder fills partially when trade size is less than the rest of the order,\nfill costs or returns quantity * price of paper balance, buy needs the balance and sell needs\nthe position, order which can not be settled is rejected,\nchanges of orders are sent to /connect and /bit-mex/stream as {\"type\": \"execution\", \"data\": {...}}",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper trading"
+                ],
+                "summary": "place paper order",
+                "parameters": [
+                    {
+                        "description": "Order",
+                        "name": "Order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/paper.OrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaperOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/paper/orders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper trading"
+                ],
+                "summary": "get paper order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaperOrder"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper trading"
+                ],
+                "summary": "cancel paper order, fills made before are kept",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PaperOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/paper/positions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "closed positions are kept with their realized profit",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper trading"
+                ],
+                "summary": "get paper positions of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.PaperPosition"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/refresh": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "user refresh token",
+                "parameters": [
+                    {
+                        "description": "Tokens",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authmiddleware.Tokens"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/authmiddleware.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/registration": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "available user roles: ADMIN/BASE",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "user registration",
+                "parameters": [
+                    {
+                        "description": "User Info",
+                        "name": "userInfo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AuthUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.RegistrationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "get user info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/email": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "verification link valid for 24 hours is sent to the email,\nprice alert, password change and login emails are sent once it is opened",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "set email of the user",
+                "parameters": [
+                    {
+                        "description": "Email",
+                        "name": "Email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/email.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/email.Status"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/email-notifications": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "password change and login emails are always sent to verified email",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "turn email of fired price alerts on or off",
+                "parameters": [
+                    {
+                        "description": "Notifications",
+                        "name": "Notifications",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/email.NotificationsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/email.Status"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/session-limit": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "null maxSessions resets the limit to the server default, 0 lifts the limit",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "set how many /connect sessions the user may open, admin only",
+                "parameters": [
+                    {
+                        "description": "Session limit",
+                        "name": "Limit",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/session.LimitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/session.LimitRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "get open /connect sessions of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/session.Info"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/update-info": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "update user info",
+                "parameters": [
+                    {
+                        "description": "User",
+                        "name": "User",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watchlists": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "get watchlists of the user in their order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Watchlist"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "name is unique among watchlists of the user, repeated symbols are dropped,\nnew watchlist is the last one, subscribe to its symbols with watchlist of\nPATCH /api/v1/bit-mex/subscription or of subscribe command of /connect",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "create watchlist",
+                "parameters": [
+                    {
+                        "description": "Watchlist",
+                        "name": "Watchlist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Watchlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watchlists/order": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ids must list every watchlist of the user once, in the new order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "reorder watchlists of the user",
+                "parameters": [
+                    {
+                        "description": "Order",
+                        "name": "Order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.OrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Watchlist"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watchlists/shared/{token}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "get watchlist shared with the token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Watchlist"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watchlists/shared/{token}/copy": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "copy is not shared and does not follow changes of the shared watchlist",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "copy watchlist shared with the token to watchlists of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the copy, name of the shared watchlist by default",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Watchlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watchlists/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "get watchlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watchlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Watchlist"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "rename watchlist and replace its symbols",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watchlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Watchlist",
+                        "name": "Watchlist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Watchlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "delete watchlist, subscriptions made with it are kept",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watchlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Watchlist"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watchlists/{id}/share": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "any user with shareToken of the watchlist can read it with GET /api/v1/watchlists/shared/{token}\nand copy it with POST /api/v1/watchlists/shared/{token}/copy, shared watchlist keeps its token",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "share watchlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watchlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Watchlist"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlists"
+                ],
+                "summary": "stop sharing watchlist, its token is revoked and copies are kept",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watchlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Watchlist"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "get webhooks of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "events are POST to url as {\"id\": \"...\", \"event\": \"alert\", \"timestamp\": \"...\", \"data\": {...}},\nalert event sends fired price alerts, trade event sends trades of symbols,\nat most one trade of a symbol every tradeIntervalMs (1000 to 3600000), the latest one is sent,\nrequest is signed in X-Webhook-Signature as sha256=hex(HMAC-SHA256(secret, timestamp + \".\" + body))\nwith timestamp of X-Webhook-Timestamp, secret is returned only in this response,\nfailed delivery is retried with exponential backoff and kept in dead letters once out of attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "create webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "Webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/dead-letters": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "get deliveries of all webhooks of the user which are out of attempts, the newest first",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of deliveries up to 1000, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "get webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "active defaults to true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "replace url and events of webhook, secret is kept",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook",
+                        "name": "Webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "delete webhook with its delivery logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "status is pending, retrying, delivered or failed,\nlastStatusCode and lastError are the result of the latest attempt",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "get delivery log of webhook, the newest first",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of deliveries up to 1000, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/connect": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "version 1 (default) sends trades as {symbol, price, timestamp},\nversion 2 sends trades with every BitMex field in {\"type\": \"trade\", \"data\": {...}}\nsubscriptions are managed with commands {\"id\": \"1\", \"op\": \"subscribe\", \"symbols\": [], \"channels\": []},\nop is one of subscribe, unsubscribe, list or ping, every command is answered with ack or error frame\nlast trade and quote of subscribed symbols are sent as {\"type\": \"snapshot\", \"data\": {...}} after connect and subscribe\nencoding query or Sec-WebSocket-Protocol header chooses json (default), msgpack or protobuf frames,\nbinary frames follow the schema of proto/stream.proto, protobuf uses version 2 payloads only\nbatch=true packs frames of one BitMex message into one array, permessage-deflate is used when the client offers it\nthe server pings the client, session without messages and pongs for WS_IDLE_TIMEOUT is closed with code 4000\n{\"type\": \"tokenExpiring\", \"expiresAt\": \"...\"} is sent WS_TOKEN_EXPIRY_WARNING ahead of access token expiry,\n{\"id\": \"1\", \"op\": \"reauth\", \"token\": \"...\"} replaces the token, otherwise the session is closed with code 4003,\nsessions of removed users are closed with code 4004",
+                "tags": [
+                    "User"
+                ],
+                "summary": "connect to price updates WebSocket",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Payload version, 1 or 2",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Frame encoding: json, msgpack or protobuf",
+                        "name": "encoding",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Send frames of one BitMex message in one array",
+                        "name": "batch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.UIResponseErrorBadRequest"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "alert.Request": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "condition": {
+                    "$ref": "#/definitions/model.AlertCondition"
+                },
+                "percent": {
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "repeat": {
+                    "type": "boolean"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "windowMs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "auth.RegistrationResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "authmiddleware.Tokens": {
+            "type": "object",
+            "properties": {
+                "accessToken": {
+                    "type": "string"
+                },
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "candle.Bar": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "type": "number"
+                },
+                "closed": {
+                    "type": "boolean"
+                },
+                "high": {
+                    "type": "number"
+                },
+                "interval": {
+                    "$ref": "#/definitions/candle.Interval"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "open": {
+                    "type": "number"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "trades": {
+                    "type": "integer"
+                },
+                "volume": {
+                    "type": "integer"
+                }
+            }
+        },
+        "candle.Interval": {
+            "type": "string",
+            "enum": [
+                "1m",
+                "5m",
+                "15m",
+                "1h",
+                "1d"
+            ],
+            "x-enum-varnames": [
+                "Minute1",
+                "Minute5",
+                "Minute15",
+                "Hour1",
+                "Day1"
+            ]
+        },
+        "depth.Level": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "depth.Snapshot": {
+            "type": "object",
+            "properties": {
+                "asks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/depth.Level"
+                    }
+                },
+                "bids": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/depth.Level"
+                    }
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "email.NotificationsRequest": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "email.Request": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "email.Status": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "verified": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "errors.UIResponseErrorBadRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "message": {
+                    "type": "string",
+                    "example": "request invalid body"
+                }
+            }
+        },
+        "model.Alert": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "condition": {
+                    "$ref": "#/definitions/model.AlertCondition"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "percent": {
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "repeat": {
+                    "type": "boolean"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "triggeredAt": {
+                    "type": "string"
+                },
+                "windowMs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AlertCondition": {
+            "type": "string",
+            "enum": [
+                "crossesAbove",
+                "crossesBelow",
+                "movesPercent"
+            ],
+            "x-enum-varnames": [
+                "CrossesAbove",
+                "CrossesBelow",
+                "MovesPercent"
+            ]
+        },
+        "model.AlertEvent": {
+            "type": "object",
+            "properties": {
+                "alertID": {
+                    "type": "string"
+                },
+                "condition": {
+                    "$ref": "#/definitions/model.AlertCondition"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "referencePrice": {
+                    "type": "number"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AuthUser": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "role": {
                     "$ref": "#/definitions/model.UserRole"
                 },
-                "username": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ChangePassword": {
+            "type": "object",
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "old_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.OrderSide": {
+            "type": "string",
+            "enum": [
+                "buy",
+                "sell"
+            ],
+            "x-enum-varnames": [
+                "Buy",
+                "Sell"
+            ]
+        },
+        "model.OrderStatus": {
+            "type": "string",
+            "enum": [
+                "new",
+                "partiallyFilled",
+                "filled",
+                "cancelled",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "OrderNew",
+                "OrderPartiallyFilled",
+                "OrderFilled",
+                "OrderCancelled",
+                "OrderRejected"
+            ]
+        },
+        "model.OrderType": {
+            "type": "string",
+            "enum": [
+                "market",
+                "limit",
+                "stop"
+            ],
+            "x-enum-varnames": [
+                "MarketOrder",
+                "LimitOrder",
+                "StopOrder"
+            ]
+        },
+        "model.PaperAccount": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PaperFill": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "orderID": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "side": {
+                    "$ref": "#/definitions/model.OrderSide"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PaperOrder": {
+            "type": "object",
+            "properties": {
+                "avgFillPrice": {
+                    "type": "number"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "filledQuantity": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "side": {
+                    "$ref": "#/definitions/model.OrderSide"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.OrderStatus"
+                },
+                "stopPrice": {
+                    "type": "number"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.OrderType"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PaperPosition": {
+            "type": "object",
+            "properties": {
+                "avgPrice": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "realizedPnl": {
+                    "type": "number"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "model.ChangePassword": {
+        "model.Trade": {
             "type": "object",
             "properties": {
-                "new_password": {
+                "foreignNotional": {
+                    "type": "number"
+                },
+                "grossValue": {
+                    "type": "integer"
+                },
+                "homeNotional": {
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "side": {
                     "type": "string"
                 },
-                "old_password": {
+                "size": {
+                    "type": "integer"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "tickDirection": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "trdMatchID": {
                     "type": "string"
                 }
             }
@@ -355,32 +2472,240 @@ const docTemplate = `{
                 "BaseUserRole"
             ]
         },
-        "subscription.Request": {
+        "model.Watchlist": {
             "type": "object",
             "properties": {
-                "action": {
-                    "$ref": "#/definitions/subscription.SubscriptionAction"
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "shareToken": {
+                    "type": "string"
                 },
                 "symbols": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         },
-        "subscription.Response": {
+        "model.Webhook": {
             "type": "object",
             "properties": {
-                "error": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
                     "type": "string"
                 },
-                "success": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "symbols": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tradeIntervalMs": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deliveredAt": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/model.WebhookEvent"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lastStatusCode": {
+                    "type": "integer"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.WebhookDeliveryStatus"
+                },
+                "webhookID": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WebhookDeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivered",
+                "retrying",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "WebhookDeliveryPending",
+                "WebhookDeliveryDelivered",
+                "WebhookDeliveryRetrying",
+                "WebhookDeliveryFailed"
+            ]
+        },
+        "model.WebhookEvent": {
+            "type": "string",
+            "enum": [
+                "alert",
+                "trade"
+            ],
+            "x-enum-varnames": [
+                "WebhookAlertEvent",
+                "WebhookTradeEvent"
+            ]
+        },
+        "paper.OrderRequest": {
+            "type": "object",
+            "properties": {
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "side": {
+                    "$ref": "#/definitions/model.OrderSide"
+                },
+                "stopPrice": {
+                    "type": "number"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.OrderType"
+                }
+            }
+        },
+        "session.Info": {
+            "type": "object",
+            "properties": {
+                "batch": {
                     "type": "boolean"
+                },
+                "connectedAt": {
+                    "type": "string"
+                },
+                "encoding": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "queue": {
+                    "$ref": "#/definitions/session.Queue"
+                },
+                "subscriptions": {
+                    "$ref": "#/definitions/subscription.Subscriptions"
+                },
+                "transport": {
+                    "$ref": "#/definitions/session.Transport"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "session.LimitRequest": {
+            "type": "object",
+            "properties": {
+                "maxSessions": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "session.Queue": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "conflated": {
+                    "type": "integer"
+                },
+                "dropped": {
+                    "type": "integer"
+                },
+                "lagMs": {
+                    "type": "integer"
+                },
+                "maxLagMs": {
+                    "type": "integer"
+                },
+                "queued": {
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
                 }
             }
         },
-        "subscription.SubscriptionAction": {
+        "session.Transport": {
+            "type": "string",
+            "enum": [
+                "websocket",
+                "sse",
+                "grpc"
+            ],
+            "x-enum-varnames": [
+                "WebSocketTransport",
+                "SSETransport",
+                "GRPCTransport"
+            ]
+        },
+        "subscription.Action": {
             "type": "string",
             "enum": [
                 "subscribe",
@@ -390,6 +2715,169 @@ const docTemplate = `{
                 "Subscribe",
                 "Unsubscribe"
             ]
+        },
+        "subscription.Channel": {
+            "type": "string",
+            "enum": [
+                "trade",
+                "quote",
+                "orderBook"
+            ],
+            "x-enum-varnames": [
+                "TradeChannel",
+                "QuoteChannel",
+                "OrderBookChannel"
+            ]
+        },
+        "subscription.Delivery": {
+            "type": "object",
+            "properties": {
+                "aggregate": {
+                    "type": "boolean"
+                },
+                "intervalMs": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "$ref": "#/definitions/subscription.DeliveryMode"
+                }
+            }
+        },
+        "subscription.DeliveryMode": {
+            "type": "string",
+            "enum": [
+                "realtime",
+                "throttled"
+            ],
+            "x-enum-varnames": [
+                "RealtimeDelivery",
+                "ThrottledDelivery"
+            ]
+        },
+        "subscription.Request": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/subscription.Action"
+                },
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.Channel"
+                    }
+                },
+                "delivery": {
+                    "$ref": "#/definitions/subscription.Delivery"
+                },
+                "symbols": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "watchlist": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "subscription.Subscriptions": {
+            "type": "object",
+            "properties": {
+                "delivery": {
+                    "$ref": "#/definitions/subscription.Delivery"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trade": {
+                    "type": "boolean"
+                },
+                "tradeSymbols": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "trade.Page": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "string"
+                },
+                "trades": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Trade"
+                    }
+                }
+            }
+        },
+        "watchlist.OrderRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "watchlist.Request": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "symbols": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "webhook.Request": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WebhookEvent"
+                    }
+                },
+                "symbols": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tradeIntervalMs": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
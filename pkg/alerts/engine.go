@@ -0,0 +1,207 @@
+// Package alerts evaluates price alert rules of users against the trade stream.
+package alerts
+
+import (
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+)
+
+// Fired is alert fired by a trade, alert without Repeat is removed from the engine when it fires.
+type Fired struct {
+	Alert model.Alert
+	Event model.AlertEvent
+}
+
+// Engine keeps active alerts by symbol. Crossing alerts compare the trade with the previous trade of the symbol,
+// so alert put before the first trade of the symbol fires on the second trade at the earliest.
+type Engine struct {
+	rules map[string][]*rule
+	last  map[string]float64
+
+	mu sync.Mutex
+}
+
+type rule struct {
+	alert  model.Alert
+	window extremes
+}
+
+func NewEngine() *Engine {
+	return &Engine{
+		rules: make(map[string][]*rule),
+		last:  make(map[string]float64),
+	}
+}
+
+// Load puts active alerts.
+func (e *Engine) Load(alerts []model.Alert) {
+	for _, alert := range alerts {
+		e.Put(alert)
+	}
+}
+
+// Put adds alert or replaces alert with the same ID, inactive alert is removed.
+func (e *Engine) Put(alert model.Alert) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.remove(alert.ID)
+
+	if alert.Active {
+		e.rules[alert.Symbol] = append(e.rules[alert.Symbol], &rule{alert: alert})
+	}
+}
+
+func (e *Engine) Remove(id uuid.UUID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.remove(id)
+}
+
+func (e *Engine) remove(id uuid.UUID) {
+	for symbol, rules := range e.rules {
+		for i, r := range rules {
+			if r.alert.ID != id {
+				continue
+			}
+
+			if len(rules) == 1 {
+				delete(e.rules, symbol)
+			} else {
+				e.rules[symbol] = append(rules[:i:i], rules[i+1:]...)
+			}
+
+			return
+		}
+	}
+}
+
+// Add evaluates alerts of the trade symbol and returns the fired ones in the order they were put.
+func (e *Engine) Add(record bitmex.TradeDataRecord) []Fired {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	previous, ok := e.last[record.Symbol]
+	e.last[record.Symbol] = record.Price
+
+	var fired []Fired
+
+	rules := e.rules[record.Symbol][:0:0]
+
+	for _, r := range e.rules[record.Symbol] {
+		reference, fire := r.evaluate(record, previous, ok)
+		if !fire {
+			rules = append(rules, r)
+
+			continue
+		}
+
+		timestamp := record.Timestamp
+		r.alert.TriggeredAt = &timestamp
+
+		fired = append(fired, Fired{
+			Alert: r.alert,
+			Event: model.AlertEvent{
+				AlertID:        r.alert.ID,
+				UserID:         r.alert.UserID,
+				Symbol:         record.Symbol,
+				Condition:      r.alert.Condition,
+				Price:          record.Price,
+				ReferencePrice: reference,
+				Timestamp:      record.Timestamp,
+			},
+		})
+
+		if r.alert.Repeat {
+			rules = append(rules, r)
+		}
+	}
+
+	if len(rules) == 0 {
+		delete(e.rules, record.Symbol)
+	} else {
+		e.rules[record.Symbol] = rules
+	}
+
+	return fired
+}
+
+// evaluate returns reference price of the fired alert, window of fired moving alert starts over from the trade.
+func (r *rule) evaluate(record bitmex.TradeDataRecord, previous float64, hasPrevious bool) (float64, bool) {
+	switch r.alert.Condition {
+	case model.CrossesAbove:
+		return r.alert.Price, hasPrevious && previous < r.alert.Price && record.Price >= r.alert.Price
+	case model.CrossesBelow:
+		return r.alert.Price, hasPrevious && previous > r.alert.Price && record.Price <= r.alert.Price
+	case model.MovesPercent:
+		r.window.add(record.Price, record.Timestamp, record.Timestamp.Add(-r.alert.Window()))
+
+		low, high := r.window.low(), r.window.high()
+
+		var reference float64
+
+		switch {
+		case low > 0 && (record.Price-low)/low*100 >= r.alert.Percent:
+			reference = low
+		case high > 0 && (high-record.Price)/high*100 >= r.alert.Percent:
+			reference = high
+		default:
+			return 0, false
+		}
+
+		r.window = extremes{}
+		r.window.add(record.Price, record.Timestamp, record.Timestamp)
+
+		return reference, true
+	}
+
+	return 0, false
+}
+
+type point struct {
+	price float64
+	at    time.Time
+}
+
+// extremes keeps the lowest and the highest price of the sliding window in monotonic queues,
+// so every trade is added and evicted once.
+type extremes struct {
+	lows  []point
+	highs []point
+}
+
+// add appends trade price at the time and evicts prices before since.
+func (e *extremes) add(price float64, at, since time.Time) {
+	for len(e.lows) > 0 && e.lows[len(e.lows)-1].price >= price {
+		e.lows = e.lows[:len(e.lows)-1]
+	}
+
+	for len(e.highs) > 0 && e.highs[len(e.highs)-1].price <= price {
+		e.highs = e.highs[:len(e.highs)-1]
+	}
+
+	e.lows = append(e.lows, point{price: price, at: at})
+	e.highs = append(e.highs, point{price: price, at: at})
+
+	for e.lows[0].at.Before(since) {
+		e.lows = e.lows[1:]
+	}
+
+	for e.highs[0].at.Before(since) {
+		e.highs = e.highs[1:]
+	}
+}
+
+func (e *extremes) low() float64 {
+	return e.lows[0].price
+}
+
+func (e *extremes) high() float64 {
+	return e.highs[0].price
+}
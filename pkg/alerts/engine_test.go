@@ -0,0 +1,108 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func trade(symbol string, price float64, offset time.Duration) bitmex.TradeDataRecord {
+	return bitmex.TradeDataRecord{Symbol: symbol, Price: price, Timestamp: start.Add(offset)}
+}
+
+func TestEngine_Crosses(t *testing.T) {
+	engine := NewEngine()
+	above := model.Alert{ID: uuid.NewV4(), Symbol: "XBTUSD", Condition: model.CrossesAbove, Price: 70000, Active: true}
+	below := model.Alert{ID: uuid.NewV4(), Symbol: "XBTUSD", Condition: model.CrossesBelow, Price: 60000, Active: true}
+	engine.Load([]model.Alert{above, below})
+
+	// the first trade of the symbol has nothing to cross from
+	assert.Empty(t, engine.Add(trade("XBTUSD", 71000, 0)))
+	assert.Empty(t, engine.Add(trade("XBTUSD", 69000, time.Second)))
+	assert.Empty(t, engine.Add(trade("ETHUSD", 71000, time.Second)))
+
+	fired := engine.Add(trade("XBTUSD", 70000, 2*time.Second))
+	require.Len(t, fired, 1)
+	assert.Equal(t, above.ID, fired[0].Event.AlertID)
+	assert.InDelta(t, 70000, fired[0].Event.Price, 0)
+	assert.InDelta(t, 70000, fired[0].Event.ReferencePrice, 0)
+	assert.Equal(t, start.Add(2*time.Second), *fired[0].Alert.TriggeredAt)
+
+	// alert without repeat fires once
+	assert.Empty(t, engine.Add(trade("XBTUSD", 69000, 3*time.Second)))
+	assert.Empty(t, engine.Add(trade("XBTUSD", 71000, 4*time.Second)))
+
+	fired = engine.Add(trade("XBTUSD", 59000, 5*time.Second))
+	require.Len(t, fired, 1)
+	assert.Equal(t, model.CrossesBelow, fired[0].Event.Condition)
+}
+
+func TestEngine_Repeat(t *testing.T) {
+	engine := NewEngine()
+	alert := model.Alert{
+		ID:        uuid.NewV4(),
+		Symbol:    "XBTUSD",
+		Condition: model.CrossesAbove,
+		Price:     70000,
+		Repeat:    true,
+		Active:    true,
+	}
+	engine.Put(alert)
+
+	engine.Add(trade("XBTUSD", 69000, 0))
+	assert.Len(t, engine.Add(trade("XBTUSD", 70001, time.Second)), 1)
+	assert.Empty(t, engine.Add(trade("XBTUSD", 70002, 2*time.Second)))
+	engine.Add(trade("XBTUSD", 69000, 3*time.Second))
+	assert.Len(t, engine.Add(trade("XBTUSD", 70001, 4*time.Second)), 1)
+
+	alert.Price = 80000
+	engine.Put(alert)
+	engine.Add(trade("XBTUSD", 69000, 5*time.Second))
+	assert.Empty(t, engine.Add(trade("XBTUSD", 70001, 6*time.Second)))
+
+	engine.Remove(alert.ID)
+	assert.Empty(t, engine.Add(trade("XBTUSD", 80001, 7*time.Second)))
+
+	alert.Active = false
+	engine.Put(alert)
+	engine.Add(trade("XBTUSD", 69000, 8*time.Second))
+	assert.Empty(t, engine.Add(trade("XBTUSD", 80001, 9*time.Second)))
+}
+
+func TestEngine_MovesPercent(t *testing.T) {
+	engine := NewEngine()
+	alert := model.Alert{
+		ID:        uuid.NewV4(),
+		Symbol:    "XBTUSD",
+		Condition: model.MovesPercent,
+		Percent:   2,
+		WindowMs:  (5 * time.Minute).Milliseconds(),
+		Repeat:    true,
+		Active:    true,
+	}
+	engine.Put(alert)
+
+	assert.Empty(t, engine.Add(trade("XBTUSD", 100, 0)))
+	assert.Empty(t, engine.Add(trade("XBTUSD", 101, time.Minute)))
+	// 100 has left the window
+	assert.Empty(t, engine.Add(trade("XBTUSD", 102, 5*time.Minute+time.Second)))
+
+	fired := engine.Add(trade("XBTUSD", 103.1, 6*time.Minute))
+	require.Len(t, fired, 1)
+	assert.InDelta(t, 101, fired[0].Event.ReferencePrice, 0)
+
+	// window starts over from the trade that fired the alert
+	assert.Empty(t, engine.Add(trade("XBTUSD", 103.2, 6*time.Minute+time.Second)))
+
+	fired = engine.Add(trade("XBTUSD", 101, 7*time.Minute))
+	require.Len(t, fired, 1)
+	assert.InDelta(t, 103.2, fired[0].Event.ReferencePrice, 0)
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/alert"
+)
+
+const (
+	defaultAlertEventsLimit = 100
+	maxAlertEventsLimit     = 1000
+)
+
+type AlertHandler struct {
+	api *api
+}
+
+func NewAlertHandler(a *api) *AlertHandler {
+	return &AlertHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary create price alert
+// @Description crossesAbove and crossesBelow fire when trade price crosses price,
+// @Description movesPercent fires when trade price moves by percent either way within windowMs (up to 24h),
+// @Description fired alerts are sent to /connect and /bit-mex/stream as {"type": "alert", "data": {...}},
+// @Description alert without repeat is deactivated once it fires
+// @Produce json
+// @Tags Alerts
+// @Security ApiKeyAuth
+// @Param Alert  body alert.Request  true "Alert"
+// @Success 200 {object} model.Alert
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/alerts [post]
+//
+//nolint:varnamelen
+func (h *AlertHandler) Create(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Alerts.Create.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	newAlert := &model.Alert{UserID: userID, CreatedAt: time.Now().UTC()}
+	if !h.bindAlert(c, newAlert) {
+		return
+	}
+
+	if err = h.api.postgresStore.Alert.Create(newAlert); err != nil {
+		logger.Errorf("Alerts.Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.alerts.Put(*newAlert)
+
+	c.JSON(http.StatusOK, newAlert)
+}
+
+// List
+// @Summary get price alerts of the user
+// @Produce json
+// @Tags Alerts
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Alert
+// @Failure 401 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/alerts [get]
+//
+//nolint:varnamelen
+func (h *AlertHandler) List(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Alerts.List.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	alerts, err := h.api.postgresStore.Alert.List(userID)
+	if err != nil {
+		logger.Errorf("Alerts.List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// Get
+// @Summary get price alert
+// @Produce json
+// @Tags Alerts
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Alert ID"
+// @Success 200 {object} model.Alert
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/alerts/{id} [get]
+//
+//nolint:varnamelen
+func (h *AlertHandler) Get(c *gin.Context) {
+	userAlert, ok := h.getAlert(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, userAlert)
+}
+
+// Update
+// @Summary replace rule of price alert
+// @Description active defaults to true, so updated alert is armed again
+// @Produce json
+// @Tags Alerts
+// @Security ApiKeyAuth
+// @Param id     path  string         true  "Alert ID"
+// @Param Alert  body  alert.Request  true  "Alert"
+// @Success 200 {object} model.Alert
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/alerts/{id} [put]
+//
+//nolint:varnamelen
+func (h *AlertHandler) Update(c *gin.Context) {
+	userAlert, ok := h.getAlert(c)
+	if !ok {
+		return
+	}
+
+	if !h.bindAlert(c, userAlert) {
+		return
+	}
+
+	err := h.api.postgresStore.Alert.Update(userAlert)
+	if err != nil {
+		logger.Errorf("Alerts.Update.Update", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrAlertNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.alerts.Put(*userAlert)
+
+	c.JSON(http.StatusOK, userAlert)
+}
+
+// Delete
+// @Summary delete price alert, its history is kept
+// @Produce json
+// @Tags Alerts
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Alert ID"
+// @Success 200 {object} model.Alert
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/alerts/{id} [delete]
+//
+//nolint:varnamelen
+func (h *AlertHandler) Delete(c *gin.Context) {
+	userAlert, ok := h.getAlert(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Alert.Delete(userAlert.UserID, userAlert.ID)
+	if err != nil {
+		logger.Errorf("Alerts.Delete.Delete", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrAlertNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.alerts.Remove(userAlert.ID)
+
+	c.JSON(http.StatusOK, userAlert)
+}
+
+// History
+// @Summary get fired price alerts of the user, the newest first
+// @Produce json
+// @Tags Alerts
+// @Security ApiKeyAuth
+// @Param limit  query int  false "Number of alerts up to 1000, 100 by default"
+// @Success 200 {array} model.AlertEvent
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/alerts/history [get]
+//
+//nolint:varnamelen
+func (h *AlertHandler) History(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Alerts.History.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAlertEventsLimit)))
+	if err != nil || limit <= 0 || limit > maxAlertEventsLimit {
+		logger.Errorf("Alerts.History.Atoi", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	events, err := h.api.postgresStore.Alert.ListEvents(userID, limit)
+	if err != nil {
+		logger.Errorf("Alerts.History.ListEvents", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// getAlert loads alert of id path parameter owned by the user of the request, error response is written on failure.
+func (h *AlertHandler) getAlert(c *gin.Context) (*model.Alert, bool) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Alerts.getAlert.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, false
+	}
+
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("Alerts.getAlert.FromString", err)
+		c.JSON(http.StatusNotFound, model.ErrAlertNotFound)
+
+		return nil, false
+	}
+
+	userAlert, err := h.api.postgresStore.Alert.Get(userID, id)
+	if err != nil {
+		logger.Errorf("Alerts.getAlert.Get", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrAlertNotFound)
+
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	return userAlert, true
+}
+
+// bindAlert applies alert.Request of the body to the alert, error response is written on failure.
+func (h *AlertHandler) bindAlert(c *gin.Context, userAlert *model.Alert) bool {
+	var request alert.Request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Errorf("Alerts.bindAlert.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return false
+	}
+
+	if _, ok := h.api.symbolUser.Get(request.Symbol); !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectSymbol)
+
+		return false
+	}
+
+	request.Apply(userAlert)
+
+	if !userAlert.IsValid() {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectAlert)
+
+		return false
+	}
+
+	return true
+}
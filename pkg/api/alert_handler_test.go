@@ -0,0 +1,252 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/alerts"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/alert"
+	"bitmex-api/pkg/model/ui/stream"
+)
+
+func TestAlertHandler_Fire(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	p.alertRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(newAlert *model.Alert) error {
+		newAlert.ID = uuid.NewV4()
+
+		return nil
+	})
+
+	var created model.Alert
+	status := p.do(t, http.MethodPost, "/api/v1/alerts", alert.Request{
+		Symbol:    "XBTUSD",
+		Condition: model.CrossesAbove,
+		Price:     70000,
+	}, &created)
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, created.Active)
+	assert.NotEqual(t, uuid.Nil, created.ID)
+
+	triggered := make(chan model.AlertEvent, 1)
+	p.alertRepo.EXPECT().Trigger(gomock.Any(), true).DoAndReturn(func(event *model.AlertEvent, _ bool) error {
+		event.ID = 1
+		triggered <- *event
+
+		return nil
+	})
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500},
+	))
+
+	var frame struct {
+		Type stream.MessageType `json:"type"`
+		Data model.AlertEvent   `json:"data"`
+	}
+	readFrame(t, conn, stream.AlertMessageType, &frame)
+	assert.Equal(t, int64(1), frame.Data.ID)
+	assert.Equal(t, created.ID, frame.Data.AlertID)
+	assert.InDelta(t, 70500, frame.Data.Price, 0)
+	assert.InDelta(t, 70000, frame.Data.ReferencePrice, 0)
+
+	event := <-triggered
+	assert.Equal(t, p.userID, event.UserID)
+
+	// fired alert without repeat is off
+	p.api.alerts.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69000})
+	assert.Empty(t, p.api.alerts.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500}))
+}
+
+func TestAlertHandler_SlowTrigger(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+	conn := p.connectPath(t, "/connect?version=2")
+
+	p.api.alerts.Put(model.Alert{
+		ID:        uuid.NewV4(),
+		UserID:    p.userID,
+		Symbol:    "XBTUSD",
+		Condition: model.CrossesAbove,
+		Price:     70000,
+		Repeat:    true,
+		Active:    true,
+	})
+
+	release := make(chan struct{})
+	p.alertRepo.EXPECT().Trigger(gomock.Any(), false).DoAndReturn(func(event *model.AlertEvent, _ bool) error {
+		<-release
+		event.ID = 1
+
+		return nil
+	})
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70600},
+	))
+
+	// trades are not held up by the alert history write
+	var trade struct {
+		Type stream.MessageType     `json:"type"`
+		Data bitmex.TradeDataRecord `json:"data"`
+	}
+	for _, price := range []float64{69000, 70500, 70600} {
+		readFrame(t, conn, stream.TradeMessageType, &trade)
+		assert.InDelta(t, price, trade.Data.Price, 0)
+	}
+
+	close(release)
+
+	var frame struct {
+		Type stream.MessageType `json:"type"`
+		Data model.AlertEvent   `json:"data"`
+	}
+	readFrame(t, conn, stream.AlertMessageType, &frame)
+	assert.Equal(t, int64(1), frame.Data.ID)
+}
+
+func TestAlertHandler_TriggerError(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+	conn := p.connectPath(t, "/connect?version=2")
+
+	p.api.alerts.Put(model.Alert{
+		ID:        uuid.NewV4(),
+		UserID:    p.userID,
+		Symbol:    "XBTUSD",
+		Condition: model.CrossesAbove,
+		Price:     70000,
+		Repeat:    true,
+		Active:    true,
+	})
+
+	gomock.InOrder(
+		p.alertRepo.EXPECT().Trigger(gomock.Any(), false).Return(errors.New("connection reset")),
+		p.alertRepo.EXPECT().Trigger(gomock.Any(), false).DoAndReturn(func(event *model.AlertEvent, _ bool) error {
+			event.ID = 2
+
+			return nil
+		}),
+	)
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500},
+	))
+
+	// alert which failed to be stored is not sent
+	var frame struct {
+		Type stream.MessageType `json:"type"`
+		Data model.AlertEvent   `json:"data"`
+	}
+	readFrame(t, conn, stream.AlertMessageType, &frame)
+	assert.Equal(t, int64(2), frame.Data.ID)
+}
+
+func TestAlertHandler_QueueFull(t *testing.T) {
+	// nil queue is always full
+	api := &api{alerts: alerts.NewEngine()}
+
+	once := model.Alert{ID: uuid.NewV4(), Symbol: "XBTUSD", Condition: model.CrossesAbove, Price: 70000, Active: true}
+	api.alerts.Put(once)
+
+	for i := 0; i < 2; i++ {
+		api.alerts.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69000})
+		fired := api.alerts.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500})
+		require.Len(t, fired, 1)
+		assert.Equal(t, once.ID, fired[0].Alert.ID)
+
+		// dropped alert which does not repeat fires again
+		api.BitMex().queueAlerts(fired)
+	}
+}
+
+func TestAlertHandler_Validate(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	var statusError model.StatusError
+	status := p.do(t, http.MethodPost, "/api/v1/alerts", alert.Request{
+		Symbol:    "UNKNOWN",
+		Condition: model.CrossesAbove,
+		Price:     70000,
+	}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrIncorrectSymbol, statusError)
+
+	status = p.do(t, http.MethodPost, "/api/v1/alerts", alert.Request{
+		Symbol:    "XBTUSD",
+		Condition: model.MovesPercent,
+		Percent:   2,
+	}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrIncorrectAlert, statusError)
+
+	status = p.do(t, http.MethodGet, "/api/v1/alerts/history?limit=0", nil, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status = p.do(t, http.MethodGet, "/api/v1/alerts/unknown", nil, &statusError)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, model.ErrAlertNotFound, statusError)
+}
+
+func TestAlertHandler_UpdateDelete(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	stored := model.Alert{
+		ID:        uuid.NewV4(),
+		UserID:    p.userID,
+		Symbol:    "XBTUSD",
+		Condition: model.CrossesBelow,
+		Price:     60000,
+	}
+	p.alertRepo.EXPECT().Get(p.userID, stored.ID).DoAndReturn(func(uuid.UUID, uuid.UUID) (*model.Alert, error) {
+		userAlert := stored
+
+		return &userAlert, nil
+	}).Times(2)
+	p.alertRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+	var updated model.Alert
+	status := p.do(t, http.MethodPut, "/api/v1/alerts/"+stored.ID.String(), alert.Request{
+		Symbol:    "ETHUSD",
+		Condition: model.MovesPercent,
+		Percent:   2,
+		WindowMs:  300000,
+		Repeat:    true,
+	}, &updated)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ETHUSD", updated.Symbol)
+	assert.True(t, updated.Active)
+	assert.Zero(t, updated.Price)
+
+	p.api.alerts.Add(bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000})
+	assert.Len(t, p.api.alerts.Add(bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2100}), 1)
+
+	p.alertRepo.EXPECT().Delete(p.userID, stored.ID).Return(nil)
+
+	var deleted model.Alert
+	status = p.do(t, http.MethodDelete, "/api/v1/alerts/"+stored.ID.String(), nil, &deleted)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, stored.ID, deleted.ID)
+	assert.Empty(t, p.api.alerts.Add(bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2300}))
+
+	p.alertRepo.EXPECT().ListEvents(p.userID, 10).Return([]model.AlertEvent{{ID: 1, AlertID: stored.ID}}, nil)
+
+	var events []model.AlertEvent
+	status = p.do(t, http.MethodGet, "/api/v1/alerts/history?limit=10", nil, &events)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, events, 1)
+	assert.Equal(t, stored.ID, events[0].AlertID)
+}
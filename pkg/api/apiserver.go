@@ -4,36 +4,47 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
 	uuid "github.com/satori/go.uuid"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"google.golang.org/grpc"
 
 	_ "bitmex-api/docs"
+	"bitmex-api/pkg/alerts"
 	"bitmex-api/pkg/authmiddleware"
+	"bitmex-api/pkg/bitmexclient"
+	"bitmex-api/pkg/candles"
 	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/lastvalue"
 	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
 	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+	"bitmex-api/pkg/notify"
+	"bitmex-api/pkg/orderbook"
+	"bitmex-api/pkg/paper"
 	"bitmex-api/pkg/store"
+	"bitmex-api/pkg/tradehistory"
+	"bitmex-api/pkg/webhook"
 )
 
 const (
-	bitMexWebSocketURL     = "wss://testnet.bitmex.com/realtime"
-	bitMexAPIActiveSymbols = "https://testnet.bitmex.com/api/v1/instrument/active"
-	goroutineCount         = 2
-	subscribeMessageStart  = `{"op": "subscribe", "args": [`
-	subscribeMessageEnd    = `]}`
+	bitMexActiveSymbolsPath = "/api/v1/instrument/active"
+	goroutineCount          = 11
 )
 
 type Server struct {
 	*http.Server
+
+	GRPC *grpc.Server
 }
 
 type api struct {
@@ -42,16 +53,35 @@ type api struct {
 	config        *config.ServerConfig
 	auth          authmiddleware.AuthMiddleware
 
-	bitMexWSConn *websocket.Conn
+	bitMexClient     *bitmexclient.Client
+	bitMexHTTPClient *http.Client
+	orderBooks       *orderbook.Manager
+	candles          *candles.Aggregator
+	tradeWriter      *tradehistory.Writer
+	lastValues       *lastvalue.Cache
+	alerts           *alerts.Engine
+	firedAlerts      chan alerts.Fired
+	webhooks         *webhook.Dispatcher
+	mailer           *notify.Mailer
+	paper            *paper.Engine
+	executions       chan paper.Execution
+	symbolsRefresh   chan struct{}
 
 	allSymbols allSymbols
 	symbolUser symbolUser
-	userWSConn userWSConn
+	topicUser  symbolUser
+	sessions   wsSessions
+	sseStreams sseStreams
 
 	authHandler          *AuthHandler
 	userHandler          *UserHandler
 	bitMexHandler        *BitMexHandler
 	userWebSocketHandler *UserWebSocketHandler
+	alertHandler         *AlertHandler
+	webhookHandler       *WebhookHandler
+	emailHandler         *EmailHandler
+	paperHandler         *PaperHandler
+	watchlistHandler     *WatchlistHandler
 }
 
 type symbolUser struct {
@@ -66,21 +96,15 @@ type allSymbols struct {
 	mu sync.RWMutex
 }
 
-type userWSConn struct {
-	userConn map[uuid.UUID]*websocket.Conn
-	connUser map[*websocket.Conn]uuid.UUID
-
-	mu sync.RWMutex
-}
-
 func NewServer(
 	ctx context.Context,
 	config *config.ServerConfig,
 	postgresStore *store.Store,
 	auth authmiddleware.AuthMiddleware,
+	mailer *notify.Mailer,
 	wg *sync.WaitGroup,
 ) *Server {
-	handler := newAPI(ctx, config, postgresStore, auth, wg)
+	handler := newAPI(ctx, config, postgresStore, auth, mailer, wg)
 
 	srv := &http.Server{
 		Addr:              config.ServerPort,
@@ -90,6 +114,28 @@ func NewServer(
 
 	return &Server{
 		Server: srv,
+		GRPC:   newGRPCServer(handler),
+	}
+}
+
+// StopGRPC waits for gRPC calls to finish and stops the server forcibly when they take longer than timeout.
+// Streams of trades are ended by CloseSessions, so it is called once the context of the server is done.
+func (s *Server) StopGRPC(timeout time.Duration) {
+	stopped := make(chan struct{})
+
+	go func() {
+		s.GRPC.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+	case <-timer.C:
+		logger.Infof("gRPC server did not stop in %s, closing connections", timeout)
+		s.GRPC.Stop()
 	}
 }
 
@@ -99,12 +145,28 @@ func newAPI(
 	config *config.ServerConfig,
 	postgresStore *store.Store,
 	auth authmiddleware.AuthMiddleware,
+	mailer *notify.Mailer,
 	wg *sync.WaitGroup,
 ) *api {
 	api := &api{
 		config:        config,
 		postgresStore: postgresStore,
 		auth:          auth,
+		mailer:        mailer,
+		bitMexClient:  bitmexclient.New(&config.BitMex),
+		bitMexHTTPClient: &http.Client{
+			Timeout: config.BitMex.HTTPTimeout.Duration,
+		},
+		orderBooks:     orderbook.NewManager(),
+		candles:        candles.NewAggregator(config.Candles.History),
+		tradeWriter:    tradehistory.NewWriter(postgresStore.Trade, &config.Trades),
+		lastValues:     lastvalue.NewCache(),
+		alerts:         alerts.NewEngine(),
+		firedAlerts:    make(chan alerts.Fired, alertsQueueSize),
+		webhooks:       webhook.NewDispatcher(postgresStore.Webhook, &config.Webhooks),
+		paper:          paper.NewEngine(),
+		executions:     make(chan paper.Execution, executionsQueueSize),
+		symbolsRefresh: make(chan struct{}, 1),
 		allSymbols: allSymbols{
 			allSymbols: make([]string, 0),
 			mu:         sync.RWMutex{},
@@ -113,22 +175,39 @@ func newAPI(
 			symbolUserSubscriptions: make(map[string][]uuid.UUID),
 			mu:                      sync.RWMutex{},
 		},
-		userWSConn: userWSConn{
-			userConn: make(map[uuid.UUID]*websocket.Conn),
-			connUser: make(map[*websocket.Conn]uuid.UUID),
-			mu:       sync.RWMutex{},
+		topicUser: symbolUser{
+			symbolUserSubscriptions: make(map[string][]uuid.UUID),
+			mu:                      sync.RWMutex{},
+		},
+		sessions: wsSessions{
+			sessions:     make(map[uuid.UUID]*wsSession),
+			userSessions: make(map[uuid.UUID][]*wsSession),
+			mu:           sync.RWMutex{},
+		},
+		sseStreams: sseStreams{
+			streams: make(map[uuid.UUID]*sseStream),
+			mu:      sync.Mutex{},
 		},
 	}
-	wg.Add(goroutineCount)
-
-	channelConnected := make(chan struct{})
-	go api.connectToBitMex(ctx, wg, channelConnected)
-	<-channelConnected
 	api.updateSymbols()
-	api.subscribeToAllSymbols()
-	api.updateUserSubscriptionFromDB()
+	api.subscribeUserTopicsFromDB()
+	api.loadAlerts()
+	api.loadWebhooks()
+	api.loadPaperOrders()
+
+	wg.Add(goroutineCount)
 
+	go api.bitMexClient.Run(ctx, wg)
+	go api.RefreshSymbols(ctx, wg)
 	go api.BitMex().SendUsersDataOnUpdate(ctx, wg)
+	go api.BitMex().CloseCandles(ctx, wg)
+	go api.BitMex().DeliverAlerts(ctx, wg)
+	go api.BitMex().SettleExecutions(ctx, wg)
+	go api.tradeWriter.Run(ctx, wg)
+	go api.webhooks.Run(ctx, wg)
+	go api.mailer.Run(ctx, wg)
+	go api.UserWebSocket().CloseSessions(ctx, wg)
+	go api.UserWebSocket().CheckTokens(ctx, wg)
 
 	api.router = configureRouter(api)
 
@@ -168,6 +247,26 @@ func (a *api) Auth() *AuthHandler {
 	return a.authHandler
 }
 
+// authorizeToken validates access token and checks that its user exists and has the role of the token,
+// as AuthMiddleware.Authorize does for REST API.
+func (a *api) authorizeToken(token string) (*authmiddleware.AccessClaims, model.Error) {
+	claims, err := a.auth.Validate(token)
+	if err != nil || claims == nil {
+		logger.Errorf("authorizeToken.Validate", err)
+
+		return nil, model.ErrUnauthorized
+	}
+
+	userDB, exists := a.postgresStore.Auth.Get(claims.BaseClaims.ID)
+	if !exists || userDB.Role != claims.BaseClaims.Role {
+		logger.Errorf("authorizeToken.Get", "user does not exist or role is changed")
+
+		return nil, model.ErrRefreshExpired
+	}
+
+	return claims, nil
+}
+
 //nolint:gocritic
 func (a *api) getUserIDFromHeader(c *gin.Context) (uuid.UUID, error) {
 	token := strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", -1)
@@ -176,6 +275,13 @@ func (a *api) getUserIDFromHeader(c *gin.Context) (uuid.UUID, error) {
 	return userID, err
 }
 
+//nolint:gocritic
+func (a *api) getUserRoleFromHeader(c *gin.Context) (model.UserRole, error) {
+	token := strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", -1)
+
+	return a.auth.GetUserRole(token)
+}
+
 func (a *api) User() *UserHandler {
 	if a.userHandler == nil {
 		a.userHandler = NewUserHandler(a)
@@ -200,116 +306,259 @@ func (a *api) UserWebSocket() *UserWebSocketHandler {
 	return a.userWebSocketHandler
 }
 
-//nolint:gosimple
-func (a *api) connectToBitMex(ctx context.Context, wg *sync.WaitGroup, channelConnected chan struct{}) {
-	defer wg.Done()
+func (a *api) Alert() *AlertHandler {
+	if a.alertHandler == nil {
+		a.alertHandler = NewAlertHandler(a)
+	}
 
-	c, resp, err := websocket.DefaultDialer.Dial(bitMexWebSocketURL, nil)
-	defer func() {
-		if err = resp.Body.Close(); err != nil {
-			logger.Errorf("error close response body", err)
-		}
-		if err = c.Close(); err != nil {
-			logger.Errorf("error close connection", err)
-		}
-	}()
-	if err != nil {
-		logger.Errorf("Dial error", err)
+	return a.alertHandler
+}
+
+func (a *api) Webhook() *WebhookHandler {
+	if a.webhookHandler == nil {
+		a.webhookHandler = NewWebhookHandler(a)
 	}
 
-	a.bitMexWSConn = c
-	channelConnected <- struct{}{}
+	return a.webhookHandler
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			unsubscribeMessage := `{"op": "unsubscribe", "args": [`
-
-			symbols := a.allSymbols.GetAll()
-			for i, symbol := range symbols {
-				if i == len(symbols)-1 {
-					unsubscribeMessage += fmt.Sprintf(`"trade:%s"`, symbol)
-				}
-				unsubscribeMessage += fmt.Sprintf(`"trade:%s", `, symbol)
-			}
+func (a *api) Email() *EmailHandler {
+	if a.emailHandler == nil {
+		a.emailHandler = NewEmailHandler(a)
+	}
 
-			unsubscribeMessage += subscribeMessageEnd
+	return a.emailHandler
+}
 
-			if err = c.WriteMessage(websocket.TextMessage, []byte(unsubscribeMessage)); err != nil {
-				logger.Errorf("Unsubscribe error", err)
-			}
-			time.Sleep(1 * time.Second)
+func (a *api) Paper() *PaperHandler {
+	if a.paperHandler == nil {
+		a.paperHandler = NewPaperHandler(a)
+	}
 
-			logger.Infof("connectToBitMex done")
+	return a.paperHandler
+}
 
-			return
-		}
+func (a *api) Watchlist() *WatchlistHandler {
+	if a.watchlistHandler == nil {
+		a.watchlistHandler = NewWatchlistHandler(a)
 	}
+
+	return a.watchlistHandler
 }
 
+// subscribeToAllSymbols is safe to call repeatedly, the client skips topics it already has.
 func (a *api) subscribeToAllSymbols() {
-	subscribeMessage := subscribeMessageStart
-	newCycle := true
 	symbols := a.allSymbols.GetAll()
-	for i, symbol := range symbols {
-		if newCycle {
-			subscribeMessage += fmt.Sprintf(`"trade:%s"`, symbol)
-			newCycle = false
-		}
+	topics := make([]string, 0, len(symbols))
 
-		subscribeMessage += fmt.Sprintf(`, "trade:%s"`, symbol)
-		if (i+1)%15 == 0 {
-			subscribeMessage += subscribeMessageEnd
+	for _, symbol := range symbols {
+		topics = append(topics, bitmex.Topic(bitmex.TradeTable, symbol))
+	}
 
-			logger.Infof(subscribeMessage)
+	if err := a.bitMexClient.Subscribe(topics...); err != nil {
+		logger.Errorf("Subscribe error", err)
+	}
+}
 
-			if err := a.bitMexWSConn.WriteMessage(websocket.TextMessage, []byte(subscribeMessage)); err != nil {
-				logger.Errorf("Unsubscribe error", err)
-			}
+// subscribeUserTopicsFromDB warms up upstream topics of stored subscriptions, so sessions get data right after connect.
+func (a *api) subscribeUserTopicsFromDB() {
+	allUsers, err := a.postgresStore.User.GetAll()
+	if err != nil {
+		logger.Errorf("error get all users", err)
+	}
 
-			subscribeMessage = subscribeMessageStart
-			newCycle = true
-		}
+	for _, user := range allUsers {
+		a.subscribeUpstream(user.SubscriptionTopics)
 	}
+}
 
-	subscribeMessage += subscribeMessageEnd
+// loadAlerts starts evaluating stored active alerts of all users.
+func (a *api) loadAlerts() {
+	active, err := a.postgresStore.Alert.GetActive()
+	if err != nil {
+		logger.Errorf("error get active alerts", err)
 
-	if err := a.bitMexWSConn.WriteMessage(websocket.TextMessage, []byte(subscribeMessage)); err != nil {
-		logger.Errorf("Unsubscribe error", err)
+		return
 	}
+
+	a.alerts.Load(active)
 }
 
-func (a *api) updateUserSubscriptionFromDB() {
-	allUsers, err := a.postgresStore.User.GetAll()
+// loadWebhooks starts delivering events to stored active webhooks of all users.
+func (a *api) loadWebhooks() {
+	active, err := a.postgresStore.Webhook.GetActive()
 	if err != nil {
-		logger.Errorf("error get all users", err)
+		logger.Errorf("error get active webhooks", err)
+
+		return
 	}
 
-	for _, user := range allUsers {
-		if !user.Subscription {
-			continue
+	a.webhooks.Load(active)
+}
+
+// loadPaperOrders starts filling stored open paper orders of all users.
+func (a *api) loadPaperOrders() {
+	open, err := a.postgresStore.Paper.GetOpenOrders()
+	if err != nil {
+		logger.Errorf("error get open paper orders", err)
+
+		return
+	}
+
+	a.paper.Load(open)
+}
+
+// route moves subscriber between stream routes when its subscriptions change from before to after.
+func (a *api) route(subscriberID uuid.UUID, before, after subscription.Subscriptions) {
+	beforeSymbols, afterSymbols := a.tradeSymbols(before), a.tradeSymbols(after)
+
+	for _, symbol := range beforeSymbols {
+		if !slices.Contains(afterSymbols, symbol) {
+			a.symbolUser.Delete(symbol, subscriberID)
+		}
+	}
+
+	for _, symbol := range afterSymbols {
+		if !slices.Contains(beforeSymbols, symbol) {
+			a.symbolUser.Add(symbol, subscriberID)
 		}
-		if len(user.SubscriptionSymbols) == 0 {
-			allSymbolNames := a.allSymbols.GetAll()
-			for _, symbolName := range allSymbolNames {
-				usersSubscribedToSymbol, _ := a.symbolUser.Get(symbolName)
+	}
+
+	added := make([]string, 0, len(after.Topics))
 
-				a.symbolUser.Insert(symbolName, append(usersSubscribedToSymbol, user.UserID))
+	for _, topic := range before.Topics {
+		if !slices.Contains(after.Topics, topic) {
+			a.topicUser.Delete(topic, subscriberID)
+		}
+	}
+
+	for _, topic := range after.Topics {
+		if !slices.Contains(before.Topics, topic) {
+			a.topicUser.Add(topic, subscriberID)
+			added = append(added, topic)
+		}
+	}
+
+	a.subscribeUpstream(added)
+}
+
+// snapshots returns the last trade and quote of every symbol subscribed with after but not with before.
+func (a *api) snapshots(before, after subscription.Subscriptions) []stream.Snapshot {
+	snapshots := make([]stream.Snapshot, 0)
+	snapshot := func(symbol string) *stream.Snapshot {
+		for i := range snapshots {
+			if snapshots[i].Symbol == symbol {
+				return &snapshots[i]
 			}
 		}
-		for _, symbol := range user.SubscriptionSymbols {
-			usersSubscribedToSymbol, _ := a.symbolUser.Get(symbol)
 
-			a.symbolUser.Insert(symbol, append(usersSubscribedToSymbol, user.UserID))
+		snapshots = append(snapshots, stream.Snapshot{Symbol: symbol})
+
+		return &snapshots[len(snapshots)-1]
+	}
+
+	beforeSymbols := a.tradeSymbols(before)
+
+	for _, symbol := range a.tradeSymbols(after) {
+		if slices.Contains(beforeSymbols, symbol) {
+			continue
+		}
+
+		if record, ok := a.lastValues.Trade(symbol); ok {
+			snapshot(symbol).Trade = &record
+		}
+	}
+
+	for _, topic := range after.Topics {
+		channel, symbol, _ := strings.Cut(topic, ":")
+		if subscription.Channel(channel) != subscription.QuoteChannel || slices.Contains(before.Topics, topic) {
+			continue
+		}
+
+		if record, ok := a.lastValues.Quote(symbol); ok {
+			snapshot(symbol).Quote = &record
 		}
 	}
+
+	return snapshots
+}
+
+// tradeSymbols returns symbols whose trades are received with subscriptions.
+func (a *api) tradeSymbols(subscriptions subscription.Subscriptions) []string {
+	if !subscriptions.Trade {
+		return nil
+	}
+
+	if len(subscriptions.TradeSymbols) == 0 {
+		return a.allSymbols.GetAll()
+	}
+
+	return subscriptions.TradeSymbols
 }
 
+// subscribeUpstream makes sure topics are streamed from BitMex.
+func (a *api) subscribeUpstream(topics []string) {
+	if len(topics) == 0 || a.bitMexClient == nil {
+		return
+	}
+
+	upstreamTopics := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		upstreamTopics = append(upstreamTopics, a.upstreamTopic(topic))
+	}
+
+	if err := a.bitMexClient.Subscribe(upstreamTopics...); err != nil {
+		logger.Errorf("Subscribe error", err)
+	}
+}
+
+// upstreamTopic maps user topic channel:symbol to BitMex table topic.
+// Candles are built from trades, so candle topics map to trade topic.
+func (a *api) upstreamTopic(topic string) string {
+	channel, symbol, _ := strings.Cut(topic, ":")
+	if subscription.Channel(channel) == subscription.OrderBookChannel {
+		return bitmex.Topic(a.config.BitMex.OrderBookTable, symbol)
+	}
+
+	if _, ok := subscription.Channel(channel).CandleInterval(); ok {
+		return bitmex.Topic(bitmex.TradeTable, symbol)
+	}
+
+	return topic
+}
+
+// requestSymbols asks RefreshSymbols to update symbols, request made while one is waiting is merged with it.
+//
 //nolint:noctx
+func (a *api) requestSymbols() {
+	select {
+	case a.symbolsRefresh <- struct{}{}:
+	default:
+	}
+}
+
+// RefreshSymbols updates symbols on request until ctx is done.
+func (a *api) RefreshSymbols(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("refreshSymbols done")
+
+			return
+		case <-a.symbolsRefresh:
+			a.updateSymbols()
+		}
+	}
+}
+
 func (a *api) updateSymbols() {
-	response, err := http.Get(bitMexAPIActiveSymbols)
+	response, err := a.bitMexHTTPClient.Get(a.config.BitMex.RestBaseURL() + bitMexActiveSymbolsPath)
 	if err != nil {
 		logger.Errorf("HTTP request error", err)
+
+		return
 	}
 	defer response.Body.Close()
 
@@ -317,10 +566,20 @@ func (a *api) updateSymbols() {
 	err = json.NewDecoder(response.Body).Decode(&symbols)
 	if err != nil {
 		logger.Errorf("JSON decoding error", err)
+
+		return
 	}
 
+	added := make([]string, 0)
+
 	for _, symbol := range symbols {
-		a.allSymbols.Update(symbol.Symbol)
+		if a.allSymbols.Update(symbol.Symbol) {
+			added = append(added, symbol.Symbol)
+		}
+	}
+
+	if len(added) != 0 && a.bitMexClient != nil {
+		a.subscribeToAllSymbols()
 	}
 
 	symbolNames := a.allSymbols.GetAll()
@@ -331,6 +590,18 @@ func (a *api) updateSymbols() {
 			a.symbolUser.Insert(symbolName, []uuid.UUID{})
 		}
 	}
+
+	// sessions subscribed to trades of all symbols get the new ones too
+	for _, session := range a.sessions.GetAll() {
+		subscriptions := session.Subscriptions()
+		if !subscriptions.Trade || len(subscriptions.TradeSymbols) != 0 {
+			continue
+		}
+
+		for _, symbol := range added {
+			a.symbolUser.Add(symbol, session.id)
+		}
+	}
 }
 
 func (m *allSymbols) GetAll() []string {
@@ -341,11 +612,18 @@ func (m *allSymbols) GetAll() []string {
 	return symbols
 }
 
-func (m *allSymbols) Update(symbol string) {
-	symbols := append(m.GetAll(), symbol)
+// Update adds symbol if it is not known yet and reports whether it was added.
+func (m *allSymbols) Update(symbol string) bool {
 	m.mu.Lock()
-	m.allSymbols = symbols
-	m.mu.Unlock()
+	defer m.mu.Unlock()
+
+	if slices.Contains(m.allSymbols, symbol) {
+		return false
+	}
+
+	m.allSymbols = append(m.allSymbols, symbol)
+
+	return true
 }
 
 func (m *symbolUser) Get(symbol string) ([]uuid.UUID, bool) {
@@ -362,56 +640,24 @@ func (m *symbolUser) Insert(symbol string, users []uuid.UUID) {
 	m.mu.Unlock()
 }
 
-func (a *api) DeleteSymbolUser(symbol string, userID uuid.UUID) {
-	users, ok := a.symbolUser.Get(symbol)
-
-	if !ok {
-		a.updateSymbols()
-		users, ok = a.symbolUser.Get(symbol)
-
-		if !ok {
-			return
-		}
-	}
-
-	for i, u := range users {
-		if u == userID {
-			users = append(users[:i], users[i+1:]...)
-		}
-	}
-
-	a.symbolUser.mu.Lock()
-	a.symbolUser.symbolUserSubscriptions[symbol] = users
-	a.symbolUser.mu.Unlock()
-}
-
-func (m *userWSConn) GetConn(userID uuid.UUID) (*websocket.Conn, bool) {
-	m.mu.RLock()
-	conn, ok := m.userConn[userID]
-	m.mu.RUnlock()
-
-	return conn, ok
-}
-
-func (m *userWSConn) Delete(conn *websocket.Conn) {
-	m.mu.RLock()
-
-	userID, ok := m.connUser[conn]
-	if ok {
-		delete(m.connUser, conn)
-	}
-
-	_, ok = m.userConn[userID]
-	if ok {
-		delete(m.userConn, userID)
+// Add adds subscriber to key unless it is already there.
+// Subscribers are copied on write as slices returned by Get are iterated without the lock.
+func (m *symbolUser) Add(key string, id uuid.UUID) {
+	m.mu.Lock()
+	if users := m.symbolUserSubscriptions[key]; !slices.Contains(users, id) {
+		m.symbolUserSubscriptions[key] = append(slices.Clip(users), id)
 	}
-
-	m.mu.RUnlock()
+	m.mu.Unlock()
 }
 
-func (m *userWSConn) Create(conn *websocket.Conn, userID uuid.UUID) {
+// Delete removes subscriber from key, the new slice is built so that readers of the old one are not affected.
+func (m *symbolUser) Delete(key string, userID uuid.UUID) {
 	m.mu.Lock()
-	m.connUser[conn] = userID
-	m.userConn[userID] = conn
+	users := m.symbolUserSubscriptions[key]
+	if slices.Contains(users, userID) {
+		m.symbolUserSubscriptions[key] = slices.DeleteFunc(slices.Clone(users), func(u uuid.UUID) bool {
+			return u == userID
+		})
+	}
 	m.mu.Unlock()
 }
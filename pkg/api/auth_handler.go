@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
@@ -43,41 +44,51 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	if !user.IsValid(false) {
-		logger.Errorf("Login.Empty login or pass", err)
-		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+	tokens, status, statusError := h.login(user, c.ClientIP()+" "+c.Request.UserAgent())
+	if statusError != nil {
+		c.JSON(status, statusError)
 
 		return
 	}
 
+	c.JSON(http.StatusOK, tokens)
+}
+
+// login checks credentials of the user and creates tokens, status is HTTP status of the returned error.
+// Verified email of the user is notified of the login from client.
+func (h *AuthHandler) login(user *model.AuthUser, client string) (*authmiddleware.Tokens, int, model.Error) {
+	if !user.IsValid(false) {
+		logger.Errorf("Login.Empty login or pass", nil)
+
+		return nil, http.StatusBadRequest, model.ErrInvalidBody
+	}
+
 	userDB, err := h.api.postgresStore.Auth.GetByUsername(user.Username)
 	if err != nil {
 		logger.Errorf("Login.Get", err)
 		if err.Error() == model.NotFound {
-			c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
-		} else {
-			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+			return nil, http.StatusUnauthorized, model.ErrUnauthorized
 		}
 
-		return
+		return nil, http.StatusInternalServerError, model.ErrUnhealthy
 	}
 
 	if !authmiddleware.IsPasswordMatch(user.Password, userDB.Password) {
-		logger.Errorf("Login.IsPasswordMatch", err)
-		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+		logger.Errorf("Login.IsPasswordMatch", nil)
 
-		return
+		return nil, http.StatusUnauthorized, model.ErrUnauthorized
 	}
 
 	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role)
 	if err != nil {
 		logger.Errorf("Login.CreateTokens", err)
-		c.JSON(http.StatusBadRequest, model.ErrUnhealthy)
 
-		return
+		return nil, http.StatusBadRequest, model.ErrUnhealthy
 	}
 
-	c.JSON(http.StatusOK, tokens)
+	h.api.mailer.Login(h.api.emailRecipient(userDB.ID, false), time.Now(), client)
+
+	return tokens, http.StatusOK, nil
 }
 
 // Register
@@ -244,6 +255,8 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	h.api.mailer.PasswordChanged(h.api.emailRecipient(userID, false), time.Now())
+
 	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role)
 	if err != nil {
 		logger.Errorf("ChangePassword.CreateTokens", err)
@@ -0,0 +1,57 @@
+package api
+
+import "bytes"
+
+// sessionBatch collects frames produced from one upstream message. Sessions connected with batch=true
+// get them as JSON arrays of at most maxSize frames on flush, other sessions get every frame at once.
+// Batch is used by the goroutine handling the message only.
+type sessionBatch struct {
+	maxSize  int
+	frames   map[*wsSession][][]byte
+	sessions []*wsSession
+}
+
+func newSessionBatch(maxSize int) *sessionBatch {
+	return &sessionBatch{
+		maxSize: maxSize,
+		frames:  make(map[*wsSession][][]byte),
+	}
+}
+
+// send queues frame to the session or holds it until flush, key is used for frames sent at once only.
+func (b *sessionBatch) send(session *wsSession, key string, data []byte) {
+	if !session.batch {
+		session.send(key, data)
+
+		return
+	}
+
+	if _, ok := b.frames[session]; !ok {
+		b.sessions = append(b.sessions, session)
+	}
+
+	b.frames[session] = append(b.frames[session], data)
+}
+
+// flush queues held frames, batches are not conflated as they mix symbols and channels.
+func (b *sessionBatch) flush() {
+	for _, session := range b.sessions {
+		frames := b.frames[session]
+
+		for len(frames) > 0 {
+			size := min(b.maxSize, len(frames))
+			session.send("", joinFrames(frames[:size]))
+			frames = frames[size:]
+		}
+	}
+
+	clear(b.frames)
+	b.sessions = b.sessions[:0]
+}
+
+// joinFrames packs JSON frames into JSON array.
+func joinFrames(frames [][]byte) []byte {
+	data := append([]byte{'['}, bytes.Join(frames, []byte(","))...)
+
+	return append(data, ']')
+}
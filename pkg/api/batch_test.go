@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/sendqueue"
+)
+
+func TestUserWebSocketHandler_Batch(t *testing.T) {
+	p := initPipelineSessions(t, &model.User{Subscription: true}, config.SessionsConfig{
+		SendQueueSize:      100,
+		OverflowPolicy:     string(sendqueue.DropOldest),
+		WriteTimeout:       config.Duration{Duration: pipelineTimeout},
+		CompressionLevel:   9,
+		MaxBatchSize:       2,
+		PingInterval:       config.Duration{Duration: time.Second},
+		IdleTimeout:        config.Duration{Duration: pipelineTimeout},
+		TokenCheckInterval: config.Duration{Duration: time.Second},
+		TokenExpiryWarning: config.Duration{Duration: time.Minute},
+	})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+pipelineToken)
+
+	dialer := websocket.Dialer{EnableCompression: true}
+
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(p.server.URL, "http")+"/connect?version=2&batch=true", header)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	t.Cleanup(func() { _ = conn.Close() })
+	assert.Contains(t, resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	require.NoError(t, conn.WriteJSON(stream.Command{ID: "connect", Op: stream.PingCommand}))
+
+	var ack stream.AckMessage
+	readJSON(t, conn, &ack)
+	require.Equal(t, "connect", ack.ID)
+
+	// one upstream message of three trades is sent as batches of at most two frames
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000},
+		bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42001},
+	))
+
+	var batch []struct {
+		Type stream.MessageType     `json:"type"`
+		Data bitmex.TradeDataRecord `json:"data"`
+	}
+	readJSON(t, conn, &batch)
+	require.Len(t, batch, 2)
+	assert.Equal(t, stream.TradeMessageType, batch[0].Type)
+	assert.Equal(t, "XBTUSD", batch[0].Data.Symbol)
+	assert.Equal(t, "ETHUSD", batch[1].Data.Symbol)
+
+	readJSON(t, conn, &batch)
+	require.Len(t, batch, 1)
+	assert.InDelta(t, 42001, batch[0].Data.Price, 0)
+
+	sessions := p.api.sessions.GetByUser(p.userID)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Info().Batch)
+}
+
+func TestUserWebSocketHandler_IncorrectBatch(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	resp, err := http.Get(p.server.URL + "/connect?batch=yes")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -3,13 +3,34 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
 
+	"bitmex-api/pkg/alerts"
+	"bitmex-api/pkg/bitmexclient"
 	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
 	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/candle"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+	"bitmex-api/pkg/model/ui/trade"
+	"bitmex-api/pkg/paper"
+)
+
+const (
+	candlesCloseInterval = time.Second
+	alertsQueueSize      = 1024
+	executionsQueueSize  = 1024
+	defaultCandlesLimit  = 100
+	defaultTradesLimit   = 100
+	maxTradesLimit       = 1000
 )
 
 type BitMexHandler struct {
@@ -22,62 +43,606 @@ func NewBitMexHandler(a *api) *BitMexHandler {
 	}
 }
 
-//nolint:nestif,gocognit,cyclop
+// OrderBook
+// @Summary get order book snapshot
+// @Description book is mirrored on the first request, until then 503 is returned
+// @Produce json
+// @Tags BitMex
+// @Security ApiKeyAuth
+// @Param symbol  path  string  true  "Symbol"
+// @Param depth   query int     false "Levels per side, whole book when 0"
+// @Success 200 {object} depth.Snapshot
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 503 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/bit-mex/order-book/{symbol} [get]
+//
+//nolint:varnamelen
+func (h *BitMexHandler) OrderBook(c *gin.Context) {
+	symbol := c.Param("symbol")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("depth", "0"))
+	if err != nil || limit < 0 {
+		logger.Errorf("OrderBook.Atoi", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if _, ok := h.api.symbolUser.Get(symbol); !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectSymbol)
+
+		return
+	}
+
+	snapshot, ok := h.api.orderBooks.Snapshot(symbol, limit)
+	if !ok {
+		topic := h.api.upstreamTopic(bitmex.Topic(string(subscription.OrderBookChannel), symbol))
+		if err = h.api.bitMexClient.Subscribe(topic); err != nil {
+			logger.Errorf("OrderBook.Subscribe", err)
+		}
+
+		c.JSON(http.StatusServiceUnavailable, model.ErrOrderBookNotReady)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// Candles
+// @Summary get recent OHLCV bars
+// @Description bars are sorted from oldest to newest, the last one may be still open
+// @Produce json
+// @Tags BitMex
+// @Security ApiKeyAuth
+// @Param symbol    path  string  true  "Symbol"
+// @Param interval  query string  false "Bar interval: 1m, 5m, 15m, 1h or 1d, 1m by default"
+// @Param limit     query int     false "Number of bars, 100 by default"
+// @Success 200 {array} candle.Bar
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/bit-mex/candles/{symbol} [get]
+//
+//nolint:varnamelen
+func (h *BitMexHandler) Candles(c *gin.Context) {
+	symbol := c.Param("symbol")
+
+	interval := candle.Interval(c.DefaultQuery("interval", string(candle.Minute1)))
+	if _, ok := interval.Duration(); !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectInterval)
+
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultCandlesLimit)))
+	if err != nil || limit <= 0 {
+		logger.Errorf("Candles.Atoi", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if _, ok := h.api.symbolUser.Get(symbol); !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectSymbol)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, h.api.candles.Bars(symbol, interval, limit))
+}
+
+// Trades
+// @Summary get stored trades history
+// @Description trades are sorted by timestamp, pass nextCursor of the response to get the next page
+// @Produce json
+// @Tags BitMex
+// @Security ApiKeyAuth
+// @Param symbol  query string  true  "Symbol"
+// @Param from    query string  false "RFC3339 start, inclusive"
+// @Param to      query string  false "RFC3339 end, exclusive"
+// @Param cursor  query string  false "Cursor of the next page"
+// @Param limit   query int     false "Page size up to 1000, 100 by default"
+// @Success 200 {object} trade.Page
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/bit-mex/trades [get]
+//
+//nolint:varnamelen,cyclop
+func (h *BitMexHandler) Trades(c *gin.Context) {
+	query := model.TradeQuery{Symbol: c.Query("symbol")}
+
+	if _, ok := h.api.symbolUser.Get(query.Symbol); !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectSymbol)
+
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTradesLimit)))
+	if err != nil || limit <= 0 || limit > maxTradesLimit {
+		logger.Errorf("Trades.Atoi", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	query.From, query.To, err = timeRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		logger.Errorf("Trades.timeRange", err)
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectTimeRange)
+
+		return
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err := model.ParseTradeCursor(cursor)
+		if err != nil {
+			logger.Errorf("Trades.ParseTradeCursor", err)
+			c.JSON(http.StatusBadRequest, model.ErrIncorrectCursor)
+
+			return
+		}
+
+		query.After = &after
+	}
+
+	// one extra trade tells whether there is a next page
+	query.Limit = limit + 1
+
+	trades, err := h.api.postgresStore.Trade.List(query)
+	if err != nil {
+		logger.Errorf("Trades.List", err)
+		c.JSON(http.StatusBadRequest, model.ErrUnhealthy)
+
+		return
+	}
+
+	page := trade.Page{Trades: trades}
+	if len(trades) > limit {
+		page.Trades = trades[:limit]
+		last := page.Trades[limit-1]
+		page.NextCursor = model.TradeCursor{Timestamp: last.Timestamp, ID: last.ID}.Encode()
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func timeRange(from, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+
+	if from != "" {
+		if start, err = time.Parse(time.RFC3339, from); err != nil {
+			return start, end, err
+		}
+	}
+
+	if to != "" {
+		if end, err = time.Parse(time.RFC3339, to); err != nil {
+			return start, end, err
+		}
+	}
+
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return start, end, model.ErrIncorrectTimeRange
+	}
+
+	return start, end, nil
+}
+
 func (h *BitMexHandler) SendUsersDataOnUpdate(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
+
+	messages := h.api.bitMexClient.Messages()
+	states := h.api.bitMexClient.States()
+
 	for {
 		select {
 		case <-ctx.Done():
 			logger.Infof("sendUsersDataOnUpdate done")
 
 			return
-		default:
-			_, message, err := h.api.bitMexWSConn.ReadMessage()
-			if err != nil {
+		case state := <-states:
+			h.sendStatus(state)
+		case message, ok := <-messages:
+			if !ok {
+				logger.Infof("sendUsersDataOnUpdate done")
+
 				return
 			}
 
-			var tradeMessage bitmex.TradeMessage
-			if err := json.Unmarshal(message, &tradeMessage); err != nil {
-				logger.Errorf("JSON unmarshal error:", err)
+			h.handleMessage(message)
+		}
+	}
+}
+
+func (h *BitMexHandler) handleMessage(message []byte) {
+	var tableMessage bitmex.TableMessage
+	if err := json.Unmarshal(message, &tableMessage); err != nil {
+		logger.Errorf("JSON unmarshal error:", err)
+
+		return
+	}
+
+	batch := newSessionBatch(h.api.config.Sessions.MaxBatchSize)
+	defer batch.flush()
+
+	switch tableMessage.Table {
+	case "":
+		h.handleResponse(message)
+	case bitmex.TradeTable:
+		if tableMessage.Action == bitmex.InsertAction {
+			h.handleTrades(batch, tableMessage.Data)
+		}
+	case bitmex.QuoteTable:
+		if tableMessage.Action == bitmex.InsertAction || tableMessage.Action == bitmex.PartialAction {
+			h.handleQuotes(batch, tableMessage.Data)
+		}
+	case bitmex.OrderBookL2Table, bitmex.OrderBookL2_25Table:
+		h.handleOrderBook(batch, tableMessage.Table, tableMessage.Action, tableMessage.Data)
+	}
+}
+
+func (h *BitMexHandler) handleTrades(batch *sessionBatch, data json.RawMessage) {
+	var records []bitmex.TradeDataRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		logger.Errorf("JSON unmarshal error:", err)
+
+		return
+	}
+
+	for _, record := range records {
+		logger.Infof("Symbol: %s, Price: %f\n", record.Symbol, record.Price)
+		h.api.tradeWriter.Add(record)
+		h.api.lastValues.PutTrade(record)
+		h.queueAlerts(h.api.alerts.Add(record))
+		h.queueExecutions(h.api.paper.Add(record))
+		h.api.webhooks.Trade(record)
+
+		var sessions []uuid.UUID
+		sessions, ok := h.api.symbolUser.Get(record.Symbol)
+		if !ok {
+			// symbols are refreshed by RefreshSymbols, so the read loop never waits for BitMex REST API
+			logger.Errorf("unknown symbol:", record.Symbol)
+			h.api.requestSymbols()
+
+			continue
+		}
+
+		h.sendTrade(batch, sessions, record)
+		h.sendCandles(batch, h.api.candles.Add(record))
+	}
+}
+
+// queueAlerts hands fired alerts to DeliverAlerts, so the upstream read loop never waits for DB.
+// The alert is dropped when the queue is full, alert which does not repeat is put back to fire again.
+func (h *BitMexHandler) queueAlerts(fired []alerts.Fired) {
+	for _, f := range fired {
+		select {
+		case h.api.firedAlerts <- f:
+		default:
+			logger.Errorf("alerts queue is full, alert dropped", f.Alert.ID)
+
+			if !f.Alert.Repeat {
+				h.api.alerts.Put(f.Alert)
+			}
+		}
+	}
+}
+
+// DeliverAlerts delivers fired alerts until ctx is done, alerts queued by then are delivered on shutdown.
+func (h *BitMexHandler) DeliverAlerts(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
 
-				continue
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case f := <-h.api.firedAlerts:
+					h.sendAlert(f)
+				default:
+					logger.Infof("deliverAlerts done")
+
+					return
+				}
 			}
+		case f := <-h.api.firedAlerts:
+			h.sendAlert(f)
+		}
+	}
+}
+
+// sendAlert records fired alert in alert history and pushes it to every session, webhook and email of its user.
+// The alert is stored first to send the ID of the history record, alert which failed to be stored is not sent.
+func (h *BitMexHandler) sendAlert(f alerts.Fired) {
+	if err := h.api.postgresStore.Alert.Trigger(&f.Event, !f.Alert.Repeat); err != nil {
+		logger.Errorf("sendAlert.Trigger", err)
+
+		return
+	}
+
+	h.api.webhooks.Alert(f.Event)
+
+	h.api.mailer.Alert(h.api.emailRecipient(f.Event.UserID, true), f.Event)
+
+	data, err := json.Marshal(stream.Envelope{Type: stream.AlertMessageType, Data: f.Event})
+	if err != nil {
+		logger.Errorf("JSON marshal:", err)
+
+		return
+	}
+
+	for _, session := range h.api.sessions.GetByUser(f.Event.UserID) {
+		session.send("", data)
+	}
+}
+
+// queueExecutions hands fills of paper orders to SettleExecutions, so the upstream read loop never waits for DB.
+// The order is restored when the queue is full, so the fill is made by a later trade.
+func (h *BitMexHandler) queueExecutions(executions []paper.Execution) {
+	for _, e := range executions {
+		select {
+		case h.api.executions <- e:
+		default:
+			logger.Errorf("executions queue is full, fill dropped", e.Order.ID)
+			h.api.paper.Restore(e)
+		}
+	}
+}
 
-			if tradeMessage.Table == "trade" && tradeMessage.Action == "insert" {
-				for _, record := range tradeMessage.Data {
-					logger.Infof("Symbol: %s, Price: %f\n", record.Symbol, record.Price)
-					var users []uuid.UUID
-					users, ok := h.api.symbolUser.Get(record.Symbol)
-					if !ok {
-						h.api.updateSymbols()
-						users, ok = h.api.symbolUser.Get(record.Symbol)
-						if !ok {
-							logger.Errorf("invalid symbol:", record.Symbol)
-
-							continue
-						}
-					}
-
-					for _, userID := range users {
-						conn, ok := h.api.userWSConn.GetConn(userID)
-						if !ok {
-							continue
-						}
-
-						data, err := json.Marshal(record)
-						if err != nil {
-							logger.Errorf("JSON marshal:", err)
-
-							continue
-						}
-
-						if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-							logger.Errorf("Subscribe error:", err)
-						}
-					}
+// SettleExecutions settles fills of paper orders until ctx is done, fills queued by then are settled on shutdown.
+func (h *BitMexHandler) SettleExecutions(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-h.api.executions:
+					h.settleExecution(e)
+				default:
+					logger.Infof("settleExecutions done")
+
+					return
 				}
 			}
+		case e := <-h.api.executions:
+			h.settleExecution(e)
+		}
+	}
+}
+
+// settleExecution settles fill of paper order in paper account and pushes execution report to sessions of its user.
+// Fill which the account can not settle rejects the rest of the order, fill which failed to be stored
+// restores the order to be filled again.
+func (h *BitMexHandler) settleExecution(e paper.Execution) {
+	if !h.api.paper.Settle(e) {
+		return
+	}
+
+	report := model.ExecutionReport{Order: e.Order, Fill: &e.Fill}
+
+	err := h.api.postgresStore.Paper.Fill(&report.Order, report.Fill)
+
+	switch {
+	case errors.Is(err, model.ErrInsufficientBalance), errors.Is(err, model.ErrInsufficientPosition):
+		h.api.paper.Remove(e.Order.ID)
+
+		report = model.ExecutionReport{Order: e.Previous}
+		report.Order.Status, report.Order.Reason = model.OrderRejected, err.Error()
+		report.Order.UpdatedAt = e.Fill.Timestamp
+
+		if err = h.api.postgresStore.Paper.CloseOrder(&report.Order); err != nil {
+			logger.Errorf("settleExecution.CloseOrder", err)
+
+			return
+		}
+	case errors.Is(err, model.ErrPaperOrderClosed), errors.Is(err, model.ErrRecordNotFound):
+		h.api.paper.Remove(e.Order.ID)
+		logger.Errorf("settleExecution.Fill", err)
+
+		return
+	case err != nil:
+		h.api.paper.Restore(e)
+		logger.Errorf("settleExecution.Fill", err)
+
+		return
+	}
+
+	h.api.sendExecution(report)
+}
+
+// CloseCandles finalizes bars once their interval is over even if no trade comes after it.
+// Bars are closed with CloseDelay to let late trades of the interval arrive.
+func (h *BitMexHandler) CloseCandles(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(candlesCloseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("closeCandles done")
+
+			return
+		case now := <-ticker.C:
+			batch := newSessionBatch(h.api.config.Sessions.MaxBatchSize)
+			h.sendCandles(batch, h.api.candles.Close(now.Add(-h.api.config.Candles.CloseDelay.Duration)))
+			batch.flush()
+		}
+	}
+}
+
+func (h *BitMexHandler) sendCandles(batch *sessionBatch, bars []candle.Bar) {
+	for _, bar := range bars {
+		topic := bitmex.Topic(string(subscription.CandleChannel(bar.Interval)), bar.Symbol)
+
+		sessions, ok := h.api.topicUser.Get(topic)
+		if !ok || len(sessions) == 0 {
+			continue
+		}
+
+		data, err := json.Marshal(stream.Envelope{Type: stream.CandleMessageType, Data: bar})
+		if err != nil {
+			logger.Errorf("JSON marshal:", err)
+
+			continue
+		}
+
+		// bar of the next interval must not conflate the closing frame of the previous one
+		h.sendToSessions(batch, sessions, topic+":"+bar.Start.Format(time.RFC3339), data)
+	}
+}
+
+// sendTrade sends trade in the payload version of every session, throttled sessions get it once their interval is over.
+func (h *BitMexHandler) sendTrade(batch *sessionBatch, sessions []uuid.UUID, record bitmex.TradeDataRecord) {
+	legacy, err := json.Marshal(stream.NewLegacyTrade(record))
+	if err != nil {
+		logger.Errorf("JSON marshal:", err)
+
+		return
+	}
+
+	envelope, err := json.Marshal(stream.Envelope{Type: stream.TradeMessageType, Data: record})
+	if err != nil {
+		logger.Errorf("JSON marshal:", err)
+
+		return
+	}
+
+	for _, sessionID := range sessions {
+		session, ok := h.api.sessions.Get(sessionID)
+		if !ok {
+			continue
+		}
+
+		if delivery := session.delivery(); delivery.Throttled() {
+			session.throttleTrade(record, delivery)
+
+			continue
+		}
+
+		data := legacy
+		if session.version == stream.V2 {
+			data = envelope
+		}
+
+		batch.send(session, bitmex.Topic(bitmex.TradeTable, record.Symbol), data)
+	}
+}
+
+func (h *BitMexHandler) handleQuotes(batch *sessionBatch, data json.RawMessage) {
+	var records []bitmex.QuoteDataRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		logger.Errorf("JSON unmarshal error:", err)
+
+		return
+	}
+
+	for _, record := range records {
+		h.api.lastValues.PutQuote(record)
+
+		topic := bitmex.Topic(bitmex.QuoteTable, record.Symbol)
+
+		sessions, ok := h.api.topicUser.Get(topic)
+		if !ok {
+			continue
+		}
+
+		data, err := json.Marshal(stream.Envelope{Type: stream.QuoteMessageType, Data: record})
+		if err != nil {
+			logger.Errorf("JSON marshal:", err)
+
+			continue
+		}
+
+		h.sendToSessions(batch, sessions, topic, data)
+	}
+}
+
+func (h *BitMexHandler) handleOrderBook(batch *sessionBatch, table, action string, data json.RawMessage) {
+	var records []bitmex.OrderBookL2Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		logger.Errorf("JSON unmarshal error:", err)
+
+		return
+	}
+
+	updated, resync := h.api.orderBooks.Apply(action, records)
+
+	for _, symbol := range resync {
+		if err := h.api.bitMexClient.Resubscribe(bitmex.Topic(table, symbol)); err != nil {
+			logger.Errorf("Resubscribe error:", err)
 		}
 	}
+
+	for _, symbol := range updated {
+		topic := bitmex.Topic(string(subscription.OrderBookChannel), symbol)
+
+		sessions, ok := h.api.topicUser.Get(topic)
+		if !ok || len(sessions) == 0 {
+			continue
+		}
+
+		snapshot, ok := h.api.orderBooks.Snapshot(symbol, h.api.config.BitMex.OrderBookDepth)
+		if !ok {
+			continue
+		}
+
+		data, err := json.Marshal(stream.Envelope{Type: stream.OrderBookMessageType, Data: snapshot})
+		if err != nil {
+			logger.Errorf("JSON marshal:", err)
+
+			continue
+		}
+
+		h.sendToSessions(batch, sessions, topic, data)
+	}
+}
+
+// sendToSessions queues data to every session, key is topic of the data for conflate overflow policy.
+func (h *BitMexHandler) sendToSessions(batch *sessionBatch, sessions []uuid.UUID, key string, data []byte) {
+	for _, sessionID := range sessions {
+		session, ok := h.api.sessions.Get(sessionID)
+		if !ok {
+			continue
+		}
+
+		batch.send(session, key, data)
+	}
+}
+
+func (h *BitMexHandler) handleResponse(message []byte) {
+	var response bitmex.ResponseMessage
+	if err := json.Unmarshal(message, &response); err != nil {
+		logger.Errorf("JSON unmarshal error:", err)
+
+		return
+	}
+
+	if response.Error != "" {
+		logger.Errorf("BitMex error:", response.Error)
+	}
+}
+
+// sendStatus notifies every connected user about upstream connection state.
+func (h *BitMexHandler) sendStatus(state bitmexclient.State) {
+	data, err := statusMessage(state)
+	if err != nil {
+		logger.Errorf("JSON marshal:", err)
+
+		return
+	}
+
+	for _, session := range h.api.sessions.GetAll() {
+		session.send("", data)
+	}
+}
+
+func statusMessage(state bitmexclient.State) ([]byte, error) {
+	return json.Marshal(stream.StatusMessage{
+		Type:      stream.StatusMessageType,
+		State:     string(state),
+		Timestamp: time.Now().UTC(),
+	})
 }
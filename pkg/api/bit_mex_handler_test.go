@@ -0,0 +1,377 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/bitmexclient"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/candle"
+	"bitmex-api/pkg/model/ui/depth"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+	"bitmex-api/pkg/model/ui/trade"
+)
+
+func TestBitMexHandler_SendUsersDataOnUpdate(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+	conn := p.connect(t)
+
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, p.fake.SendError(http.StatusBadRequest, "Unknown table"))
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000, Timestamp: timestamp},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Side: "Sell", Size: 10, Price: 42000.5, Timestamp: timestamp},
+	))
+
+	var record map[string]interface{}
+	readJSON(t, conn, &record)
+
+	assert.Equal(t, map[string]interface{}{
+		"symbol":    "XBTUSD",
+		"price":     42000.5,
+		"timestamp": "2024-01-01T00:00:00Z",
+	}, record)
+}
+
+func TestBitMexHandler_TradesV2(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+	conn := p.connectPath(t, "/connect?version=2")
+
+	trade := bitmex.TradeDataRecord{
+		Symbol:          "XBTUSD",
+		Side:            "Buy",
+		Size:            100,
+		Price:           42000.5,
+		TickDirection:   "PlusTick",
+		TrdMatchID:      "00000000-006d-1000-0000-000000000001",
+		GrossValue:      238095,
+		HomeNotional:    0.00238095,
+		ForeignNotional: 100,
+		Timestamp:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, p.fake.SendTrades(trade))
+
+	var envelope struct {
+		Type stream.MessageType     `json:"type"`
+		Data bitmex.TradeDataRecord `json:"data"`
+	}
+	readJSON(t, conn, &envelope)
+	assert.Equal(t, stream.TradeMessageType, envelope.Type)
+	assert.Equal(t, trade, envelope.Data)
+}
+
+func TestBitMexHandler_NewSymbol(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true})
+	conn := p.connectPath(t, "/connect?version=2")
+
+	// trade of unknown symbol refreshes symbols in the background
+	p.fake.SetInstruments("XBTUSD", "ETHUSD", "SOLUSD")
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "SOLUSD", Price: 100}))
+
+	require.Eventually(t, func() bool {
+		_, ok := p.api.symbolUser.Get("SOLUSD")
+
+		return ok
+	}, pipelineTimeout, 10*time.Millisecond)
+
+	// session subscribed to trades of all symbols gets the new one
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "SOLUSD", Price: 101}))
+
+	var envelope struct {
+		Type stream.MessageType     `json:"type"`
+		Data bitmex.TradeDataRecord `json:"data"`
+	}
+	readFrame(t, conn, stream.TradeMessageType, &envelope)
+	assert.Equal(t, "SOLUSD", envelope.Data.Symbol)
+	assert.InDelta(t, 101, envelope.Data.Price, 0)
+}
+
+func TestBitMexHandler_ConnectIncorrectVersion(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	resp, err := http.Get(p.server.URL + "/connect?version=3")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestBitMexHandler_Reconnect(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true})
+	conn := p.connect(t)
+
+	opsBefore := len(p.fake.Ops())
+	p.fake.DropConnections()
+
+	var status stream.StatusMessage
+	readJSON(t, conn, &status)
+	assert.Equal(t, stream.StatusMessageType, status.Type)
+	assert.Equal(t, string(bitmexclient.StateReconnecting), status.State)
+
+	readJSON(t, conn, &status)
+	assert.Equal(t, string(bitmexclient.StateConnected), status.State)
+
+	require.True(t, p.fake.WaitForTopics(pipelineTimeout, "trade:XBTUSD", "trade:ETHUSD"))
+	assert.Greater(t, len(p.fake.Ops()), opsBefore)
+
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2100}))
+
+	var record bitmex.TradeDataRecord
+	readJSON(t, conn, &record)
+	assert.Equal(t, "ETHUSD", record.Symbol)
+}
+
+func TestBitMexHandler_Quotes(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+	conn := p.connect(t)
+
+	p.userRepo.EXPECT().UpdateSubscriptions(p.user).Return(nil).Times(1)
+
+	p.subscribe(t, subscription.Request{
+		Action:   subscription.Subscribe,
+		Symbols:  []string{"ETHUSD"},
+		Channels: []subscription.Channel{subscription.QuoteChannel},
+	})
+	assert.Equal(t, []string{"quote:ETHUSD"}, []string(p.user.SubscriptionTopics))
+	require.True(t, p.fake.WaitForTopics(pipelineTimeout, "quote:ETHUSD"))
+
+	quote := bitmex.QuoteDataRecord{Symbol: "ETHUSD", BidSize: 10, BidPrice: 1999.5, AskPrice: 2000, AskSize: 20}
+	require.NoError(t, p.fake.SendQuotes(bitmex.QuoteDataRecord{Symbol: "XBTUSD", BidPrice: 1}, quote))
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000}))
+
+	var envelope struct {
+		Type stream.MessageType     `json:"type"`
+		Data bitmex.QuoteDataRecord `json:"data"`
+	}
+	readJSON(t, conn, &envelope)
+	assert.Equal(t, stream.QuoteMessageType, envelope.Type)
+	assert.Equal(t, quote, envelope.Data)
+
+	var record bitmex.TradeDataRecord
+	readJSON(t, conn, &record)
+	assert.Equal(t, "XBTUSD", record.Symbol)
+}
+
+func TestBitMexHandler_UnsubscribeTradesKeepsQuotes(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionTopics: []string{"quote:XBTUSD"}})
+	p.connect(t)
+
+	var stored model.User
+
+	p.userRepo.EXPECT().UpdateSubscriptions(p.user).DoAndReturn(func(user *model.User) error {
+		stored = *user
+
+		return nil
+	}).Times(1)
+
+	p.subscribe(t, subscription.Request{
+		Action:   subscription.Unsubscribe,
+		Channels: []subscription.Channel{subscription.TradeChannel},
+	})
+
+	// subscription=false is written explicitly, otherwise the user would get trades of every symbol back
+	assert.False(t, stored.Subscription)
+	assert.Empty(t, stored.SubscriptionSymbols)
+	assert.Equal(t, []string{"quote:XBTUSD"}, []string(stored.SubscriptionTopics))
+}
+
+func TestBitMexHandler_UnsubscribeTradesOfSymbol(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD", "ETHUSD"},
+		SubscriptionTopics: []string{"quote:XBTUSD", "quote:ETHUSD"}})
+	p.connect(t)
+
+	p.userRepo.EXPECT().UpdateSubscriptions(p.user).Return(nil).Times(3)
+
+	// trades of the other symbol are kept
+	p.subscribe(t, subscription.Request{
+		Action:   subscription.Unsubscribe,
+		Channels: []subscription.Channel{subscription.TradeChannel},
+		Symbols:  []string{"XBTUSD"},
+	})
+	assert.True(t, p.user.Subscription)
+	assert.Equal(t, []string{"ETHUSD"}, []string(p.user.SubscriptionSymbols))
+	assert.Equal(t, []string{"quote:XBTUSD", "quote:ETHUSD"}, []string(p.user.SubscriptionTopics))
+
+	var statusError model.StatusError
+	status := p.do(t, http.MethodPatch, "/api/v1/bit-mex/subscription", subscription.Request{
+		Action:   subscription.Unsubscribe,
+		Channels: []subscription.Channel{subscription.TradeChannel},
+		Symbols:  []string{"XBTUSD"},
+	}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrAlreadyUnsubscribed, statusError)
+
+	// without channels every subscription of the symbol is removed
+	p.subscribe(t, subscription.Request{Action: subscription.Unsubscribe, Symbols: []string{"ETHUSD"}})
+	assert.False(t, p.user.Subscription)
+	assert.Empty(t, p.user.SubscriptionSymbols)
+	assert.Equal(t, []string{"quote:XBTUSD"}, []string(p.user.SubscriptionTopics))
+
+	// trades of all symbols keep the other known symbols
+	p.user.Subscription, p.user.SubscriptionSymbols = true, []string{}
+	p.subscribe(t, subscription.Request{
+		Action:   subscription.Unsubscribe,
+		Channels: []subscription.Channel{subscription.TradeChannel},
+		Symbols:  []string{"ETHUSD"},
+	})
+	assert.True(t, p.user.Subscription)
+	assert.Equal(t, []string{"XBTUSD"}, []string(p.user.SubscriptionSymbols))
+}
+
+func TestBitMexHandler_OrderBook(t *testing.T) {
+	p := initPipeline(t, &model.User{SubscriptionTopics: []string{"orderBook:XBTUSD"}})
+	conn := p.connect(t)
+
+	require.True(t, p.fake.WaitForTopics(pipelineTimeout, "orderBookL2_25:XBTUSD"))
+
+	require.NoError(t, p.fake.SendOrderBook(bitmex.PartialAction,
+		bitmex.OrderBookL2Record{Symbol: "XBTUSD", ID: 1, Side: bitmex.SellSide, Price: 101, Size: 10},
+		bitmex.OrderBookL2Record{Symbol: "XBTUSD", ID: 2, Side: bitmex.BuySide, Price: 99, Size: 20},
+		bitmex.OrderBookL2Record{Symbol: "XBTUSD", ID: 3, Side: bitmex.BuySide, Price: 98, Size: 30},
+	))
+	require.NoError(t, p.fake.SendOrderBook(bitmex.UpdateAction,
+		bitmex.OrderBookL2Record{Symbol: "XBTUSD", ID: 2, Side: bitmex.BuySide, Size: 25},
+	))
+
+	var envelope struct {
+		Type stream.MessageType `json:"type"`
+		Data depth.Snapshot     `json:"data"`
+	}
+	readJSON(t, conn, &envelope)
+	assert.Equal(t, stream.OrderBookMessageType, envelope.Type)
+	assert.Equal(t, []depth.Level{{ID: 2, Price: 99, Size: 20}, {ID: 3, Price: 98, Size: 30}}, envelope.Data.Bids)
+
+	readJSON(t, conn, &envelope)
+	assert.Equal(t, []depth.Level{{ID: 2, Price: 99, Size: 25}, {ID: 3, Price: 98, Size: 30}}, envelope.Data.Bids)
+	assert.Equal(t, []depth.Level{{ID: 1, Price: 101, Size: 10}}, envelope.Data.Asks)
+
+	var snapshot depth.Snapshot
+	assert.Equal(t, http.StatusOK, p.get(t, "/api/v1/bit-mex/order-book/XBTUSD?depth=1", &snapshot))
+	assert.Equal(t, depth.Snapshot{
+		Symbol: "XBTUSD",
+		Bids:   []depth.Level{{ID: 2, Price: 99, Size: 25}},
+		Asks:   []depth.Level{{ID: 1, Price: 101, Size: 10}},
+	}, snapshot)
+
+	var statusError model.StatusError
+	assert.Equal(t, http.StatusServiceUnavailable, p.get(t, "/api/v1/bit-mex/order-book/ETHUSD", &statusError))
+	assert.Equal(t, model.ErrOrderBookNotReady, statusError)
+	require.True(t, p.fake.WaitForTopics(pipelineTimeout, "orderBookL2_25:ETHUSD"))
+
+	assert.Equal(t, http.StatusBadRequest, p.get(t, "/api/v1/bit-mex/order-book/UNKNOWN", &statusError))
+
+	opsBefore := len(p.fake.Ops())
+	require.NoError(t, p.fake.SendOrderBook(bitmex.DeleteAction,
+		bitmex.OrderBookL2Record{Symbol: "XBTUSD", ID: 42, Side: bitmex.BuySide},
+	))
+	require.True(t, p.fake.WaitForOps(pipelineTimeout, opsBefore+2))
+
+	ops := p.fake.Ops()[opsBefore:]
+	assert.Equal(t, []bitmex.Operation{
+		{Op: bitmex.Unsubscribe, Args: []string{"orderBookL2_25:XBTUSD"}},
+		{Op: bitmex.Subscribe, Args: []string{"orderBookL2_25:XBTUSD"}},
+	}, ops)
+}
+
+func TestBitMexHandler_Candles(t *testing.T) {
+	p := initPipeline(t, &model.User{SubscriptionTopics: []string{"candle1m:XBTUSD"}})
+	conn := p.connect(t)
+
+	timestamp := time.Now().UTC().Truncate(time.Minute)
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000, Size: 1, Timestamp: timestamp},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000, Size: 5, Timestamp: timestamp},
+	))
+
+	var envelope struct {
+		Type stream.MessageType `json:"type"`
+		Data candle.Bar         `json:"data"`
+	}
+	readJSON(t, conn, &envelope)
+	assert.Equal(t, stream.CandleMessageType, envelope.Type)
+	assert.Equal(t, candle.Bar{
+		Symbol:   "XBTUSD",
+		Interval: candle.Minute1,
+		Start:    timestamp,
+		Open:     42000,
+		High:     42000,
+		Low:      42000,
+		Close:    42000,
+		Volume:   5,
+		Trades:   1,
+	}, envelope.Data)
+
+	var bars []candle.Bar
+	assert.Equal(t, http.StatusOK, p.get(t, "/api/v1/bit-mex/candles/ETHUSD?interval=5m", &bars))
+	require.Len(t, bars, 1)
+	assert.Equal(t, candle.Minute5, bars[0].Interval)
+	assert.Equal(t, float64(2000), bars[0].Close)
+
+	var statusError model.StatusError
+	assert.Equal(t, http.StatusBadRequest, p.get(t, "/api/v1/bit-mex/candles/ETHUSD?interval=2m", &statusError))
+	assert.Equal(t, model.ErrIncorrectInterval, statusError)
+}
+
+func TestBitMexHandler_Trades(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Side: "Buy", Size: 5, Price: 42000, TrdMatchID: "1", Timestamp: timestamp},
+	))
+
+	select {
+	case trades := <-p.inserted:
+		assert.Equal(t, []model.Trade{
+			{Symbol: "XBTUSD", Side: "Buy", Size: 5, Price: 42000, TrdMatchID: "1", Timestamp: timestamp},
+		}, trades)
+	case <-time.After(pipelineTimeout):
+		t.Fatal("trades were not inserted")
+	}
+
+	stored := []model.Trade{
+		{ID: 1, Symbol: "XBTUSD", Price: 42000, Timestamp: timestamp},
+		{ID: 2, Symbol: "XBTUSD", Price: 42001, Timestamp: timestamp.Add(time.Second)},
+		{ID: 3, Symbol: "XBTUSD", Price: 42002, Timestamp: timestamp.Add(2 * time.Second)},
+	}
+	cursor := model.TradeCursor{Timestamp: timestamp.Add(time.Second), ID: 2}
+
+	p.tradeRepo.EXPECT().List(model.TradeQuery{
+		Symbol: "XBTUSD",
+		From:   timestamp,
+		To:     timestamp.Add(time.Hour),
+		Limit:  3,
+	}).Return(stored, nil).Times(1)
+	p.tradeRepo.EXPECT().List(model.TradeQuery{
+		Symbol: "XBTUSD",
+		After:  &cursor,
+		Limit:  3,
+	}).Return(stored[2:], nil).Times(1)
+
+	var page trade.Page
+	assert.Equal(t, http.StatusOK, p.get(t,
+		"/api/v1/bit-mex/trades?symbol=XBTUSD&limit=2&from=2024-01-01T00:00:00Z&to=2024-01-01T01:00:00Z", &page))
+	assert.Len(t, page.Trades, 2)
+	assert.Equal(t, cursor.Encode(), page.NextCursor)
+
+	page = trade.Page{}
+	assert.Equal(t, http.StatusOK, p.get(t, "/api/v1/bit-mex/trades?symbol=XBTUSD&limit=2&cursor="+cursor.Encode(), &page))
+	assert.Len(t, page.Trades, 1)
+	assert.Empty(t, page.NextCursor)
+
+	var statusError model.StatusError
+	assert.Equal(t, http.StatusBadRequest, p.get(t, "/api/v1/bit-mex/trades?symbol=XBTUSD&cursor=bad", &statusError))
+	assert.Equal(t, model.ErrIncorrectCursor, statusError)
+	assert.Equal(t, http.StatusBadRequest,
+		p.get(t, "/api/v1/bit-mex/trades?symbol=XBTUSD&from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z", &statusError))
+	assert.Equal(t, model.ErrIncorrectTimeRange, statusError)
+	assert.Equal(t, http.StatusBadRequest, p.get(t, "/api/v1/bit-mex/trades?symbol=UNKNOWN", &statusError))
+	assert.Equal(t, model.ErrIncorrectSymbol, statusError)
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/email"
+	"bitmex-api/pkg/notify"
+)
+
+type EmailHandler struct {
+	api *api
+}
+
+func NewEmailHandler(a *api) *EmailHandler {
+	return &EmailHandler{
+		api: a,
+	}
+}
+
+// Set
+// @Summary set email of the user
+// @Description verification link valid for 24 hours is sent to the email,
+// @Description price alert, password change and login emails are sent once it is opened
+// @Produce json
+// @Tags User
+// @Security ApiKeyAuth
+// @Param Email  body email.Request  true "Email"
+// @Success 200 {object} email.Status
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 503 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/user/email [put]
+//
+//nolint:varnamelen
+func (h *EmailHandler) Set(c *gin.Context) {
+	request := &email.Request{}
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Errorf("Email.Set.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	request.Email = strings.TrimSpace(request.Email)
+
+	address, err := mail.ParseAddress(request.Email)
+	if err != nil || address.Address != request.Email {
+		logger.Errorf("Email.Set.ParseAddress", err)
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectEmail)
+
+		return
+	}
+
+	if !h.api.mailer.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, model.ErrEmailDisabled)
+
+		return
+	}
+
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Email.Set.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	token, tokenHash, err := model.NewEmailToken()
+	if err != nil {
+		logger.Errorf("Email.Set.NewEmailToken", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(model.EmailVerificationTTL)
+
+	err = h.api.postgresStore.User.SetEmail(userID, request.Email, tokenHash, expiresAt)
+	if err != nil {
+		logger.Errorf("Email.Set.SetEmail", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrUserNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.mailer.Verification(request.Email, token)
+
+	c.JSON(http.StatusOK, email.Status{Email: request.Email})
+}
+
+// Verify
+// @Summary verify email of the user with the token of verification link
+// @Produce json
+// @Tags User
+// @Param token  query  string  true  "Verification token"
+// @Success 200 {object} email.Status
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/email/verify [get]
+//
+//nolint:varnamelen
+func (h *EmailHandler) Verify(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, model.ErrInvalidEmailToken)
+
+		return
+	}
+
+	user, err := h.api.postgresStore.User.VerifyEmail(model.HashEmailToken(token), time.Now().UTC())
+	if err != nil {
+		logger.Errorf("Email.Verify.VerifyEmail", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, model.ErrInvalidEmailToken)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, email.Status{Email: user.Email, Verified: user.EmailVerified, Alerts: user.EmailAlerts})
+}
+
+// Notifications
+// @Summary turn email of fired price alerts on or off
+// @Description password change and login emails are always sent to verified email
+// @Produce json
+// @Tags User
+// @Security ApiKeyAuth
+// @Param Notifications  body email.NotificationsRequest  true "Notifications"
+// @Success 200 {object} email.Status
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/user/email-notifications [patch]
+//
+//nolint:varnamelen
+func (h *EmailHandler) Notifications(c *gin.Context) {
+	request := &email.NotificationsRequest{}
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Errorf("Email.Notifications.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Email.Notifications.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	err = h.api.postgresStore.User.UpdateEmailAlerts(userID, request.Alerts)
+	if err != nil {
+		logger.Errorf("Email.Notifications.UpdateEmailAlerts", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrUserNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	user, err := h.api.postgresStore.User.Get(userID)
+	if err != nil {
+		logger.Errorf("Email.Notifications.Get", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, email.Status{Email: user.Email, Verified: user.EmailVerified, Alerts: user.EmailAlerts})
+}
+
+// emailRecipient resolves verified email of the user when the mailer sends the message,
+// so the caller never waits for the lookup.
+func (a *api) emailRecipient(userID uuid.UUID, alert bool) notify.Recipient {
+	return func() (string, bool) {
+		return a.verifiedEmail(userID, alert)
+	}
+}
+
+// verifiedEmail returns verified email of the user, alert requires the user to take price alert emails.
+// Nothing is returned when email notifications are off.
+func (a *api) verifiedEmail(userID uuid.UUID, alert bool) (string, bool) {
+	if !a.mailer.Enabled() {
+		return "", false
+	}
+
+	user, err := a.postgresStore.User.Get(userID)
+	if err != nil {
+		logger.Errorf("verifiedEmail.Get", err)
+
+		return "", false
+	}
+
+	if user.Email == "" || !user.EmailVerified || (alert && !user.EmailAlerts) {
+		return "", false
+	}
+
+	return user.Email, true
+}
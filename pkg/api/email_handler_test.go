@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/authmiddleware/mockauthmiddleware"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/alert"
+	"bitmex-api/pkg/model/ui/email"
+	"bitmex-api/pkg/notify/fakesmtp"
+	"bitmex-api/pkg/store"
+)
+
+var verificationToken = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+// nextMail waits for the next email received by the pipeline mail server.
+func (p *pipeline) nextMail(t *testing.T) fakesmtp.Mail {
+	t.Helper()
+
+	select {
+	case received := <-p.smtp.Mails:
+		return received
+	case <-time.After(pipelineTimeout):
+		t.Fatal("email was not sent")
+	}
+
+	return fakesmtp.Mail{}
+}
+
+func TestEmailHandler_Verify(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	var tokenHash string
+	p.userRepo.EXPECT().SetEmail(p.userID, "trader@example.com", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ uuid.UUID, _, hash string, expiresAt time.Time) error {
+			tokenHash = hash
+			assert.WithinDuration(t, time.Now().Add(model.EmailVerificationTTL), expiresAt, time.Minute)
+
+			return nil
+		})
+
+	var status email.Status
+	code := p.do(t, http.MethodPut, "/api/v1/user/email", email.Request{Email: " trader@example.com "}, &status)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, email.Status{Email: "trader@example.com"}, status)
+
+	received := p.nextMail(t)
+	assert.Equal(t, []string{"trader@example.com"}, received.To)
+	assert.Equal(t, "Confirm your email", received.Message.Header.Get("Subject"))
+
+	match := verificationToken.FindStringSubmatch(received.Body)
+	require.Len(t, match, 2)
+	assert.Equal(t, model.HashEmailToken(match[1]), tokenHash)
+
+	p.userRepo.EXPECT().VerifyEmail(tokenHash, gomock.Any()).Return(&model.User{
+		Email:         "trader@example.com",
+		EmailVerified: true,
+	}, nil)
+
+	code = p.do(t, http.MethodGet, "/api/v1/email/verify?token="+match[1], nil, &status)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, email.Status{Email: "trader@example.com", Verified: true}, status)
+
+	// the link is valid once
+	p.userRepo.EXPECT().VerifyEmail(tokenHash, gomock.Any()).Return(nil, model.ErrRecordNotFound)
+
+	var statusError model.StatusError
+	code = p.do(t, http.MethodGet, "/api/v1/email/verify?token="+match[1], nil, &statusError)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, model.ErrInvalidEmailToken, statusError)
+}
+
+func TestEmailHandler_Alert(t *testing.T) {
+	p := initPipeline(t, &model.User{Email: "trader@example.com", EmailVerified: true, EmailAlerts: true})
+
+	p.alertRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(newAlert *model.Alert) error {
+		newAlert.ID = uuid.NewV4()
+
+		return nil
+	})
+	p.alertRepo.EXPECT().Trigger(gomock.Any(), true).Return(nil)
+
+	var created model.Alert
+	code := p.do(t, http.MethodPost, "/api/v1/alerts", alert.Request{
+		Symbol:    "XBTUSD",
+		Condition: model.CrossesAbove,
+		Price:     70000,
+	}, &created)
+	require.Equal(t, http.StatusOK, code)
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500},
+	))
+
+	received := p.nextMail(t)
+	assert.Equal(t, []string{"trader@example.com"}, received.To)
+	assert.Contains(t, received.Message.Header.Get("Subject"), "XBTUSD")
+	assert.Contains(t, received.Body, "70500")
+}
+
+func TestEmailHandler_Validate(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	var statusError model.StatusError
+	code := p.do(t, http.MethodPut, "/api/v1/user/email", email.Request{Email: "trader"}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, model.ErrIncorrectEmail, statusError)
+
+	code = p.do(t, http.MethodPut, "/api/v1/user/email", email.Request{Email: "Trader <trader@example.com>"}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, model.ErrIncorrectEmail, statusError)
+
+	code = p.do(t, http.MethodGet, "/api/v1/email/verify", nil, &statusError)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, model.ErrInvalidEmailToken, statusError)
+
+	p.userRepo.EXPECT().UpdateEmailAlerts(p.userID, true).Return(nil)
+
+	var status email.Status
+	code = p.do(t, http.MethodPatch, "/api/v1/user/email-notifications", email.NotificationsRequest{Alerts: true}, &status)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestEmailHandler_Disabled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	auth := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	auth.EXPECT().Authorize(gomock.Any()).Return().AnyTimes()
+
+	testAPI := initTestAPI(t, auth, &store.Store{})
+
+	body, err := json.Marshal(email.Request{Email: "trader@example.com"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPut, "/api/v1/user/email", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	testAPI.ServeHTTP(rr, req)
+
+	var statusError model.StatusError
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &statusError))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, model.ErrEmailDisabled, statusError)
+}
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"bitmex-api/pkg/authmiddleware"
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/marketdatapb"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/session"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+)
+
+const authorizationMetadata = "authorization"
+
+type userIDContextKey struct{}
+
+// MarketDataServer serves gRPC API on top of REST handlers, StreamTrades shares fan-out with WebSocket sessions.
+type MarketDataServer struct {
+	marketdatapb.UnimplementedMarketDataServer
+
+	api *api
+}
+
+func newGRPCServer(a *api) *grpc.Server {
+	server := &MarketDataServer{api: a}
+
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(server.unaryAuth),
+		grpc.StreamInterceptor(server.streamAuth),
+	)
+	marketdatapb.RegisterMarketDataServer(grpcServer, server)
+
+	return grpcServer
+}
+
+//nolint:gochecknoglobals
+var publicMethods = map[string]bool{
+	marketdatapb.MarketData_Login_FullMethodName:   true,
+	marketdatapb.MarketData_Refresh_FullMethodName: true,
+}
+
+func (s *MarketDataServer) unaryAuth(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	userID, err := s.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(context.WithValue(ctx, userIDContextKey{}, userID), req)
+}
+
+// authStream carries context with ID of the authorized user.
+type authStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *MarketDataServer) streamAuth(
+	srv interface{},
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	userID, err := s.authorize(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authStream{
+		ServerStream: ss,
+		ctx:          context.WithValue(ss.Context(), userIDContextKey{}, userID),
+	})
+}
+
+// authorize checks access token of authorization metadata the same way AuthMiddleware.Authorize checks the header.
+func (s *MarketDataServer) authorize(ctx context.Context) (uuid.UUID, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get(authorizationMetadata)
+	if len(values) == 0 {
+		return uuid.Nil, grpcError(model.ErrUnauthorized)
+	}
+
+	claims, err := s.api.authorizeToken(strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return uuid.Nil, grpcError(err)
+	}
+
+	return claims.BaseClaims.ID, nil
+}
+
+func userIDFromContext(ctx context.Context) uuid.UUID {
+	userID, _ := ctx.Value(userIDContextKey{}).(uuid.UUID)
+
+	return userID
+}
+
+// grpcError converts model error to status with the code matching its HTTP status.
+func grpcError(err error) error {
+	statusError := model.ErrUnhealthy
+	if modelError, ok := err.(model.Error); ok { //nolint:errorlint
+		statusError = modelError
+	}
+
+	code := codes.Internal
+
+	switch statusError.Status() {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+
+	return status.Error(code, statusError.Error())
+}
+
+func (s *MarketDataServer) Login(ctx context.Context, req *marketdatapb.LoginRequest) (*marketdatapb.Tokens, error) {
+	client := "gRPC"
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String() + " gRPC"
+	}
+
+	user := &model.AuthUser{Username: req.GetUsername(), Password: req.GetPassword()}
+
+	tokens, _, err := s.api.Auth().login(user, client)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &marketdatapb.Tokens{AccessToken: tokens.Access, RefreshToken: tokens.Refresh}, nil
+}
+
+func (s *MarketDataServer) Refresh(_ context.Context, req *marketdatapb.Tokens) (*marketdatapb.Tokens, error) {
+	tokens, err := s.api.auth.Refresh(authmiddleware.Tokens{Access: req.GetAccessToken(), Refresh: req.GetRefreshToken()})
+	if err != nil {
+		logger.Errorf("Refresh.Refresh", err)
+
+		return nil, grpcError(model.ErrUnauthorized)
+	}
+
+	return &marketdatapb.Tokens{AccessToken: tokens.Access, RefreshToken: tokens.Refresh}, nil
+}
+
+func (s *MarketDataServer) GetSubscriptions(
+	ctx context.Context,
+	_ *marketdatapb.GetSubscriptionsRequest,
+) (*marketdatapb.Subscriptions, error) {
+	user, err := s.api.postgresStore.User.Get(userIDFromContext(ctx))
+	if err != nil || user == nil {
+		logger.Errorf("GetSubscriptions.Get", err)
+
+		return nil, grpcError(model.ErrUnhealthy)
+	}
+
+	subscriptions := userSubscriptions(user)
+
+	return &marketdatapb.Subscriptions{
+		Trade:        subscriptions.Trade,
+		TradeSymbols: subscriptions.TradeSymbols,
+		Topics:       subscriptions.Topics,
+		Delivery: &marketdatapb.Delivery{
+			Mode:       string(subscriptions.Delivery.Mode),
+			IntervalMs: int32(subscriptions.Delivery.IntervalMs),
+			Aggregate:  subscriptions.Delivery.Aggregate,
+		},
+	}, nil
+}
+
+func (s *MarketDataServer) UpdateSubscription(
+	ctx context.Context,
+	req *marketdatapb.SubscriptionRequest,
+) (*marketdatapb.SubscriptionResponse, error) {
+	action := &subscription.Request{
+		Symbols:  req.GetSymbols(),
+		Channels: make([]subscription.Channel, 0, len(req.GetChannels())),
+	}
+
+	switch req.GetAction() {
+	case marketdatapb.SubscriptionRequest_ACTION_SUBSCRIBE:
+		action.Action = subscription.Subscribe
+	case marketdatapb.SubscriptionRequest_ACTION_UNSUBSCRIBE:
+		action.Action = subscription.Unsubscribe
+	default:
+		return nil, grpcError(model.ErrInvalidBody)
+	}
+
+	for _, channel := range req.GetChannels() {
+		action.Channels = append(action.Channels, subscription.Channel(channel))
+	}
+
+	if delivery := req.GetDelivery(); delivery != nil {
+		action.Delivery = &subscription.Delivery{
+			Mode:       subscription.DeliveryMode(delivery.GetMode()),
+			IntervalMs: int(delivery.GetIntervalMs()),
+			Aggregate:  delivery.GetAggregate(),
+		}
+	}
+
+	if err := s.api.UserWebSocket().applyRequest(userIDFromContext(ctx), action); err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &marketdatapb.SubscriptionResponse{Success: true}, nil
+}
+
+// StreamTrades opens gRPC session of the user, it counts towards the session limit and is listed with other sessions.
+func (s *MarketDataServer) StreamTrades(
+	req *marketdatapb.StreamTradesRequest,
+	srv marketdatapb.MarketData_StreamTradesServer,
+) error {
+	userID := userIDFromContext(srv.Context())
+
+	user, err := s.api.postgresStore.User.Get(userID)
+	if err != nil || user == nil {
+		logger.Errorf("StreamTrades.Get", err)
+
+		return grpcError(model.ErrUnhealthy)
+	}
+
+	for _, symbol := range req.GetSymbols() {
+		if _, ok := s.api.symbolUser.Get(symbol); !ok {
+			return grpcError(model.ErrIncorrectSymbol)
+		}
+	}
+
+	subscriptions := subscription.Subscriptions{
+		Trade:        true,
+		TradeSymbols: req.GetSymbols(),
+		Delivery:     subscription.Delivery{Mode: subscription.RealtimeDelivery},
+	}
+	if len(req.GetSymbols()) == 0 {
+		stored := userSubscriptions(user)
+
+		subscriptions.Trade = stored.Trade
+		subscriptions.TradeSymbols = stored.TradeSymbols
+		subscriptions.Delivery = stored.Delivery
+		// windows have no trade message, throttled stream sends the last trade of the window
+		subscriptions.Delivery.Aggregate = false
+	}
+
+	sess := newWSSession(uuid.NewV4(), nil, userID, stream.V2, s.api.config.Sessions)
+	sess.transport = session.GRPCTransport
+
+	if !s.api.sessions.Create(sess, s.api.sessionLimit(user)) {
+		return grpcError(model.ErrTooManySessions)
+	}
+	defer s.api.UserWebSocket().closeSession(sess)
+
+	stop := context.AfterFunc(srv.Context(), sess.queue.Close)
+	defer stop()
+
+	s.api.UserWebSocket().openSession(sess, subscriptions)
+
+	logger.Infof("user connected %v, grpc session %v", userID, sess.id)
+
+	return sendTrades(srv, sess)
+}
+
+// sendTrades sends trades queued to the session until the stream or the session is over.
+func sendTrades(srv marketdatapb.MarketData_StreamTradesServer, sess *wsSession) error {
+	defer close(sess.closed)
+
+	for {
+		message, ok := sess.queue.Pop()
+		if !ok {
+			break
+		}
+
+		record, ok := tradeFrame(message.Data)
+		if !ok {
+			continue
+		}
+
+		if err := srv.Send(tradeMessage(record)); err != nil {
+			return err
+		}
+	}
+
+	if sess.queue.Overflowed() {
+		return status.Error(codes.ResourceExhausted, SlowConsumerReason)
+	}
+
+	if sess.closing.Load() {
+		return status.Error(codes.Unavailable, ShutdownReason)
+	}
+
+	return srv.Context().Err() //nolint:wrapcheck
+}
+
+// tradeFrame extracts trade of trade and snapshot frames, other frames are not streamed over gRPC.
+func tradeFrame(data []byte) (bitmex.TradeDataRecord, bool) {
+	var envelope struct {
+		Type stream.MessageType `json:"type"`
+		Data json.RawMessage    `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return bitmex.TradeDataRecord{}, false
+	}
+
+	switch envelope.Type {
+	case stream.TradeMessageType:
+		var record bitmex.TradeDataRecord
+		if err := json.Unmarshal(envelope.Data, &record); err != nil {
+			return bitmex.TradeDataRecord{}, false
+		}
+
+		return record, true
+	case stream.SnapshotMessageType:
+		var snapshot stream.Snapshot
+		if err := json.Unmarshal(envelope.Data, &snapshot); err != nil || snapshot.Trade == nil {
+			return bitmex.TradeDataRecord{}, false
+		}
+
+		return *snapshot.Trade, true
+	}
+
+	return bitmex.TradeDataRecord{}, false
+}
+
+func tradeMessage(record bitmex.TradeDataRecord) *marketdatapb.Trade {
+	return &marketdatapb.Trade{
+		Symbol:          record.Symbol,
+		Side:            record.Side,
+		Size:            record.Size,
+		Price:           record.Price,
+		TickDirection:   record.TickDirection,
+		TrdMatchId:      record.TrdMatchID,
+		GrossValue:      record.GrossValue,
+		HomeNotional:    record.HomeNotional,
+		ForeignNotional: record.ForeignNotional,
+		Timestamp:       timestamppb.New(record.Timestamp),
+	}
+}
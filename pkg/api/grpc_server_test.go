@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"bitmex-api/pkg/marketdatapb"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/session"
+)
+
+// grpcClient serves gRPC API of the pipeline on a local port.
+func (p *pipeline) grpcClient(t *testing.T) marketdatapb.MarketDataClient {
+	t.Helper()
+
+	client, _ := p.grpcServe(t)
+
+	return client
+}
+
+func (p *pipeline) grpcServe(t *testing.T) (marketdatapb.MarketDataClient, *grpc.Server) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := newGRPCServer(p.api)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		server.Stop()
+	})
+
+	return marketdatapb.NewMarketDataClient(conn), server
+}
+
+func authorized(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, authorizationMetadata, "Bearer "+pipelineToken)
+}
+
+func TestMarketDataServer_Unauthenticated(t *testing.T) {
+	p := initPipeline(t, nil)
+	client := p.grpcClient(t)
+
+	_, err := client.GetSubscriptions(context.Background(), &marketdatapb.GetSubscriptionsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), authorizationMetadata, "Bearer wrong")
+	_, err = client.GetSubscriptions(ctx, &marketdatapb.GetSubscriptionsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestMarketDataServer_Subscriptions(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+	client := p.grpcClient(t)
+	ctx := authorized(context.Background())
+
+	subscriptions, err := client.GetSubscriptions(ctx, &marketdatapb.GetSubscriptionsRequest{})
+	require.NoError(t, err)
+	assert.True(t, subscriptions.GetTrade())
+	assert.Equal(t, []string{"XBTUSD"}, subscriptions.GetTradeSymbols())
+	assert.Equal(t, "realtime", subscriptions.GetDelivery().GetMode())
+
+	_, err = client.UpdateSubscription(ctx, &marketdatapb.SubscriptionRequest{Symbols: []string{"ETHUSD"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.UpdateSubscription(ctx, &marketdatapb.SubscriptionRequest{
+		Action:  marketdatapb.SubscriptionRequest_ACTION_SUBSCRIBE,
+		Symbols: []string{"XBTUSD"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "already subscribed")
+
+	p.userRepo.EXPECT().UpdateSubscriptions(p.user).Return(nil)
+
+	response, err := client.UpdateSubscription(ctx, &marketdatapb.SubscriptionRequest{
+		Action:  marketdatapb.SubscriptionRequest_ACTION_SUBSCRIBE,
+		Symbols: []string{"ETHUSD"},
+	})
+	require.NoError(t, err)
+	assert.True(t, response.GetSuccess())
+	assert.Equal(t, []string{"XBTUSD", "ETHUSD"}, []string(p.user.SubscriptionSymbols))
+}
+
+func TestMarketDataServer_StreamTrades(t *testing.T) {
+	p := initPipeline(t, nil)
+	client := p.grpcClient(t)
+
+	ctx, cancel := context.WithCancel(authorized(context.Background()))
+	defer cancel()
+
+	unknown, err := client.StreamTrades(ctx, &marketdatapb.StreamTradesRequest{Symbols: []string{"UNKNOWN"}})
+	require.NoError(t, err)
+
+	_, err = unknown.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	trades, err := client.StreamTrades(ctx, &marketdatapb.StreamTradesRequest{Symbols: []string{"XBTUSD"}})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		sessions := p.api.sessions.GetByUser(p.userID)
+
+		return len(sessions) == 1 && sessions[0].Info().Transport == session.GRPCTransport
+	}, pipelineTimeout, 5*time.Millisecond)
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000, Side: "Buy"},
+	))
+
+	trade, err := trades.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "XBTUSD", trade.GetSymbol())
+	assert.Equal(t, "Buy", trade.GetSide())
+	assert.InDelta(t, 42000, trade.GetPrice(), 0)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		return len(p.api.sessions.GetByUser(p.userID)) == 0
+	}, pipelineTimeout, 5*time.Millisecond)
+}
+
+func TestMarketDataServer_StreamTradesShutdown(t *testing.T) {
+	p := initPipeline(t, nil)
+	client, server := p.grpcServe(t)
+
+	request := &marketdatapb.StreamTradesRequest{Symbols: []string{"XBTUSD"}}
+
+	trades, err := client.StreamTrades(authorized(context.Background()), request)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(p.api.sessions.GetByUser(p.userID)) == 1
+	}, pipelineTimeout, 5*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go p.api.UserWebSocket().CloseSessions(ctx, wg)
+
+	cancel()
+	wg.Wait()
+
+	_, err = trades.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, ShutdownReason, status.Convert(err).Message())
+
+	// no stream is left, so graceful stop does not fall back to Stop
+	stopped := make(chan struct{})
+
+	go func() {
+		(&Server{GRPC: server}).StopGRPC(time.Minute)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(pipelineTimeout):
+		t.Fatal("gRPC server is not stopped")
+	}
+
+	assert.Empty(t, p.api.sessions.GetByUser(p.userID))
+}
@@ -0,0 +1,433 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/paper"
+	"bitmex-api/pkg/model/ui/stream"
+)
+
+const (
+	defaultPaperListLimit = 100
+	maxPaperListLimit     = 1000
+)
+
+type PaperHandler struct {
+	api *api
+}
+
+func NewPaperHandler(a *api) *PaperHandler {
+	return &PaperHandler{
+		api: a,
+	}
+}
+
+// PlaceOrder
+// @Summary place paper order
+// @Description market order fills at the price of the next trade of the symbol,
+// @Description limit order fills at the trade price once it is at price or better,
+// @Description stop order becomes market order once trade price reaches stopPrice, above it for buy and below for sell,
+// @Description order fills partially when trade size is less than the rest of the order,
+// @Description fill costs or returns quantity * price of paper balance, buy needs the balance and sell needs
+// @Description the position, order which can not be settled is rejected,
+// @Description changes of orders are sent to /connect and /bit-mex/stream as {"type": "execution", "data": {...}}
+// @Produce json
+// @Tags Paper trading
+// @Security ApiKeyAuth
+// @Param Order  body paper.OrderRequest  true "Order"
+// @Success 200 {object} model.PaperOrder
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/paper/orders [post]
+//
+//nolint:varnamelen
+func (h *PaperHandler) PlaceOrder(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Paper.PlaceOrder.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	var request paper.OrderRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		logger.Errorf("Paper.PlaceOrder.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if _, ok := h.api.symbolUser.Get(request.Symbol); !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectSymbol)
+
+		return
+	}
+
+	now := time.Now().UTC()
+	order := &model.PaperOrder{UserID: userID, Status: model.OrderNew, CreatedAt: now, UpdatedAt: now}
+	request.Apply(order)
+
+	if !order.IsValid() {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectPaperOrder)
+
+		return
+	}
+
+	// fills are settled in the account, so it is created before the first order
+	if _, err = h.api.postgresStore.Paper.Account(userID, h.api.config.Paper.InitialBalance); err != nil {
+		logger.Errorf("Paper.PlaceOrder.Account", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	count, err := h.api.postgresStore.Paper.CountOpenOrders(userID)
+	if err != nil {
+		logger.Errorf("Paper.PlaceOrder.CountOpenOrders", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if count >= int64(h.api.config.Paper.MaxOpenOrders) {
+		c.JSON(http.StatusBadRequest, model.ErrTooManyPaperOrders)
+
+		return
+	}
+
+	if err = h.api.postgresStore.Paper.CreateOrder(order); err != nil {
+		logger.Errorf("Paper.PlaceOrder.CreateOrder", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.paper.Put(*order)
+	h.api.sendExecution(model.ExecutionReport{Order: *order})
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ListOrders
+// @Summary get paper orders of the user, the newest first
+// @Produce json
+// @Tags Paper trading
+// @Security ApiKeyAuth
+// @Param open   query bool false "Only orders waiting for fills"
+// @Param limit  query int  false "Number of orders up to 1000, 100 by default"
+// @Success 200 {array} model.PaperOrder
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/paper/orders [get]
+//
+//nolint:varnamelen
+func (h *PaperHandler) ListOrders(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Paper.ListOrders.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	open, err := strconv.ParseBool(c.DefaultQuery("open", "false"))
+	if err != nil {
+		logger.Errorf("Paper.ListOrders.ParseBool", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	limit, ok := h.getLimit(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.api.postgresStore.Paper.ListOrders(userID, open, limit)
+	if err != nil {
+		logger.Errorf("Paper.ListOrders.ListOrders", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetOrder
+// @Summary get paper order
+// @Produce json
+// @Tags Paper trading
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Order ID"
+// @Success 200 {object} model.PaperOrder
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/paper/orders/{id} [get]
+//
+//nolint:varnamelen
+func (h *PaperHandler) GetOrder(c *gin.Context) {
+	order, ok := h.getOrder(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelOrder
+// @Summary cancel paper order, fills made before are kept
+// @Produce json
+// @Tags Paper trading
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Order ID"
+// @Success 200 {object} model.PaperOrder
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/paper/orders/{id} [delete]
+//
+//nolint:varnamelen
+func (h *PaperHandler) CancelOrder(c *gin.Context) {
+	order, ok := h.getOrder(c)
+	if !ok {
+		return
+	}
+
+	if !order.Open() {
+		c.JSON(http.StatusBadRequest, model.ErrPaperOrderNotOpen)
+
+		return
+	}
+
+	// order is removed first, so it is not filled while it is being cancelled
+	previous := *order
+	h.api.paper.Remove(order.ID)
+
+	order.Status, order.UpdatedAt = model.OrderCancelled, time.Now().UTC()
+
+	err := h.api.postgresStore.Paper.CloseOrder(order)
+	if err != nil {
+		logger.Errorf("Paper.CancelOrder.CloseOrder", err)
+
+		if errors.Is(err, model.ErrPaperOrderClosed) {
+			c.JSON(http.StatusBadRequest, model.ErrPaperOrderNotOpen)
+
+			return
+		}
+
+		h.api.paper.Put(previous)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.sendExecution(model.ExecutionReport{Order: *order})
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Fills
+// @Summary get fills of paper orders of the user, the newest first
+// @Produce json
+// @Tags Paper trading
+// @Security ApiKeyAuth
+// @Param limit  query int  false "Number of fills up to 1000, 100 by default"
+// @Success 200 {array} model.PaperFill
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/paper/fills [get]
+//
+//nolint:varnamelen
+func (h *PaperHandler) Fills(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Paper.Fills.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	limit, ok := h.getLimit(c)
+	if !ok {
+		return
+	}
+
+	fills, err := h.api.postgresStore.Paper.ListFills(userID, limit)
+	if err != nil {
+		logger.Errorf("Paper.Fills.ListFills", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, fills)
+}
+
+// Account
+// @Summary get paper balance of the user
+// @Description account is created with PAPER_INITIAL_BALANCE on the first use
+// @Produce json
+// @Tags Paper trading
+// @Security ApiKeyAuth
+// @Success 200 {object} model.PaperAccount
+// @Failure 401 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/paper/account [get]
+//
+//nolint:varnamelen
+func (h *PaperHandler) Account(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Paper.Account.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	account, err := h.api.postgresStore.Paper.Account(userID, h.api.config.Paper.InitialBalance)
+	if err != nil {
+		logger.Errorf("Paper.Account.Account", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// Positions
+// @Summary get paper positions of the user
+// @Description closed positions are kept with their realized profit
+// @Produce json
+// @Tags Paper trading
+// @Security ApiKeyAuth
+// @Success 200 {array} model.PaperPosition
+// @Failure 401 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/paper/positions [get]
+//
+//nolint:varnamelen
+func (h *PaperHandler) Positions(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Paper.Positions.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	positions, err := h.api.postgresStore.Paper.ListPositions(userID)
+	if err != nil {
+		logger.Errorf("Paper.Positions.ListPositions", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, positions)
+}
+
+// Reset
+// @Summary reset paper account of the user
+// @Description open orders are cancelled, positions are removed and balance is set to PAPER_INITIAL_BALANCE
+// @Produce json
+// @Tags Paper trading
+// @Security ApiKeyAuth
+// @Success 200 {object} model.PaperAccount
+// @Failure 401 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/paper/account/reset [post]
+//
+//nolint:varnamelen
+func (h *PaperHandler) Reset(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Paper.Reset.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	h.api.paper.RemoveUser(userID)
+
+	cancelled, err := h.api.postgresStore.Paper.Reset(userID, h.api.config.Paper.InitialBalance, time.Now().UTC())
+	if err != nil {
+		logger.Errorf("Paper.Reset.Reset", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	for _, order := range cancelled {
+		h.api.sendExecution(model.ExecutionReport{Order: order})
+	}
+
+	h.Account(c)
+}
+
+// getOrder loads paper order of id path parameter owned by the user of the request,
+// error response is written on failure.
+func (h *PaperHandler) getOrder(c *gin.Context) (*model.PaperOrder, bool) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Paper.getOrder.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, false
+	}
+
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("Paper.getOrder.FromString", err)
+		c.JSON(http.StatusNotFound, model.ErrPaperOrderNotFound)
+
+		return nil, false
+	}
+
+	order, err := h.api.postgresStore.Paper.GetOrder(userID, id)
+	if err != nil {
+		logger.Errorf("Paper.getOrder.GetOrder", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrPaperOrderNotFound)
+
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	return order, true
+}
+
+// getLimit parses limit query parameter, error response is written on failure.
+func (h *PaperHandler) getLimit(c *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPaperListLimit)))
+	if err != nil || limit <= 0 || limit > maxPaperListLimit {
+		logger.Errorf("Paper.getLimit.Atoi", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return 0, false
+	}
+
+	return limit, true
+}
+
+// sendExecution pushes execution report to every session of the user of the order.
+func (a *api) sendExecution(report model.ExecutionReport) {
+	data, err := json.Marshal(stream.Envelope{Type: stream.ExecutionMessageType, Data: report})
+	if err != nil {
+		logger.Errorf("JSON marshal:", err)
+
+		return
+	}
+
+	for _, session := range a.sessions.GetByUser(report.Order.UserID) {
+		session.send("", data)
+	}
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/paper"
+	"bitmex-api/pkg/model/ui/stream"
+)
+
+type executionFrame struct {
+	Type stream.MessageType    `json:"type"`
+	Data model.ExecutionReport `json:"data"`
+}
+
+// placeOrder places paper order of the request through REST API with store mocks of an empty account.
+func (p *pipeline) placeOrder(t *testing.T, request paper.OrderRequest) model.PaperOrder {
+	t.Helper()
+
+	p.paperRepo.EXPECT().Account(p.userID, 100000.0).Return(&model.PaperAccount{UserID: p.userID, Balance: 100000}, nil)
+	p.paperRepo.EXPECT().CountOpenOrders(p.userID).Return(int64(0), nil)
+	p.paperRepo.EXPECT().CreateOrder(gomock.Any()).DoAndReturn(func(order *model.PaperOrder) error {
+		order.ID = uuid.NewV4()
+
+		return nil
+	})
+
+	var order model.PaperOrder
+	status := p.do(t, http.MethodPost, "/api/v1/paper/orders", request, &order)
+	require.Equal(t, http.StatusOK, status)
+
+	return order
+}
+
+func TestPaperHandler_Fill(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	order := p.placeOrder(t, paper.OrderRequest{Symbol: "XBTUSD", Side: model.Buy, Type: model.LimitOrder,
+		Quantity: 3, Price: 70000, StopPrice: 1})
+	assert.Equal(t, model.OrderNew, order.Status)
+	assert.InDelta(t, 0, order.StopPrice, 0)
+
+	var frame executionFrame
+	readFrame(t, conn, stream.ExecutionMessageType, &frame)
+	assert.Equal(t, order.ID, frame.Data.Order.ID)
+	assert.Nil(t, frame.Data.Fill)
+
+	filled := make(chan model.PaperFill, 2)
+	p.paperRepo.EXPECT().Fill(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(filledOrder *model.PaperOrder, fill *model.PaperFill) error {
+			assert.Equal(t, order.ID, filledOrder.ID)
+			assert.Equal(t, p.userID, fill.UserID)
+			filled <- *fill
+
+			return nil
+		}).Times(2)
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500, Size: 10},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69500, Size: 2},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70000, Size: 5},
+	))
+
+	readFrame(t, conn, stream.ExecutionMessageType, &frame)
+	assert.Equal(t, model.OrderPartiallyFilled, frame.Data.Order.Status)
+	require.NotNil(t, frame.Data.Fill)
+	assert.InDelta(t, 2, frame.Data.Fill.Quantity, 0)
+	assert.InDelta(t, 69500, frame.Data.Fill.Price, 0)
+
+	readFrame(t, conn, stream.ExecutionMessageType, &frame)
+	assert.Equal(t, model.OrderFilled, frame.Data.Order.Status)
+	assert.InDelta(t, 3, frame.Data.Order.FilledQuantity, 0)
+	assert.InDelta(t, (2*69500+70000)/3.0, frame.Data.Order.AvgFillPrice, 0.001)
+
+	assert.InDelta(t, 69500, (<-filled).Price, 0)
+	assert.InDelta(t, 70000, (<-filled).Price, 0)
+}
+
+func TestPaperHandler_Reject(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	order := p.placeOrder(t, paper.OrderRequest{Symbol: "XBTUSD", Side: model.Sell, Type: model.MarketOrder, Quantity: 1})
+
+	var frame executionFrame
+	readFrame(t, conn, stream.ExecutionMessageType, &frame)
+
+	p.paperRepo.EXPECT().Fill(gomock.Any(), gomock.Any()).Return(model.ErrInsufficientPosition)
+	p.paperRepo.EXPECT().CloseOrder(gomock.Any()).DoAndReturn(func(rejected *model.PaperOrder) error {
+		assert.Equal(t, order.ID, rejected.ID)
+		assert.InDelta(t, 0, rejected.FilledQuantity, 0)
+
+		return nil
+	})
+
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70000, Size: 10}))
+
+	readFrame(t, conn, stream.ExecutionMessageType, &frame)
+	assert.Equal(t, model.OrderRejected, frame.Data.Order.Status)
+	assert.Equal(t, model.ErrInsufficientPosition.Error(), frame.Data.Order.Reason)
+	assert.Nil(t, frame.Data.Fill)
+
+	// rejected order is not filled again
+	assert.Empty(t, p.api.paper.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70000}))
+}
+
+func TestPaperHandler_FillRetry(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	order := p.placeOrder(t, paper.OrderRequest{Symbol: "XBTUSD", Side: model.Buy, Type: model.LimitOrder,
+		Quantity: 2, Price: 70000})
+
+	var frame executionFrame
+	readFrame(t, conn, stream.ExecutionMessageType, &frame)
+
+	// store is down for the first fill, the fill after it was made of the failed one and is skipped
+	filled := make(chan model.PaperFill, 1)
+	gomock.InOrder(
+		p.paperRepo.EXPECT().Fill(gomock.Any(), gomock.Any()).Return(errors.New("connection reset")),
+		p.paperRepo.EXPECT().Fill(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(filledOrder *model.PaperOrder, fill *model.PaperFill) error {
+				assert.Equal(t, order.ID, filledOrder.ID)
+				filled <- *fill
+
+				return nil
+			}),
+	)
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69500, Size: 1},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69600, Size: 1},
+	))
+
+	// restored order is filled in full by the next trade once the failed fill is settled
+	var fill model.PaperFill
+
+	require.Eventually(t, func() bool {
+		assert.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69700, Size: 5}))
+
+		select {
+		case fill = <-filled:
+			return true
+		case <-time.After(20 * time.Millisecond):
+			return false
+		}
+	}, pipelineTimeout, time.Millisecond)
+	assert.InDelta(t, 2, fill.Quantity, 0)
+	assert.InDelta(t, 69700, fill.Price, 0)
+
+	readFrame(t, conn, stream.ExecutionMessageType, &frame)
+	assert.Equal(t, order.ID, frame.Data.Order.ID)
+	assert.Equal(t, model.OrderFilled, frame.Data.Order.Status)
+	assert.InDelta(t, 2, frame.Data.Order.FilledQuantity, 0)
+}
+
+func TestPaperHandler_Cancel(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	stored := model.PaperOrder{
+		ID:        uuid.NewV4(),
+		UserID:    p.userID,
+		Symbol:    "XBTUSD",
+		Side:      model.Buy,
+		Type:      model.StopOrder,
+		Quantity:  1,
+		StopPrice: 80000,
+		Status:    model.OrderNew,
+		CreatedAt: time.Now().UTC(),
+	}
+	p.api.paper.Put(stored)
+
+	p.paperRepo.EXPECT().GetOrder(p.userID, stored.ID).DoAndReturn(func(uuid.UUID, uuid.UUID) (*model.PaperOrder, error) {
+		order := stored
+
+		return &order, nil
+	})
+	p.paperRepo.EXPECT().CloseOrder(gomock.Any()).Return(nil)
+
+	var cancelled model.PaperOrder
+	status := p.do(t, http.MethodDelete, "/api/v1/paper/orders/"+stored.ID.String(), nil, &cancelled)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, model.OrderCancelled, cancelled.Status)
+	assert.Empty(t, p.api.paper.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 81000}))
+
+	// closed order can not be cancelled
+	p.paperRepo.EXPECT().GetOrder(p.userID, stored.ID).Return(&cancelled, nil)
+
+	var statusError model.StatusError
+	status = p.do(t, http.MethodDelete, "/api/v1/paper/orders/"+stored.ID.String(), nil, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrPaperOrderNotOpen, statusError)
+
+	// order which failed to be cancelled is filled again
+	other := stored
+	other.ID = uuid.NewV4()
+	p.api.paper.Put(other)
+
+	p.paperRepo.EXPECT().GetOrder(p.userID, other.ID).DoAndReturn(func(uuid.UUID, uuid.UUID) (*model.PaperOrder, error) {
+		order := other
+
+		return &order, nil
+	})
+	p.paperRepo.EXPECT().CloseOrder(gomock.Any()).Return(errors.New("connection reset"))
+
+	status = p.do(t, http.MethodDelete, "/api/v1/paper/orders/"+other.ID.String(), nil, &statusError)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, model.ErrUnhealthy, statusError)
+
+	executions := p.api.paper.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 81000})
+	require.Len(t, executions, 1)
+	assert.Equal(t, other.ID, executions[0].Order.ID)
+
+	p.paperRepo.EXPECT().GetOrder(p.userID, gomock.Any()).Return(nil, model.ErrRecordNotFound)
+
+	status = p.do(t, http.MethodGet, "/api/v1/paper/orders/"+uuid.NewV4().String(), nil, &statusError)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, model.ErrPaperOrderNotFound, statusError)
+}
+
+func TestPaperHandler_Validate(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	var statusError model.StatusError
+	status := p.do(t, http.MethodPost, "/api/v1/paper/orders", paper.OrderRequest{
+		Symbol: "UNKNOWN", Side: model.Buy, Type: model.MarketOrder, Quantity: 1,
+	}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrIncorrectSymbol, statusError)
+
+	for _, request := range []paper.OrderRequest{
+		{Symbol: "XBTUSD", Side: "hold", Type: model.MarketOrder, Quantity: 1},
+		{Symbol: "XBTUSD", Side: model.Buy, Type: model.MarketOrder, Quantity: 0},
+		{Symbol: "XBTUSD", Side: model.Buy, Type: model.LimitOrder, Quantity: 1},
+		{Symbol: "XBTUSD", Side: model.Sell, Type: model.StopOrder, Quantity: 1, Price: 70000},
+		{Symbol: "XBTUSD", Side: model.Sell, Type: "trailing", Quantity: 1},
+	} {
+		status = p.do(t, http.MethodPost, "/api/v1/paper/orders", request, &statusError)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, model.ErrIncorrectPaperOrder, statusError)
+	}
+
+	p.paperRepo.EXPECT().Account(p.userID, 100000.0).Return(&model.PaperAccount{}, nil)
+	p.paperRepo.EXPECT().CountOpenOrders(p.userID).Return(int64(2), nil)
+
+	status = p.do(t, http.MethodPost, "/api/v1/paper/orders", paper.OrderRequest{
+		Symbol: "XBTUSD", Side: model.Buy, Type: model.MarketOrder, Quantity: 1,
+	}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrTooManyPaperOrders, statusError)
+
+	status = p.do(t, http.MethodGet, "/api/v1/paper/orders?open=maybe", nil, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status = p.do(t, http.MethodGet, "/api/v1/paper/fills?limit=0", nil, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestPaperHandler_Reset(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	open := model.PaperOrder{ID: uuid.NewV4(), UserID: p.userID, Symbol: "XBTUSD", Side: model.Buy,
+		Type: model.MarketOrder, Quantity: 1, Status: model.OrderNew}
+	p.api.paper.Put(open)
+
+	cancelled := open
+	cancelled.Status, cancelled.Reason = model.OrderCancelled, model.PaperResetReason
+
+	p.paperRepo.EXPECT().Reset(p.userID, 100000.0, gomock.Any()).Return([]model.PaperOrder{cancelled}, nil)
+	p.paperRepo.EXPECT().Account(p.userID, 100000.0).Return(&model.PaperAccount{UserID: p.userID, Balance: 100000}, nil)
+
+	var account model.PaperAccount
+	status := p.do(t, http.MethodPost, "/api/v1/paper/account/reset", nil, &account)
+	require.Equal(t, http.StatusOK, status)
+	assert.InDelta(t, 100000, account.Balance, 0)
+
+	var frame executionFrame
+	readFrame(t, conn, stream.ExecutionMessageType, &frame)
+	assert.Equal(t, open.ID, frame.Data.Order.ID)
+	assert.Equal(t, model.PaperResetReason, frame.Data.Order.Reason)
+
+	assert.Empty(t, p.api.paper.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70000}))
+}
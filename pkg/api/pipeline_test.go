@@ -0,0 +1,351 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/authmiddleware"
+	"bitmex-api/pkg/authmiddleware/mockauthmiddleware"
+	"bitmex-api/pkg/bitmexclient/fakebitmex"
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+	"bitmex-api/pkg/notify"
+	"bitmex-api/pkg/notify/fakesmtp"
+	"bitmex-api/pkg/sendqueue"
+	"bitmex-api/pkg/store"
+	"bitmex-api/pkg/store/mockpostgresstore"
+)
+
+const (
+	pipelineTimeout = 5 * time.Second
+	pipelineToken   = "pipeline-token"
+)
+
+type pipeline struct {
+	api           *api
+	fake          *fakebitmex.Server
+	server        *httptest.Server
+	userRepo      *mockpostgresstore.MockUserRepository
+	tradeRepo     *mockpostgresstore.MockTradeRepository
+	alertRepo     *mockpostgresstore.MockAlertRepository
+	webhookRepo   *mockpostgresstore.MockWebhookRepository
+	paperRepo     *mockpostgresstore.MockPaperRepository
+	watchlistRepo *mockpostgresstore.MockWatchlistRepository
+	inserted      chan []model.Trade
+	user          *model.User
+	userID        uuid.UUID
+	// tokens are access tokens accepted by auth mock
+	tokens *sync.Map
+	// removed makes auth repository forget the user
+	removed *atomic.Bool
+	// smtp receives emails of the user
+	smtp *fakesmtp.Server
+}
+
+// initPipeline runs API against fake BitMex for the single user, nil user has no stored subscriptions.
+func initPipeline(t *testing.T, user *model.User) *pipeline {
+	t.Helper()
+
+	return initPipelineSessions(t, user, config.SessionsConfig{
+		SendQueueSize:      100,
+		OverflowPolicy:     string(sendqueue.DropOldest),
+		WriteTimeout:       config.Duration{Duration: pipelineTimeout},
+		CompressionLevel:   1,
+		MaxBatchSize:       100,
+		PingInterval:       config.Duration{Duration: time.Second},
+		IdleTimeout:        config.Duration{Duration: pipelineTimeout},
+		TokenCheckInterval: config.Duration{Duration: time.Second},
+		TokenExpiryWarning: config.Duration{Duration: time.Minute},
+	})
+}
+
+func initPipelineSessions(t *testing.T, user *model.User, sessions config.SessionsConfig) *pipeline {
+	t.Helper()
+
+	gin.SetMode(gin.ReleaseMode)
+
+	mockCtrl := gomock.NewController(t)
+	fake := fakebitmex.NewServer("XBTUSD", "ETHUSD")
+	t.Cleanup(fake.Close)
+
+	userID := uuid.NewV4()
+	if user == nil {
+		user = &model.User{}
+	}
+	user.UserID = userID
+
+	userRepo := mockpostgresstore.NewMockUserRepository(mockCtrl)
+	userRepo.EXPECT().GetAll().Return([]*model.User{user}, nil).AnyTimes()
+	userRepo.EXPECT().Get(userID).Return(user, nil).AnyTimes()
+
+	removed := &atomic.Bool{}
+
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	authRepo.EXPECT().Get(userID).DoAndReturn(func(uuid.UUID) (*model.AuthUser, bool) {
+		if removed.Load() {
+			return nil, false
+		}
+
+		return &model.AuthUser{ID: userID, Role: model.BaseUserRole}, true
+	}).AnyTimes()
+	authRepo.EXPECT().Get(gomock.Any()).DoAndReturn(func(id uuid.UUID) (*model.AuthUser, bool) {
+		return &model.AuthUser{ID: id, Role: model.BaseUserRole}, true
+	}).AnyTimes()
+
+	tradeRepo := mockpostgresstore.NewMockTradeRepository(mockCtrl)
+	tradeRepo.EXPECT().DeleteBefore(gomock.Any()).Return(int64(0), nil).AnyTimes()
+
+	alertRepo := mockpostgresstore.NewMockAlertRepository(mockCtrl)
+	alertRepo.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+
+	webhookRepo := mockpostgresstore.NewMockWebhookRepository(mockCtrl)
+	webhookRepo.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+	webhookRepo.EXPECT().GetDue(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	paperRepo := mockpostgresstore.NewMockPaperRepository(mockCtrl)
+	paperRepo.EXPECT().GetOpenOrders().Return(nil, nil).AnyTimes()
+
+	watchlistRepo := mockpostgresstore.NewMockWatchlistRepository(mockCtrl)
+
+	inserted := make(chan []model.Trade, 100)
+	tradeRepo.EXPECT().CreateBatch(gomock.Any()).DoAndReturn(func(trades []model.Trade) error {
+		inserted <- trades
+
+		return nil
+	}).AnyTimes()
+
+	auth := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	auth.EXPECT().GetUserID(gomock.Any()).Return(userID, nil).AnyTimes()
+	auth.EXPECT().Authorize(gomock.Any()).Return().AnyTimes()
+
+	tokens := &sync.Map{}
+	tokens.Store(pipelineToken, &authmiddleware.AccessClaims{
+		BaseClaims: authmiddleware.BaseClaims{ID: userID, Role: model.BaseUserRole},
+	})
+	auth.EXPECT().Validate(gomock.Any()).DoAndReturn(func(token string) (*authmiddleware.AccessClaims, error) {
+		claims, ok := tokens.Load(token)
+		if !ok {
+			return nil, errors.New("invalid token")
+		}
+
+		return claims.(*authmiddleware.AccessClaims), nil
+	}).AnyTimes()
+
+	smtp, err := fakesmtp.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(smtp.Close)
+
+	smtpConfig := smtp.Config("")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+
+	testAPI := newAPI(ctx, &config.ServerConfig{
+		BitMex:  *fake.Config(),
+		Candles: config.CandlesConfig{History: 10},
+		Trades: config.TradesConfig{
+			BatchSize:         10,
+			BufferSize:        100,
+			FlushInterval:     config.Duration{Duration: 10 * time.Millisecond},
+			Retention:         config.Duration{Duration: time.Hour},
+			RetentionInterval: config.Duration{Duration: time.Hour},
+		},
+		Sessions: sessions,
+		SSE: config.SSEConfig{
+			ReplaySize:        100,
+			ReplayTTL:         config.Duration{Duration: pipelineTimeout},
+			KeepAliveInterval: config.Duration{Duration: time.Second},
+		},
+		Webhooks: config.WebhooksConfig{
+			Workers:       1,
+			QueueSize:     10,
+			Timeout:       config.Duration{Duration: time.Second},
+			MaxAttempts:   2,
+			Backoff:       config.Duration{Duration: 10 * time.Millisecond},
+			MaxBackoff:    config.Duration{Duration: 10 * time.Millisecond},
+			RetryInterval: config.Duration{Duration: 10 * time.Millisecond},
+			AllowHTTP:     true,
+		},
+		Paper: config.PaperConfig{InitialBalance: 100000, MaxOpenOrders: 2},
+	}, &store.Store{
+		User:      userRepo,
+		Auth:      authRepo,
+		Trade:     tradeRepo,
+		Alert:     alertRepo,
+		Webhook:   webhookRepo,
+		Paper:     paperRepo,
+		Watchlist: watchlistRepo,
+	}, auth, notify.NewMailer(notify.NewSMTP(smtpConfig), smtpConfig), wg)
+	server := httptest.NewServer(testAPI)
+
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+		wg.Wait()
+	})
+
+	require.True(t, fake.WaitForTopics(pipelineTimeout, "trade:XBTUSD", "trade:ETHUSD"))
+
+	return &pipeline{
+		api:           testAPI,
+		fake:          fake,
+		server:        server,
+		userRepo:      userRepo,
+		tradeRepo:     tradeRepo,
+		alertRepo:     alertRepo,
+		webhookRepo:   webhookRepo,
+		paperRepo:     paperRepo,
+		watchlistRepo: watchlistRepo,
+		inserted:      inserted,
+		user:          user,
+		userID:        userID,
+		tokens:        tokens,
+		removed:       removed,
+		smtp:          smtp,
+	}
+}
+
+func (p *pipeline) connect(t *testing.T) *websocket.Conn {
+	t.Helper()
+
+	return p.connectPath(t, "/connect")
+}
+
+func (p *pipeline) connectPath(t *testing.T, path string) *websocket.Conn {
+	t.Helper()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+pipelineToken)
+
+	url := "ws" + strings.TrimPrefix(p.server.URL, "http") + path
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	t.Cleanup(func() { _ = conn.Close() })
+
+	sessionID, err := uuid.FromString(resp.Header.Get(SessionIDHeader))
+	require.NoError(t, err)
+
+	// commands are read once the session is open, so ack of ping means the session gets updates
+	require.NoError(t, conn.WriteJSON(stream.Command{ID: "connect", Op: stream.PingCommand}))
+
+	for {
+		var ack stream.AckMessage
+		readJSON(t, conn, &ack)
+
+		if ack.Type == stream.AckMessageType && ack.ID == "connect" {
+			break
+		}
+	}
+
+	_, ok := p.api.sessions.Get(sessionID)
+	require.True(t, ok)
+
+	return conn
+}
+
+func (p *pipeline) subscribe(t *testing.T, request subscription.Request) {
+	t.Helper()
+
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPatch, p.server.URL+"/api/v1/bit-mex/subscription", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func (p *pipeline) get(t *testing.T, path string, v interface{}) int {
+	t.Helper()
+
+	resp, err := http.Get(p.server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+
+	return resp.StatusCode
+}
+
+// do sends JSON request to REST API and decodes JSON response to v.
+func (p *pipeline) do(t *testing.T, method, path string, request, v interface{}) int {
+	t.Helper()
+
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(method, p.server.URL+path, bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+
+	return resp.StatusCode
+}
+
+func readJSON(t *testing.T, conn *websocket.Conn, v interface{}) {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(pipelineTimeout)))
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(message, v))
+}
+
+// readFrame skips frames until frame of the type and decodes it to v.
+func readFrame(t *testing.T, conn *websocket.Conn, messageType stream.MessageType, v interface{}) {
+	t.Helper()
+
+	for {
+		var message json.RawMessage
+		readJSON(t, conn, &message)
+
+		var frame struct {
+			Type stream.MessageType `json:"type"`
+		}
+		require.NoError(t, json.Unmarshal(message, &frame))
+
+		if frame.Type == messageType {
+			require.NoError(t, json.Unmarshal(message, v))
+
+			return
+		}
+	}
+}
+
+// readClose skips frames until the server closes the connection.
+func readClose(t *testing.T, conn *websocket.Conn) error {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(pipelineTimeout)))
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return err
+		}
+	}
+}
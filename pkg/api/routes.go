@@ -20,6 +20,7 @@ func configureRouter(api *api) *gin.Engine {
 	public.POST("/login", api.Auth().Login)
 	public.POST("/refresh", api.Auth().Refresh)
 	public.PATCH("/change-password", api.Auth().ChangePassword)
+	public.GET("/email/verify", api.Email().Verify)
 
 	private := router.Group("api/v1")
 
@@ -31,10 +32,61 @@ func configureRouter(api *api) *gin.Engine {
 
 	privateUser.PATCH("/update-info", api.User().UpdateInfo)
 	privateUser.GET("/", api.User().Get)
+	privateUser.GET("/sessions", api.User().Sessions)
+	privateUser.PATCH("/session-limit", api.User().SessionLimit)
+	privateUser.PUT("/email", api.Email().Set)
+	privateUser.PATCH("/email-notifications", api.Email().Notifications)
 
 	privateBitMex := private.Group("/bit-mex")
 
 	privateBitMex.PATCH("/subscription", api.UserWebSocket().SubscribeAction)
+	privateBitMex.GET("/order-book/:symbol", api.BitMex().OrderBook)
+	privateBitMex.GET("/candles/:symbol", api.BitMex().Candles)
+	privateBitMex.GET("/trades", api.BitMex().Trades)
+	privateBitMex.GET("/stream", api.UserWebSocket().Stream)
+
+	privateAlerts := private.Group("/alerts")
+
+	privateAlerts.POST("", api.Alert().Create)
+	privateAlerts.GET("", api.Alert().List)
+	privateAlerts.GET("/history", api.Alert().History)
+	privateAlerts.GET("/:id", api.Alert().Get)
+	privateAlerts.PUT("/:id", api.Alert().Update)
+	privateAlerts.DELETE("/:id", api.Alert().Delete)
+
+	privateWebhooks := private.Group("/webhooks")
+
+	privateWebhooks.POST("", api.Webhook().Create)
+	privateWebhooks.GET("", api.Webhook().List)
+	privateWebhooks.GET("/dead-letters", api.Webhook().DeadLetters)
+	privateWebhooks.GET("/:id", api.Webhook().Get)
+	privateWebhooks.PUT("/:id", api.Webhook().Update)
+	privateWebhooks.DELETE("/:id", api.Webhook().Delete)
+	privateWebhooks.GET("/:id/deliveries", api.Webhook().Deliveries)
+
+	privatePaper := private.Group("/paper")
+
+	privatePaper.POST("/orders", api.Paper().PlaceOrder)
+	privatePaper.GET("/orders", api.Paper().ListOrders)
+	privatePaper.GET("/orders/:id", api.Paper().GetOrder)
+	privatePaper.DELETE("/orders/:id", api.Paper().CancelOrder)
+	privatePaper.GET("/fills", api.Paper().Fills)
+	privatePaper.GET("/account", api.Paper().Account)
+	privatePaper.POST("/account/reset", api.Paper().Reset)
+	privatePaper.GET("/positions", api.Paper().Positions)
+
+	privateWatchlists := private.Group("/watchlists")
+
+	privateWatchlists.POST("", api.Watchlist().Create)
+	privateWatchlists.GET("", api.Watchlist().List)
+	privateWatchlists.PUT("/order", api.Watchlist().Reorder)
+	privateWatchlists.GET("/shared/:token", api.Watchlist().GetShared)
+	privateWatchlists.POST("/shared/:token/copy", api.Watchlist().CopyShared)
+	privateWatchlists.GET("/:id", api.Watchlist().Get)
+	privateWatchlists.PUT("/:id", api.Watchlist().Update)
+	privateWatchlists.DELETE("/:id", api.Watchlist().Delete)
+	privateWatchlists.POST("/:id/share", api.Watchlist().Share)
+	privateWatchlists.DELETE("/:id/share", api.Watchlist().Unshare)
 
 	router.NoRoute(func(c *gin.Context) {
 		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
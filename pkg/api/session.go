@@ -0,0 +1,437 @@
+package api
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/authmiddleware"
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/session"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+	"bitmex-api/pkg/sendqueue"
+	"bitmex-api/pkg/streamcodec"
+)
+
+const (
+	// SlowConsumerReason is sent to the session evicted by disconnect overflow policy.
+	SlowConsumerReason = "slow consumer"
+	// IdleTimeoutReason is reason of CloseIdleTimeout close frame.
+	IdleTimeoutReason = "idle timeout"
+	// ShutdownReason is reason of CloseGoingAway close frame.
+	ShutdownReason = "server shutdown"
+	// TokenExpiredReason is reason of CloseTokenExpired close frame.
+	TokenExpiredReason = "token expired"
+	// AccountRemovedReason is reason of CloseAccountRemoved close frame.
+	AccountRemovedReason = "account removed"
+)
+
+// wsSession is one /connect connection of the user. Every session has its own subscriptions,
+// they start as the stored subscriptions of the user and are changed by the session commands.
+// Frames are queued with send and written to the connection by writeLoop only,
+// so slow client does not stall upstream read loop and other sessions.
+// SSE sessions have no conn, their frames are moved to replay buffer by sseStream instead of writeLoop.
+// Frames are queued as JSON, codec converts them to the encoding of the session when they are written.
+// Session with batch set gets frames of one upstream message packed in JSON arrays, see sessionBatch.
+type wsSession struct {
+	id          uuid.UUID
+	userID      uuid.UUID
+	conn        *websocket.Conn
+	transport   session.Transport
+	codec       streamcodec.Codec
+	version     stream.Version
+	batch       bool
+	connectedAt time.Time
+
+	subscriptions subscription.Subscriptions
+	subMu         sync.Mutex
+
+	queue  *sendqueue.Queue
+	closed chan struct{}
+	// closing is set once close frame is sent, read deadline is not extended after it
+	closing atomic.Bool
+
+	throttle tradeThrottle
+
+	token sessionToken
+}
+
+// sessionToken is the access token WebSocket session is authorized with, reauth command replaces it.
+// Zero expiresAt is token without expiry.
+type sessionToken struct {
+	expiresAt time.Time
+	role      model.UserRole
+	// warned is set once tokenExpiring frame is sent for the token
+	warned bool
+
+	mu sync.Mutex
+}
+
+func newWSSession(
+	id uuid.UUID,
+	conn *websocket.Conn,
+	userID uuid.UUID,
+	version stream.Version,
+	config config.SessionsConfig,
+) *wsSession {
+	return &wsSession{
+		id:          id,
+		userID:      userID,
+		conn:        conn,
+		transport:   session.WebSocketTransport,
+		codec:       streamcodec.New(streamcodec.JSON),
+		version:     version,
+		connectedAt: time.Now().UTC(),
+		queue:       sendqueue.New(config.SendQueueSize, sendqueue.Policy(config.OverflowPolicy)),
+		closed:      make(chan struct{}),
+	}
+}
+
+// send queues frame, key groups frames of the same channel and symbol for conflate policy.
+func (s *wsSession) send(key string, data []byte) {
+	if !s.queue.Push(key, data) && s.queue.Overflowed() {
+		logger.Infof("session %v of user %v evicted: send queue is full", s.id, s.userID)
+	}
+}
+
+// writeLoop writes queued frames until the queue is closed. Connection is closed on write error
+// and on eviction, that ends read loop of the session.
+func (s *wsSession) writeLoop(writeTimeout time.Duration) {
+	defer close(s.closed)
+
+	for {
+		message, ok := s.queue.Pop()
+		if !ok {
+			break
+		}
+
+		data, err := s.codec.Encode(message.Data)
+		if err != nil {
+			logger.Errorf("error encode message ", err)
+
+			continue
+		}
+
+		if err = s.writeMessage(writeTimeout, s.messageType(), data); err != nil {
+			// frames are not sent after close frame, the connection is closed by read loop
+			if !s.closing.Load() {
+				logger.Errorf("error send message ", err)
+				s.conn.Close()
+			}
+
+			return
+		}
+	}
+
+	if s.queue.Overflowed() {
+		s.evict(writeTimeout, SlowConsumerReason)
+	}
+}
+
+func (s *wsSession) evict(writeTimeout time.Duration, reason string) {
+	b, err := json.Marshal(stream.DisconnectMessage{Type: stream.DisconnectMessageType, Reason: reason})
+	if err != nil {
+		logger.Errorf("error marshal json ", err)
+
+		return
+	}
+
+	if b, err = s.codec.Encode(b); err != nil {
+		logger.Errorf("error encode message ", err)
+
+		return
+	}
+
+	if err = s.writeMessage(writeTimeout, s.messageType(), b); err != nil {
+		s.conn.Close()
+
+		return
+	}
+
+	s.closeConn(writeTimeout, stream.ClosePolicyViolation, reason)
+}
+
+// closeConn starts close handshake, read loop of the session ends with close frame of the client
+// or when the client does not answer within writeTimeout.
+func (s *wsSession) closeConn(writeTimeout time.Duration, code int, reason string) {
+	if s.closing.Swap(true) {
+		return
+	}
+
+	deadline := time.Now().Add(writeTimeout)
+
+	if err := s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline); err != nil {
+		s.conn.Close()
+
+		return
+	}
+
+	_ = s.conn.SetReadDeadline(deadline)
+}
+
+// closeStream ends session without conn, gRPC stream of the session returns Unavailable with ShutdownReason.
+func (s *wsSession) closeStream() {
+	s.closing.Store(true)
+	s.queue.Close()
+}
+
+// keepAlive extends read deadline of the session, it is called on every message and pong of the client.
+func (s *wsSession) keepAlive(idleTimeout time.Duration) error {
+	if s.closing.Load() {
+		return nil
+	}
+
+	return s.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+}
+
+// pingLoop pings the client until the session is closed, pongs keep the session alive.
+func (s *wsSession) pingLoop(pingInterval, writeTimeout time.Duration) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			if s.closing.Load() {
+				return
+			}
+
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// messageType is WebSocket message type of the frames of the session.
+func (s *wsSession) messageType() int {
+	if s.codec.Binary() {
+		return websocket.BinaryMessage
+	}
+
+	return websocket.TextMessage
+}
+
+func (s *wsSession) writeMessage(writeTimeout time.Duration, messageType int, data []byte) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+
+	return s.conn.WriteMessage(messageType, data)
+}
+
+// close stops writeLoop and waits for it.
+func (s *wsSession) close() {
+	s.queue.Close()
+	<-s.closed
+}
+
+// authorize sets access token of the session.
+func (s *wsSession) authorize(claims *authmiddleware.AccessClaims) {
+	s.token.mu.Lock()
+	defer s.token.mu.Unlock()
+
+	s.token.expiresAt = time.Time{}
+	if claims.ExpiresAt != 0 {
+		s.token.expiresAt = time.Unix(claims.ExpiresAt, 0).UTC()
+	}
+
+	s.token.role = claims.BaseClaims.Role
+	s.token.warned = false
+}
+
+func (s *wsSession) tokenExpiresAt() time.Time {
+	s.token.mu.Lock()
+	defer s.token.mu.Unlock()
+
+	return s.token.expiresAt
+}
+
+func (s *wsSession) tokenRole() model.UserRole {
+	s.token.mu.Lock()
+	defer s.token.mu.Unlock()
+
+	return s.token.role
+}
+
+// tokenExpiring reports whether the token expires within warning of now, it is true once per token.
+func (s *wsSession) tokenExpiring(now time.Time, warning time.Duration) bool {
+	s.token.mu.Lock()
+	defer s.token.mu.Unlock()
+
+	if s.token.warned || s.token.expiresAt.IsZero() || now.Add(warning).Before(s.token.expiresAt) {
+		return false
+	}
+
+	s.token.warned = true
+
+	return true
+}
+
+func (s *wsSession) Subscriptions() subscription.Subscriptions {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	return cloneSubscriptions(s.subscriptions)
+}
+
+// sendSnapshots sends snapshot frame for every symbol the session has just subscribed to.
+func (s *wsSession) sendSnapshots(snapshots []stream.Snapshot) {
+	for _, snapshot := range snapshots {
+		data, err := json.Marshal(stream.Envelope{Type: stream.SnapshotMessageType, Data: snapshot})
+		if err != nil {
+			logger.Errorf("JSON marshal:", err)
+
+			continue
+		}
+
+		s.send("", data)
+	}
+}
+
+// delivery returns trade delivery of the session.
+func (s *wsSession) delivery() subscription.Delivery {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	return s.subscriptions.Delivery
+}
+
+func (s *wsSession) Info() session.Info {
+	var expiresAt *time.Time
+	if tokenExpiresAt := s.tokenExpiresAt(); !tokenExpiresAt.IsZero() {
+		expiresAt = &tokenExpiresAt
+	}
+
+	return session.Info{
+		ID:            s.id,
+		Transport:     s.transport,
+		Encoding:      string(s.codec.Encoding()),
+		Version:       int(s.version),
+		Batch:         s.batch,
+		ConnectedAt:   s.connectedAt,
+		ExpiresAt:     expiresAt,
+		Subscriptions: s.Subscriptions(),
+		Queue:         queueInfo(s.queue.Stats()),
+	}
+}
+
+func queueInfo(stats sendqueue.Stats) session.Queue {
+	return session.Queue{
+		Queued:    stats.Queued,
+		Capacity:  stats.Capacity,
+		Sent:      stats.Sent,
+		Dropped:   stats.Dropped,
+		Conflated: stats.Conflated,
+		LagMs:     stats.Lag.Milliseconds(),
+		MaxLagMs:  stats.MaxLag.Milliseconds(),
+	}
+}
+
+// wsSessions is registry of open sessions.
+type wsSessions struct {
+	sessions     map[uuid.UUID]*wsSession
+	userSessions map[uuid.UUID][]*wsSession
+
+	mu sync.RWMutex
+}
+
+func (m *wsSessions) Get(id uuid.UUID) (*wsSession, bool) {
+	m.mu.RLock()
+	s, ok := m.sessions[id]
+	m.mu.RUnlock()
+
+	return s, ok
+}
+
+func (m *wsSessions) GetAll() []*wsSession {
+	m.mu.RLock()
+	sessions := make([]*wsSession, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.RUnlock()
+
+	return sessions
+}
+
+func (m *wsSessions) GetByUser(userID uuid.UUID) []*wsSession {
+	m.mu.RLock()
+	sessions := slices.Clone(m.userSessions[userID])
+	m.mu.RUnlock()
+
+	return sessions
+}
+
+// Create registers session unless its user already has limit sessions, zero limit means no limit.
+func (m *wsSessions) Create(s *wsSession, limit int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limit > 0 && len(m.userSessions[s.userID]) >= limit {
+		return false
+	}
+
+	m.sessions[s.id] = s
+	m.userSessions[s.userID] = append(m.userSessions[s.userID], s)
+
+	return true
+}
+
+func (m *wsSessions) Delete(s *wsSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, s.id)
+
+	m.userSessions[s.userID] = slices.DeleteFunc(m.userSessions[s.userID], func(us *wsSession) bool {
+		return us == s
+	})
+	if len(m.userSessions[s.userID]) == 0 {
+		delete(m.userSessions, s.userID)
+	}
+}
+
+// sessionLimit returns how many sessions user may open.
+func (a *api) sessionLimit(user *model.User) int {
+	if user.MaxSessions != nil {
+		return *user.MaxSessions
+	}
+
+	return a.config.Sessions.MaxPerUser
+}
+
+func userSubscriptions(user *model.User) subscription.Subscriptions {
+	return cloneSubscriptions(subscription.Subscriptions{
+		Trade:        user.Subscription,
+		TradeSymbols: user.SubscriptionSymbols,
+		Topics:       user.SubscriptionTopics,
+		Delivery:     user.SubscriptionDelivery,
+	})
+}
+
+func setUserSubscriptions(user *model.User, subscriptions subscription.Subscriptions) {
+	user.Subscription = subscriptions.Trade
+	user.SubscriptionSymbols = subscriptions.TradeSymbols
+	user.SubscriptionTopics = subscriptions.Topics
+	user.SubscriptionDelivery = subscriptions.Delivery
+}
+
+func cloneSubscriptions(subscriptions subscription.Subscriptions) subscription.Subscriptions {
+	return subscription.Subscriptions{
+		Trade:        subscriptions.Trade,
+		TradeSymbols: append([]string{}, subscriptions.TradeSymbols...),
+		Topics:       append([]string{}, subscriptions.Topics...),
+		Delivery:     subscriptions.Delivery.Normalize(),
+	}
+}
@@ -0,0 +1,409 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/websocket"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/authmiddleware"
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/session"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+	"bitmex-api/pkg/sendqueue"
+)
+
+func TestUserWebSocketHandler_Sessions(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+	first := p.connect(t)
+	second := p.connect(t)
+
+	require.NoError(t, second.WriteJSON(stream.Command{
+		ID:      "1",
+		Op:      stream.SubscribeCommand,
+		Symbols: []string{"ETHUSD"},
+	}))
+
+	var ack stream.AckMessage
+	readJSON(t, second, &ack)
+	require.Equal(t, stream.AckMessageType, ack.Type)
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000},
+	))
+
+	var record bitmex.TradeDataRecord
+	readJSON(t, first, &record)
+	assert.Equal(t, "XBTUSD", record.Symbol)
+
+	readJSON(t, second, &record)
+	assert.Equal(t, "ETHUSD", record.Symbol)
+	readJSON(t, second, &record)
+	assert.Equal(t, "XBTUSD", record.Symbol)
+
+	p.userRepo.EXPECT().UpdateSubscriptions(p.user).Return(nil).Times(1)
+	p.subscribe(t, subscription.Request{Action: subscription.Unsubscribe})
+
+	var infos []session.Info
+	assert.Equal(t, http.StatusOK, p.get(t, "/api/v1/user/sessions", &infos))
+	require.Len(t, infos, 2)
+
+	for _, info := range infos {
+		assert.False(t, info.Subscriptions.Trade)
+		assert.Equal(t, 1, info.Version)
+	}
+
+	require.NoError(t, first.Close())
+	require.Eventually(t, func() bool {
+		return len(p.api.sessions.GetByUser(p.userID)) == 1
+	}, pipelineTimeout, 5*time.Millisecond)
+
+	users, _ := p.api.symbolUser.Get("XBTUSD")
+	assert.Empty(t, users)
+}
+
+func TestUserWebSocketHandler_SessionLimit(t *testing.T) {
+	maxSessions := 1
+	p := initPipeline(t, &model.User{MaxSessions: &maxSessions})
+	p.connect(t)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+pipelineToken)
+
+	url := "ws" + strings.TrimPrefix(p.server.URL, "http") + "/connect"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	defer conn.Close()
+
+	var statusError model.StatusError
+	readJSON(t, conn, &statusError)
+	assert.Equal(t, model.ErrTooManySessions, statusError)
+	assert.Len(t, p.api.sessions.GetByUser(p.userID), 1)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, stream.CloseTooManySessions), err)
+}
+
+// TestSymbolUser_ConcurrentSubscribe is meant to be run with -race, subscribers are read without the lock.
+func TestSymbolUser_ConcurrentSubscribe(t *testing.T) {
+	m := symbolUser{symbolUserSubscriptions: make(map[string][]uuid.UUID)}
+	stable := uuid.NewV4()
+	m.Add("XBTUSD", stable)
+
+	ids := make([]uuid.UUID, 8)
+	for i := range ids {
+		ids[i] = uuid.NewV4()
+	}
+
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+
+	for _, id := range ids {
+		wg.Add(1)
+
+		go func(id uuid.UUID) {
+			defer wg.Done()
+
+			for i := 0; i < 500; i++ {
+				m.Add("XBTUSD", id)
+				runtime.Gosched()
+				m.Delete("XBTUSD", id)
+				runtime.Gosched()
+			}
+		}(id)
+	}
+
+	readers := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+
+		go func() {
+			defer readers.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				users, _ := m.Get("XBTUSD")
+				seen := make(map[uuid.UUID]bool, len(users))
+
+				runtime.Gosched()
+
+				for _, user := range users {
+					assert.NotEqual(t, uuid.Nil, user)
+					assert.False(t, seen[user], "subscriber is listed twice")
+					seen[user] = true
+				}
+
+				assert.True(t, seen[stable], "subscriber is skipped")
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	users, _ := m.Get("XBTUSD")
+	assert.Equal(t, []uuid.UUID{stable}, users)
+}
+
+func TestUserWebSocketHandler_SlowConsumer(t *testing.T) {
+	p := initPipelineSessions(t, nil, config.SessionsConfig{
+		SendQueueSize:      4,
+		OverflowPolicy:     string(sendqueue.Disconnect),
+		WriteTimeout:       config.Duration{Duration: 50 * time.Millisecond},
+		PingInterval:       config.Duration{Duration: time.Second},
+		IdleTimeout:        config.Duration{Duration: pipelineTimeout},
+		TokenCheckInterval: config.Duration{Duration: time.Second},
+		TokenExpiryWarning: config.Duration{Duration: time.Minute},
+	})
+	conn := p.connect(t)
+
+	sessions := p.api.sessions.GetByUser(p.userID)
+	require.Len(t, sessions, 1)
+
+	// client does not read, so socket buffers fill up and the queue overflows
+	frame := []byte(`"` + strings.Repeat("x", 256*1024) + `"`)
+	for i := 0; i < 200 && !sessions[0].queue.Overflowed(); i++ {
+		sessions[0].send("", frame)
+	}
+	require.True(t, sessions[0].queue.Overflowed())
+
+	require.Eventually(t, func() bool {
+		return len(p.api.sessions.GetByUser(p.userID)) == 0
+	}, pipelineTimeout, 5*time.Millisecond)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(pipelineTimeout)))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+}
+
+func TestUserWebSocketHandler_QueueInfo(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	require.NoError(t, conn.WriteJSON(stream.Command{ID: "1", Op: stream.ListCommand}))
+
+	var ack struct {
+		Data session.Info `json:"data"`
+	}
+	readJSON(t, conn, &ack)
+
+	assert.Equal(t, 100, ack.Data.Queue.Capacity)
+	// ack of the connect ping has been written already
+	assert.GreaterOrEqual(t, ack.Data.Queue.Sent, uint64(1))
+	assert.Zero(t, ack.Data.Queue.Dropped)
+}
+
+func keepAliveSessions(pingInterval, idleTimeout time.Duration) config.SessionsConfig {
+	return config.SessionsConfig{
+		SendQueueSize:      100,
+		OverflowPolicy:     string(sendqueue.DropOldest),
+		WriteTimeout:       config.Duration{Duration: pipelineTimeout},
+		CompressionLevel:   1,
+		MaxBatchSize:       100,
+		PingInterval:       config.Duration{Duration: pingInterval},
+		IdleTimeout:        config.Duration{Duration: idleTimeout},
+		TokenCheckInterval: config.Duration{Duration: time.Second},
+		TokenExpiryWarning: config.Duration{Duration: time.Minute},
+	}
+}
+
+func TestUserWebSocketHandler_IdleTimeout(t *testing.T) {
+	p := initPipelineSessions(t, nil, keepAliveSessions(20*time.Millisecond, 100*time.Millisecond))
+	conn := p.connect(t)
+
+	// client that does not read does not answer pings
+	require.Eventually(t, func() bool {
+		return len(p.api.sessions.GetByUser(p.userID)) == 0
+	}, pipelineTimeout, 5*time.Millisecond)
+
+	// server has closed the connection after idle timeout, close frame is not answered
+	conn.SetCloseHandler(func(int, string) error { return nil })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(pipelineTimeout)))
+
+	var err error
+	for err == nil {
+		_, _, err = conn.ReadMessage()
+	}
+
+	assert.True(t, websocket.IsCloseError(err, stream.CloseIdleTimeout), err)
+}
+
+func TestUserWebSocketHandler_KeepAlive(t *testing.T) {
+	p := initPipelineSessions(t, nil, keepAliveSessions(20*time.Millisecond, 100*time.Millisecond))
+	conn := p.connect(t)
+
+	pings := make(chan struct{}, 100)
+	conn.SetPingHandler(func(data string) error {
+		pings <- struct{}{}
+
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(pipelineTimeout))
+	})
+
+	closed := make(chan error, 1)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				closed <- err
+
+				return
+			}
+		}
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Len(t, p.api.sessions.GetByUser(p.userID), 1, "pongs keep the session open")
+	assert.NotEmpty(t, pings)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	p.api.UserWebSocket().CloseSessions(ctx, wg)
+
+	select {
+	case err := <-closed:
+		assert.True(t, websocket.IsCloseError(err, stream.CloseGoingAway), err)
+	case <-time.After(pipelineTimeout):
+		t.Fatal("session was not closed on shutdown")
+	}
+
+	require.Eventually(t, func() bool {
+		return len(p.api.sessions.GetByUser(p.userID)) == 0
+	}, pipelineTimeout, 5*time.Millisecond)
+}
+
+func tokenSessions(checkInterval, expiryWarning time.Duration) config.SessionsConfig {
+	sessions := keepAliveSessions(time.Second, pipelineTimeout)
+	sessions.TokenCheckInterval = config.Duration{Duration: checkInterval}
+	sessions.TokenExpiryWarning = config.Duration{Duration: expiryWarning}
+
+	return sessions
+}
+
+// issueToken makes auth mock accept new access token of the user.
+func (p *pipeline) issueToken(userID uuid.UUID, expiresAt time.Time) string {
+	token := uuid.NewV4().String()
+	p.tokens.Store(token, &authmiddleware.AccessClaims{
+		BaseClaims: authmiddleware.BaseClaims{
+			StandardClaims: jwt.StandardClaims{ExpiresAt: expiresAt.Unix()},
+			ID:             userID,
+			Role:           model.BaseUserRole,
+		},
+	})
+
+	return token
+}
+
+func (p *pipeline) dialToken(t *testing.T, token string) *websocket.Conn {
+	t.Helper()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(p.server.URL, "http")+"/connect?version=2", header)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func TestUserWebSocketHandler_Reauth(t *testing.T) {
+	p := initPipelineSessions(t, nil, tokenSessions(20*time.Millisecond, time.Hour))
+
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second).UTC()
+	conn := p.dialToken(t, p.issueToken(p.userID, expiresAt))
+
+	var expiring stream.TokenExpiringMessage
+	readFrame(t, conn, stream.TokenExpiringMessageType, &expiring)
+	assert.Equal(t, expiresAt, expiring.ExpiresAt)
+
+	// token of other user does not replace the token of the session
+	require.NoError(t, conn.WriteJSON(stream.Command{
+		ID:    "other",
+		Op:    stream.ReauthCommand,
+		Token: p.issueToken(uuid.NewV4(), time.Now().Add(2*time.Hour)),
+	}))
+
+	var errorMessage stream.ErrorMessage
+	readFrame(t, conn, stream.ErrorMessageType, &errorMessage)
+	assert.Equal(t, "other", errorMessage.ID)
+	assert.Equal(t, http.StatusUnauthorized, errorMessage.Code)
+	assert.Equal(t, model.ErrUnauthorized.Error(), errorMessage.Message)
+
+	require.NoError(t, conn.WriteJSON(stream.Command{ID: "invalid", Op: stream.ReauthCommand, Token: "invalid"}))
+	readFrame(t, conn, stream.ErrorMessageType, &errorMessage)
+	assert.Equal(t, "invalid", errorMessage.ID)
+	assert.Equal(t, http.StatusUnauthorized, errorMessage.Code)
+
+	freshExpiresAt := time.Now().Add(2 * time.Hour).Truncate(time.Second).UTC()
+	require.NoError(t, conn.WriteJSON(stream.Command{
+		ID:    "reauth",
+		Op:    stream.ReauthCommand,
+		Token: p.issueToken(p.userID, freshExpiresAt),
+	}))
+
+	var ack struct {
+		stream.AckMessage
+		Data stream.Token `json:"data"`
+	}
+	readFrame(t, conn, stream.AckMessageType, &ack)
+	assert.Equal(t, "reauth", ack.ID)
+	assert.Equal(t, freshExpiresAt, ack.Data.ExpiresAt)
+
+	sessions := p.api.sessions.GetByUser(p.userID)
+	require.Len(t, sessions, 1)
+	require.NotNil(t, sessions[0].Info().ExpiresAt)
+	assert.Equal(t, freshExpiresAt, *sessions[0].Info().ExpiresAt)
+}
+
+func TestUserWebSocketHandler_TokenExpired(t *testing.T) {
+	p := initPipelineSessions(t, nil, tokenSessions(20*time.Millisecond, time.Hour))
+
+	conn := p.dialToken(t, p.issueToken(p.userID, time.Now().Add(time.Second)))
+
+	var expiring stream.TokenExpiringMessage
+	readFrame(t, conn, stream.TokenExpiringMessageType, &expiring)
+
+	err := readClose(t, conn)
+	assert.True(t, websocket.IsCloseError(err, stream.CloseTokenExpired), err)
+	assert.False(t, time.Now().Before(expiring.ExpiresAt))
+
+	require.Eventually(t, func() bool {
+		return len(p.api.sessions.GetByUser(p.userID)) == 0
+	}, pipelineTimeout, 5*time.Millisecond)
+}
+
+func TestUserWebSocketHandler_AccountRemoved(t *testing.T) {
+	p := initPipelineSessions(t, nil, tokenSessions(20*time.Millisecond, time.Hour))
+	conn := p.connect(t)
+
+	p.removed.Store(true)
+
+	err := readClose(t, conn)
+	assert.True(t, websocket.IsCloseError(err, stream.CloseAccountRemoved), err)
+}
@@ -6,8 +6,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"bitmex-api/pkg/alerts"
 	"bitmex-api/pkg/authmiddleware"
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/notify"
+	"bitmex-api/pkg/paper"
 	"bitmex-api/pkg/store"
+	"bitmex-api/pkg/webhook"
 )
 
 func initTestAPI(t *testing.T, middleware authmiddleware.AuthMiddleware, postgres *store.Store) *api {
@@ -18,6 +23,10 @@ func initTestAPI(t *testing.T, middleware authmiddleware.AuthMiddleware, postgre
 		router:        gin.New(),
 		auth:          middleware,
 		postgresStore: postgres,
+		alerts:        alerts.NewEngine(),
+		webhooks:      webhook.NewDispatcher(nil, &config.WebhooksConfig{QueueSize: 1}),
+		mailer:        notify.NewMailer(nil, &config.SMTPConfig{QueueSize: 1}),
+		paper:         paper.NewEngine(),
 	}
 
 	api.router = configureRouter(api)
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+)
+
+// tradeWindow is trades of the symbol received during the current throttle interval.
+type tradeWindow struct {
+	last   bitmex.TradeDataRecord
+	count  int
+	volume int64
+	start  time.Time
+}
+
+// tradeThrottle conflates trades of the throttled session per symbol.
+type tradeThrottle struct {
+	windows map[string]*tradeWindow
+
+	mu sync.Mutex
+}
+
+// add folds record into window of its symbol, true is returned when the record opened the window.
+func (t *tradeThrottle) add(record bitmex.TradeDataRecord, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.windows == nil {
+		t.windows = make(map[string]*tradeWindow)
+	}
+
+	window, ok := t.windows[record.Symbol]
+	if !ok {
+		window = &tradeWindow{start: now}
+		t.windows[record.Symbol] = window
+	}
+
+	window.last = record
+	window.count++
+	window.volume += record.Size
+
+	return !ok
+}
+
+// take removes window of the symbol.
+func (t *tradeThrottle) take(symbol string) (*tradeWindow, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	window, ok := t.windows[symbol]
+	delete(t.windows, symbol)
+
+	return window, ok
+}
+
+// throttleTrade holds trade until throttle interval of its symbol is over.
+func (s *wsSession) throttleTrade(record bitmex.TradeDataRecord, delivery subscription.Delivery) {
+	if s.throttle.add(record, time.Now().UTC()) {
+		time.AfterFunc(delivery.Interval(), func() {
+			s.flushTrades(record.Symbol, delivery.Aggregate)
+		})
+	}
+}
+
+// flushTrades sends the last trade of the window, or the whole window when aggregate is set.
+func (s *wsSession) flushTrades(symbol string, aggregate bool) {
+	window, ok := s.throttle.take(symbol)
+	if !ok {
+		return
+	}
+
+	var payload interface{}
+
+	switch {
+	case aggregate:
+		payload = stream.Envelope{Type: stream.TradeWindowMessageType, Data: stream.TradeWindow{
+			Symbol:    symbol,
+			Price:     window.last.Price,
+			Timestamp: window.last.Timestamp,
+			Count:     window.count,
+			Volume:    window.volume,
+			Start:     window.start,
+			End:       time.Now().UTC(),
+		}}
+	case s.version == stream.V2:
+		payload = stream.Envelope{Type: stream.TradeMessageType, Data: window.last}
+	default:
+		payload = stream.NewLegacyTrade(window.last)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Errorf("JSON marshal:", err)
+
+		return
+	}
+
+	s.send(bitmex.Topic(bitmex.TradeTable, symbol), data)
+}
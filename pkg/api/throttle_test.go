@@ -0,0 +1,77 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+)
+
+func TestUserWebSocketHandler_ThrottledDelivery(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true})
+	conn := p.connectPath(t, "/connect?version=2")
+
+	command := func(command stream.Command) map[string]interface{} {
+		require.NoError(t, conn.WriteJSON(command))
+
+		var reply map[string]interface{}
+		readJSON(t, conn, &reply)
+
+		return reply
+	}
+
+	assert.Equal(t, model.ErrIncorrectDelivery.Error(), command(stream.Command{
+		ID:       "1",
+		Op:       stream.SubscribeCommand,
+		Delivery: &subscription.Delivery{Mode: subscription.ThrottledDelivery, IntervalMs: 10},
+	})["message"])
+
+	assert.Equal(t, "ack", command(stream.Command{
+		ID:       "2",
+		Op:       stream.SubscribeCommand,
+		Symbols:  []string{"XBTUSD"},
+		Delivery: &subscription.Delivery{Mode: subscription.ThrottledDelivery, IntervalMs: 200},
+	})["type"])
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000, Size: 10},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42001, Size: 20},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42002, Size: 30},
+	))
+
+	var trade struct {
+		Type stream.MessageType     `json:"type"`
+		Data bitmex.TradeDataRecord `json:"data"`
+	}
+	readJSON(t, conn, &trade)
+	assert.Equal(t, stream.TradeMessageType, trade.Type)
+	assert.InDelta(t, 42002, trade.Data.Price, 0)
+
+	assert.Equal(t, "ack", command(stream.Command{
+		ID:       "3",
+		Op:       stream.SubscribeCommand,
+		Delivery: &subscription.Delivery{Mode: subscription.ThrottledDelivery, IntervalMs: 200, Aggregate: true},
+	})["type"])
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000, Size: 5},
+		bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2001, Size: 7},
+	))
+
+	var window struct {
+		Type stream.MessageType `json:"type"`
+		Data stream.TradeWindow `json:"data"`
+	}
+	readJSON(t, conn, &window)
+	assert.Equal(t, stream.TradeWindowMessageType, window.Type)
+	assert.Equal(t, "ETHUSD", window.Data.Symbol)
+	assert.InDelta(t, 2001, window.Data.Price, 0)
+	assert.Equal(t, 2, window.Data.Count)
+	assert.Equal(t, int64(12), window.Data.Volume)
+	assert.False(t, window.Data.End.Before(window.Data.Start))
+}
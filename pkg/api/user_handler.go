@@ -1,12 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"bitmex-api/pkg/logger"
 	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/session"
 )
 
 type UserHandler struct {
@@ -48,6 +50,11 @@ func (h *UserHandler) UpdateInfo(c *gin.Context) {
 		return
 	}
 
+	// session limit is set by admin only
+	user.MaxSessions = nil
+	// email is set by verification flow only
+	user.Email, user.EmailVerified, user.EmailAlerts = "", false, false
+
 	err = h.api.postgresStore.User.Update(user)
 	if err != nil {
 		logger.Errorf("UpdatePersonalInfo.Update", err)
@@ -89,3 +96,86 @@ func (h *UserHandler) Get(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+// Sessions
+// @Summary get open /connect sessions of the user
+// @Produce json
+// @Tags User
+// @Security ApiKeyAuth
+// @Success 200 {array} session.Info
+// @Failure 401 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/user/sessions [get]
+//
+//nolint:varnamelen
+func (h *UserHandler) Sessions(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Sessions.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	sessions := h.api.sessions.GetByUser(userID)
+	infos := make([]session.Info, 0, len(sessions))
+
+	for _, s := range sessions {
+		infos = append(infos, s.Info())
+	}
+
+	c.JSON(http.StatusOK, infos)
+}
+
+// SessionLimit
+// @Summary set how many /connect sessions the user may open, admin only
+// @Description null maxSessions resets the limit to the server default, 0 lifts the limit
+// @Produce json
+// @Tags User
+// @Security ApiKeyAuth
+// @Param Limit  body session.LimitRequest  true "Session limit"
+// @Success 200 {object} session.LimitRequest
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/user/session-limit [patch]
+//
+//nolint:varnamelen
+func (h *UserHandler) SessionLimit(c *gin.Context) {
+	request := &session.LimitRequest{}
+	err := c.ShouldBindJSON(&request)
+	if err != nil || (request.MaxSessions != nil && *request.MaxSessions < 0) {
+		logger.Errorf("SessionLimit.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	userRole, err := h.api.getUserRoleFromHeader(c)
+	if err != nil {
+		logger.Errorf("SessionLimit.getUserRoleFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	if userRole != model.AdminUserRole {
+		c.JSON(http.StatusBadRequest, model.ErrInvalidRole)
+
+		return
+	}
+
+	err = h.api.postgresStore.User.UpdateMaxSessions(request.UserID, request.MaxSessions)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, model.ErrUserNotFound)
+
+		return
+	}
+
+	if err != nil {
+		logger.Errorf("SessionLimit.UpdateMaxSessions", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
@@ -16,10 +16,16 @@ import (
 
 	"bitmex-api/pkg/authmiddleware/mockauthmiddleware"
 	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/session"
 	"bitmex-api/pkg/store"
 	"bitmex-api/pkg/store/mockpostgresstore"
 )
 
+var (
+	testUserID      = uuid.NewV4()
+	testMaxSessions = 2
+)
+
 var testMapUserHandler = map[string][]model.TestStructure{
 	"UpdateInfo": {
 		{
@@ -151,6 +157,69 @@ var testMapUserHandler = map[string][]model.TestStructure{
 			},
 		},
 	},
+	"SessionLimit": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPatch,
+			URL:    "https://localhost:8000/api/v1/user/session-limit",
+			Data: session.LimitRequest{
+				UserID:      testUserID,
+				MaxSessions: &testMaxSessions,
+			},
+			ExpectedData: session.LimitRequest{
+				UserID:      testUserID,
+				MaxSessions: &testMaxSessions,
+			},
+			PositiveTest: true,
+			Mock:         makeList(MiddlewareGetUserRoleMock, UserRepoUpdateMaxSessionsMock),
+			MockData: [][]interface{}{
+				{
+					model.AdminUserRole,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPatch,
+			URL:          "https://localhost:8000/api/v1/user/session-limit",
+			Data:         "{",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeRole",
+			Method: http.MethodPatch,
+			URL:    "https://localhost:8000/api/v1/user/session-limit",
+			Data: session.LimitRequest{
+				UserID: testUserID,
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidRole,
+			Mock: makeList(MiddlewareGetUserRoleMock),
+			MockData: [][]interface{}{
+				{
+					model.BaseUserRole,
+				},
+			},
+		},
+		{
+			Name:   "NegativeUserNotFound",
+			Method: http.MethodPatch,
+			URL:    "https://localhost:8000/api/v1/user/session-limit",
+			Data: session.LimitRequest{
+				UserID: testUserID,
+			},
+			PositiveTest: false, WhatError: model.ErrUserNotFound,
+			Mock: makeList(MiddlewareGetUserRoleMock, UserRepoUpdateMaxSessionsMock),
+			MockData: [][]interface{}{
+				{
+					model.AdminUserRole,
+				},
+				{
+					model.ErrRecordNotFound,
+				},
+			},
+		},
+	},
 }
 
 func TestUserHandlers(t *testing.T) {
@@ -323,3 +392,26 @@ func UserRepoGetMock(repos []interface{}, data []interface{}) {
 
 	userRepoMock.EXPECT().Get(gomock.Any()).Return(result, err).Times(1)
 }
+
+func UserRepoUpdateMaxSessionsMock(repos []interface{}, data []interface{}) {
+	var userRepoMock *mockpostgresstore.MockUserRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockUserRepository:
+			userRepoMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	userRepoMock.EXPECT().UpdateMaxSessions(gomock.Any(), gomock.Any()).Return(err).Times(1)
+}
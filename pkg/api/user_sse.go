@@ -0,0 +1,342 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/session"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/replay"
+	"bitmex-api/pkg/sendqueue"
+)
+
+const LastEventIDHeader = "Last-Event-ID"
+
+// sseStream is SSE session of the user. Frames of the session are kept in replay buffer,
+// so the session outlives its request for ReplayTTL and a client reconnecting with Last-Event-ID resumes it.
+type sseStream struct {
+	session *wsSession
+	buffer  *replay.Buffer
+
+	attached bool
+	expiry   *time.Timer
+
+	// final is appended as the last event once the session is closed, it is set before closing
+	final []byte
+}
+
+// pump moves queued frames of the session to the replay buffer until the session is closed.
+func (s *sseStream) pump() {
+	defer close(s.session.closed)
+	defer s.buffer.Close()
+
+	for {
+		message, ok := s.session.queue.Pop()
+		if !ok {
+			if s.final != nil {
+				s.buffer.Append(s.final)
+			}
+
+			return
+		}
+
+		s.buffer.Append(message.Data)
+	}
+}
+
+// sseStreams is registry of SSE sessions, attached or waiting for the client to reconnect.
+type sseStreams struct {
+	streams map[uuid.UUID]*sseStream
+
+	mu sync.Mutex
+}
+
+func (m *sseStreams) add(s *sseStream) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.streams[s.session.id] = s
+}
+
+// attach takes detached stream of the user for the resumed request.
+func (m *sseStreams) attach(id, userID uuid.UUID) (*sseStream, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.streams[id]
+	if !ok || s.attached || s.session.userID != userID {
+		return nil, false
+	}
+
+	s.attached = true
+	s.expiry.Stop()
+
+	return s, true
+}
+
+// detach keeps stream for ttl after its request is over, expire is called unless the stream is attached again.
+func (m *sseStreams) detach(s *sseStream, ttl time.Duration, expire func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.attached = false
+	s.expiry = time.AfterFunc(ttl, func() {
+		m.mu.Lock()
+		detached := !s.attached && m.streams[s.session.id] == s
+		if detached {
+			delete(m.streams, s.session.id)
+		}
+		m.mu.Unlock()
+
+		if detached {
+			expire()
+		}
+	})
+}
+
+func (m *sseStreams) remove(s *sseStream) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.streams, s.session.id)
+}
+
+// removeAll takes every stream out of the registry, detached streams do not expire anymore.
+func (m *sseStreams) removeAll() []*sseStream {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	streams := make([]*sseStream, 0, len(m.streams))
+
+	for id, s := range m.streams {
+		if s.expiry != nil {
+			s.expiry.Stop()
+		}
+
+		streams = append(streams, s)
+		delete(m.streams, id)
+	}
+
+	return streams
+}
+
+// Stream
+// @Summary stream price updates with Server-Sent Events
+// @Description data of every event is the same frame /connect sends, subscriptions are managed with REST API only.
+// @Description event id is sessionId:sequence, reconnect with Last-Event-ID header to resume the session
+// @Description and get the events after it, the session waits for reconnect for SSE_REPLAY_TTL.
+// @Produce text/event-stream
+// @Tags BitMex
+// @Security ApiKeyAuth
+// @Param version query int false "Payload version, 1 or 2"
+// @Param Last-Event-ID header string false "Id of the last received event"
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/bit-mex/stream [get]
+//
+//nolint:varnamelen
+func (h *UserWebSocketHandler) Stream(c *gin.Context) {
+	version, ok := parseVersion(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectVersion)
+
+		return
+	}
+
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil || userID == uuid.Nil {
+		logger.Errorf("Stream.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	s, cursor, ok := h.resumeStream(userID, c.GetHeader(LastEventIDHeader))
+	if !ok {
+		s, err = h.openStream(userID, version)
+		if err != nil {
+			statusError := model.ErrUnhealthy
+			if modelError, ok := err.(model.Error); ok { //nolint:errorlint
+				statusError = modelError
+			}
+
+			c.JSON(statusError.Status(), statusError)
+
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Header(SessionIDHeader, s.session.id.String())
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	h.serveStream(c, s, cursor)
+}
+
+// resumeStream attaches the stream of Last-Event-ID and returns sequence of the event as the cursor.
+// Cursor is zero when the stream is not resumed, so a new stream is served from its first event.
+func (h *UserWebSocketHandler) resumeStream(userID uuid.UUID, lastEventID string) (*sseStream, uint64, bool) {
+	if lastEventID == "" {
+		return nil, 0, false
+	}
+
+	id, sequence, ok := strings.Cut(lastEventID, ":")
+	if !ok {
+		return nil, 0, false
+	}
+
+	sessionID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	cursor, err := strconv.ParseUint(sequence, 10, 64)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	s, ok := h.api.sseStreams.attach(sessionID, userID)
+	if !ok {
+		return nil, 0, false
+	}
+
+	return s, cursor, true
+}
+
+func (h *UserWebSocketHandler) openStream(userID uuid.UUID, version stream.Version) (*sseStream, error) {
+	user, err := h.api.postgresStore.User.Get(userID)
+	if err != nil || user == nil {
+		logger.Errorf("Stream.Get", err)
+
+		return nil, model.ErrUnhealthy
+	}
+
+	sess := newWSSession(uuid.NewV4(), nil, userID, version, h.api.config.Sessions)
+	sess.transport = session.SSETransport
+
+	if !h.api.sessions.Create(sess, h.api.sessionLimit(user)) {
+		return nil, model.ErrTooManySessions
+	}
+
+	s := &sseStream{
+		session:  sess,
+		buffer:   replay.New(h.api.config.SSE.ReplaySize),
+		attached: true,
+	}
+
+	go s.pump()
+	h.api.sseStreams.add(s)
+
+	h.sendState(sess)
+	h.openSession(sess, userSubscriptions(user))
+
+	logger.Infof("user connected %v, sse session %v", userID, sess.id)
+
+	return s, nil
+}
+
+// serveStream writes events after cursor until the client goes away or the session is closed.
+func (h *UserWebSocketHandler) serveStream(c *gin.Context, s *sseStream, cursor uint64) {
+	keepAlive := time.NewTicker(h.api.config.SSE.KeepAliveInterval.Duration)
+	defer keepAlive.Stop()
+
+	for {
+		wait := s.buffer.Wait()
+
+		events, missed := s.buffer.After(cursor)
+		if missed && sendqueue.Policy(h.api.config.Sessions.OverflowPolicy) == sendqueue.Disconnect {
+			h.evictStream(c, s)
+
+			return
+		}
+
+		for _, event := range events {
+			if _, err := fmt.Fprintf(c.Writer, "id: %s:%d\ndata: %s\n\n", s.session.id, event.ID, event.Data); err != nil {
+				h.detachStream(s)
+
+				return
+			}
+
+			cursor = event.ID
+		}
+
+		if len(events) > 0 {
+			c.Writer.Flush()
+		}
+
+		if len(events) == 0 && s.buffer.Closed() {
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			h.detachStream(s)
+
+			return
+		case <-wait:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				h.detachStream(s)
+
+				return
+			}
+
+			c.Writer.Flush()
+		}
+	}
+}
+
+func (h *UserWebSocketHandler) detachStream(s *sseStream) {
+	h.api.sseStreams.detach(s, h.api.config.SSE.ReplayTTL.Duration, func() {
+		logger.Infof("sse session %v of user %v expired", s.session.id, s.session.userID)
+		h.closeSession(s.session)
+	})
+}
+
+// closeStreams ends every SSE session with shutdown disconnect event,
+// so that requests serving the streams return and do not hold up server shutdown.
+func (h *UserWebSocketHandler) closeStreams() {
+	b, err := json.Marshal(stream.DisconnectMessage{Type: stream.DisconnectMessageType, Reason: ShutdownReason})
+	if err != nil {
+		logger.Errorf("error marshal json ", err)
+
+		return
+	}
+
+	for _, s := range h.api.sseStreams.removeAll() {
+		s.final = b
+		h.closeSession(s.session)
+	}
+}
+
+// evictStream closes the stream of the client that fell behind its replay buffer.
+func (h *UserWebSocketHandler) evictStream(c *gin.Context, s *sseStream) {
+	logger.Infof("sse session %v of user %v evicted: replay buffer overrun", s.session.id, s.session.userID)
+
+	h.api.sseStreams.remove(s)
+	h.closeSession(s.session)
+
+	b, err := json.Marshal(stream.DisconnectMessage{Type: stream.DisconnectMessageType, Reason: SlowConsumerReason})
+	if err != nil {
+		logger.Errorf("error marshal json ", err)
+
+		return
+	}
+
+	if _, err = fmt.Fprintf(c.Writer, "data: %s\n\n", b); err == nil {
+		c.Writer.Flush()
+	}
+}
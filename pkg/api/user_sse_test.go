@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/stream"
+)
+
+type sseEvent struct {
+	id   string
+	data []byte
+}
+
+func (p *pipeline) stream(t *testing.T, lastEventID string) (*http.Response, *bufio.Reader, context.CancelFunc) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.server.URL+"/api/v1/bit-mex/stream?version=2", nil)
+	require.NoError(t, err)
+
+	if lastEventID != "" {
+		req.Header.Set(LastEventIDHeader, lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cancel()
+		_ = resp.Body.Close()
+	})
+
+	return resp, bufio.NewReader(resp.Body), cancel
+}
+
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var event sseEvent
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && event.data != nil:
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = []byte(strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func TestUserWebSocketHandler_Stream(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+
+	resp, reader, cancel := p.stream(t, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	sessionID, err := uuid.FromString(resp.Header.Get(SessionIDHeader))
+	require.NoError(t, err)
+
+	session, ok := p.api.sessions.Get(sessionID)
+	require.True(t, ok)
+	assert.Equal(t, "sse", string(session.Info().Transport))
+
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000}))
+
+	var trade struct {
+		Type stream.MessageType     `json:"type"`
+		Data bitmex.TradeDataRecord `json:"data"`
+	}
+
+	event := readEvent(t, reader)
+	require.NoError(t, json.Unmarshal(event.data, &trade))
+	assert.Equal(t, stream.TradeMessageType, trade.Type)
+	assert.InDelta(t, 42000, trade.Data.Price, 0)
+	assert.Equal(t, sessionID.String()+":1", event.id)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		p.api.sseStreams.mu.Lock()
+		defer p.api.sseStreams.mu.Unlock()
+
+		return !p.api.sseStreams.streams[sessionID].attached
+	}, pipelineTimeout, 5*time.Millisecond)
+
+	// trades keep coming to the detached session and are replayed on resume
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42001}))
+	require.Eventually(t, func() bool {
+		p.api.sseStreams.mu.Lock()
+		defer p.api.sseStreams.mu.Unlock()
+
+		events, _ := p.api.sseStreams.streams[sessionID].buffer.After(1)
+
+		return len(events) == 1
+	}, pipelineTimeout, 5*time.Millisecond)
+
+	resp, reader, _ = p.stream(t, event.id)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, sessionID.String(), resp.Header.Get(SessionIDHeader))
+
+	event = readEvent(t, reader)
+	require.NoError(t, json.Unmarshal(event.data, &trade))
+	assert.InDelta(t, 42001, trade.Data.Price, 0)
+	assert.Equal(t, sessionID.String()+":2", event.id)
+	assert.Len(t, p.api.sessions.GetByUser(p.userID), 1)
+}
+
+func TestUserWebSocketHandler_StreamUnknownSession(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+
+	// session of Last-Event-ID is unknown or expired, so a new session is opened from its first event
+	resp, reader, _ := p.stream(t, uuid.NewV4().String()+":5")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	sessionID, err := uuid.FromString(resp.Header.Get(SessionIDHeader))
+	require.NoError(t, err)
+
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000}))
+
+	var trade struct {
+		Data bitmex.TradeDataRecord `json:"data"`
+	}
+
+	event := readEvent(t, reader)
+	require.NoError(t, json.Unmarshal(event.data, &trade))
+	assert.InDelta(t, 42000, trade.Data.Price, 0)
+	assert.Equal(t, sessionID.String()+":1", event.id)
+}
+
+func TestUserWebSocketHandler_StreamShutdown(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+
+	resp, reader, _ := p.stream(t, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go p.api.UserWebSocket().CloseSessions(ctx, wg)
+
+	cancel()
+	wg.Wait()
+
+	var disconnect stream.DisconnectMessage
+
+	event := readEvent(t, reader)
+	require.NoError(t, json.Unmarshal(event.data, &disconnect))
+	assert.Equal(t, stream.DisconnectMessageType, disconnect.Type)
+	assert.Equal(t, ShutdownReason, disconnect.Reason)
+
+	// the stream request is over, so shutdown does not wait for the client to go away
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), pipelineTimeout)
+	defer shutdownCancel()
+
+	require.NoError(t, p.server.Config.Shutdown(shutdownCtx))
+	assert.Empty(t, p.api.sessions.GetByUser(p.userID))
+}
@@ -1,20 +1,33 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	uuid "github.com/satori/go.uuid"
 
+	"bitmex-api/pkg/bitmexclient"
 	"bitmex-api/pkg/logger"
 	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/stream"
 	"bitmex-api/pkg/model/ui/subscription"
+	"bitmex-api/pkg/streamcodec"
 )
 
 const (
+	// SessionIDHeader is set in /connect handshake response to the id of the opened session.
+	SessionIDHeader = "X-Session-Id"
 	// ReadBufferSize is buffer sizes for read.
 	ReadBufferSize int = 1024
 	// WriteBufferSize is buffer sizes for write.
@@ -60,118 +73,886 @@ func (h *UserWebSocketHandler) SubscribeAction(c *gin.Context) {
 		return
 	}
 
+	if err = h.applyRequest(userID, action); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription.Response{Success: true})
+}
+
+// applyRequest applies subscription request to stored subscriptions of the user and to every open session of the user.
+// Sessions where the request is not valid, e.g. it was already applied by a session command, are left as they are.
+func (h *UserWebSocketHandler) applyRequest(userID uuid.UUID, action *subscription.Request) error {
+	if err := h.addWatchlistSymbols(userID, action); err != nil {
+		return err
+	}
+
 	user, err := h.api.postgresStore.User.Get(userID)
 	if err != nil {
 		logger.Errorf("Subscribe.Get", err)
-		c.JSON(http.StatusBadRequest, model.ErrUnhealthy)
 
-		return
+		return model.ErrUnhealthy
 	}
 
-	if action.Action == subscription.Subscribe {
-		if err = h.subscribeActions(user, action); err != nil {
-			c.JSON(http.StatusBadRequest, err)
+	subscriptions, err := h.applyAction(userSubscriptions(user), action)
+	if err != nil {
+		return err
+	}
 
-			return
+	setUserSubscriptions(user, subscriptions)
+
+	if err = h.api.postgresStore.User.UpdateSubscriptions(user); err != nil {
+		logger.Errorf("Subscribe.UpdateSubscriptions", err)
+
+		return model.ErrUnhealthy
+	}
+
+	for _, session := range h.api.sessions.GetByUser(userID) {
+		snapshots, err := h.applySessionAction(session, action)
+		if err != nil {
+			logger.Infof("session %v skipped subscription request: %v", session.id, err)
+
+			continue
 		}
-	} else {
-		if !user.Subscription {
-			c.JSON(http.StatusBadRequest, model.ErrAlreadyUnsubscribed)
 
-			return
+		session.sendSnapshots(snapshots)
+	}
+
+	return nil
+}
+
+// addWatchlistSymbols adds symbols of the watchlist of the request to its symbols.
+// Empty watchlist is rejected, so that it is not taken for all symbols.
+func (h *UserWebSocketHandler) addWatchlistSymbols(userID uuid.UUID, action *subscription.Request) error {
+	if action.Watchlist == nil {
+		return nil
+	}
+
+	watchlist, err := h.api.postgresStore.Watchlist.Get(userID, *action.Watchlist)
+	if err != nil {
+		logger.Errorf("Subscribe.Watchlist.Get", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			return model.ErrWatchlistNotFound
 		}
 
-		allSymbolNames := h.api.allSymbols.GetAll()
+		return model.ErrUnhealthy
+	}
 
-		for _, symbol := range allSymbolNames {
-			h.api.DeleteSymbolUser(symbol, userID)
+	if len(watchlist.Symbols) == 0 {
+		return model.ErrEmptyWatchlist
+	}
+
+	symbols := slices.Clone(action.Symbols)
+	for _, symbol := range watchlist.Symbols {
+		if !slices.Contains(symbols, symbol) {
+			symbols = append(symbols, symbol)
 		}
+	}
+
+	action.Symbols = symbols
+
+	return nil
+}
+
+// applySessionAction applies subscription request to the session only, it is not stored.
+// It returns snapshots of the symbols the session has subscribed to.
+func (h *UserWebSocketHandler) applySessionAction(
+	session *wsSession,
+	action *subscription.Request,
+) ([]stream.Snapshot, error) {
+	session.subMu.Lock()
+	defer session.subMu.Unlock()
 
-		user.Subscription = false
-		user.SubscriptionSymbols = []string{}
+	subscriptions, err := h.applyAction(session.subscriptions, action)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = h.api.postgresStore.User.Update(user); err != nil {
-		logger.Errorf("Subscribe.Update", err)
-		c.JSON(http.StatusBadRequest, model.ErrUnhealthy)
+	h.api.route(session.id, session.subscriptions, subscriptions)
+	snapshots := h.api.snapshots(session.subscriptions, subscriptions)
+	session.subscriptions = subscriptions
 
-		return
+	return snapshots, nil
+}
+
+// applyAction returns subscriptions with the request applied, given subscriptions are not changed.
+func (h *UserWebSocketHandler) applyAction(
+	subscriptions subscription.Subscriptions,
+	action *subscription.Request,
+) (subscription.Subscriptions, error) {
+	subscriptions = cloneSubscriptions(subscriptions)
+
+	var err error
+	if action.Action == subscription.Subscribe {
+		err = h.subscribeActions(&subscriptions, action)
+	} else {
+		err = h.unsubscribeActions(&subscriptions, action)
 	}
 
-	c.JSON(http.StatusOK, subscription.Response{Success: true})
+	return subscriptions, err
 }
 
-func (h *UserWebSocketHandler) subscribeActions(user *model.User, action *subscription.Request) error {
-	if len(action.Symbols) == 0 {
-		symbolNames := h.api.allSymbols.GetAll()
-		for _, symbolName := range symbolNames {
-			users, ok := h.api.symbolUser.Get(symbolName)
-			if !ok {
-				continue
+func (h *UserWebSocketHandler) subscribeActions(subscriptions *subscription.Subscriptions, action *subscription.Request) error {
+	channels := action.Channels
+	if len(channels) == 0 {
+		channels = []subscription.Channel{subscription.TradeChannel}
+	}
+
+	deliveryChanged := false
+	if action.Delivery != nil {
+		if !action.Delivery.Valid() {
+			return model.ErrIncorrectDelivery
+		}
+
+		delivery := action.Delivery.Normalize()
+		deliveryChanged = subscriptions.Delivery != delivery
+		subscriptions.Delivery = delivery
+	}
+
+	// watchlists may overlap with each other and with symbols subscribed before
+	merge := action.Watchlist != nil
+
+	for _, channel := range channels {
+		var err error
+
+		switch {
+		case channel == subscription.TradeChannel:
+			err = h.subscribeTrades(subscriptions, action.Symbols, merge)
+			// subscribe to already subscribed trades changes their delivery only
+			if errors.Is(err, model.ErrAlreadySubscribed) && deliveryChanged {
+				err = nil
 			}
+		case isTopicChannel(channel):
+			err = h.subscribeTopics(subscriptions, string(channel), action.Symbols, merge)
+		default:
+			err = model.ErrIncorrectChannel
+		}
 
-			h.api.symbolUser.Insert(symbolName, append(users, user.UserID))
+		if err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		user.SubscriptionSymbols = []string{}
+// isTopicChannel reports whether channel subscriptions are kept in topics.
+func isTopicChannel(channel subscription.Channel) bool {
+	_, isCandle := channel.CandleInterval()
+
+	return isCandle || channel == subscription.QuoteChannel || channel == subscription.OrderBookChannel
+}
+
+// subscribeTrades subscribes to trades of symbols, all symbols are taken when symbols are empty.
+// Merge skips symbols which are already subscribed, the request fails only when no symbol is left.
+func (h *UserWebSocketHandler) subscribeTrades(
+	subscriptions *subscription.Subscriptions,
+	symbols []string,
+	merge bool,
+) error {
+	if len(symbols) == 0 {
+		subscriptions.Trade = true
+		subscriptions.TradeSymbols = []string{}
+
+		return nil
 	}
 
-	for _, symbol := range action.Symbols {
-		users, ok := h.api.symbolUser.Get(symbol)
+	allSymbols := subscriptions.Trade && len(subscriptions.TradeSymbols) == 0
+	added := false
 
-		if !ok {
+	for _, symbol := range symbols {
+		if _, ok := h.api.symbolUser.Get(symbol); !ok {
 			return model.ErrIncorrectSymbol
 		}
 
-		if slices.Contains(users, user.UserID) || slices.Contains(user.SubscriptionSymbols, symbol) {
+		if allSymbols || slices.Contains(subscriptions.TradeSymbols, symbol) {
+			if merge {
+				continue
+			}
+
 			return model.ErrAlreadySubscribed
 		}
 
-		h.api.symbolUser.Insert(symbol, append(users, user.UserID))
+		subscriptions.TradeSymbols = append(subscriptions.TradeSymbols, symbol)
+		added = true
+	}
+
+	if !added {
+		return model.ErrAlreadySubscribed
+	}
+
+	subscriptions.Trade = true
+
+	return nil
+}
+
+// subscribeTopics subscribes to table topics, all symbols are taken when symbols are empty.
+// Merge skips symbols which are already subscribed, the request fails only when no symbol is left.
+func (h *UserWebSocketHandler) subscribeTopics(
+	subscriptions *subscription.Subscriptions,
+	table string,
+	symbols []string,
+	merge bool,
+) error {
+	explicit := len(symbols) != 0
+	if !explicit {
+		symbols = h.api.allSymbols.GetAll()
+	}
+
+	added := false
+
+	for _, symbol := range symbols {
+		if _, ok := h.api.symbolUser.Get(symbol); !ok {
+			return model.ErrIncorrectSymbol
+		}
+
+		topic := bitmex.Topic(table, symbol)
+		if slices.Contains(subscriptions.Topics, topic) {
+			if explicit && !merge {
+				return model.ErrAlreadySubscribed
+			}
+
+			continue
+		}
+
+		subscriptions.Topics = append(subscriptions.Topics, topic)
+		added = true
+	}
+
+	if explicit && !added {
+		return model.ErrAlreadySubscribed
+	}
+
+	return nil
+}
+
+// unsubscribeActions unsubscribes from channels of the request, every channel is taken when channels are empty.
+// Only the listed symbols are unsubscribed when symbols are given.
+func (h *UserWebSocketHandler) unsubscribeActions(
+	subscriptions *subscription.Subscriptions,
+	action *subscription.Request,
+) error {
+	// watchlist may name symbols which were never subscribed or were unsubscribed by another watchlist
+	merge := action.Watchlist != nil
+
+	if len(action.Channels) == 0 {
+		if !subscriptions.Trade && len(subscriptions.Topics) == 0 {
+			return model.ErrAlreadyUnsubscribed
+		}
+
+		if len(action.Symbols) == 0 {
+			*subscriptions = subscription.Subscriptions{
+				TradeSymbols: []string{},
+				Topics:       []string{},
+				Delivery:     subscription.Delivery{Mode: subscription.RealtimeDelivery},
+			}
+
+			return nil
+		}
+
+		return h.unsubscribeSymbols(subscriptions, action.Symbols, merge)
+	}
+
+	for _, channel := range action.Channels {
+		var err error
+
+		switch {
+		case channel == subscription.TradeChannel:
+			err = h.unsubscribeTrades(subscriptions, action.Symbols, merge)
+		case isTopicChannel(channel):
+			err = unsubscribeTopics(subscriptions, string(channel), action.Symbols, merge)
+		default:
+			err = model.ErrIncorrectChannel
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unsubscribeSymbols unsubscribes symbols from trades and every topic, symbol without any subscription is rejected.
+// Merge skips symbols without subscriptions, the request fails only when nothing is unsubscribed.
+func (h *UserWebSocketHandler) unsubscribeSymbols(
+	subscriptions *subscription.Subscriptions,
+	symbols []string,
+	merge bool,
+) error {
+	removed := false
+
+	for _, symbol := range symbols {
+		found := false
+
+		if h.tradeSubscribed(subscriptions, symbol) {
+			if err := h.unsubscribeTrades(subscriptions, []string{symbol}, false); err != nil {
+				return err
+			}
+
+			found = true
+		}
+
+		topics := len(subscriptions.Topics)
+		subscriptions.Topics = slices.DeleteFunc(subscriptions.Topics, func(topic string) bool {
+			return strings.HasSuffix(topic, ":"+symbol)
+		})
+
+		if found || len(subscriptions.Topics) != topics {
+			removed = true
+		} else if !merge {
+			return model.ErrAlreadyUnsubscribed
+		}
+	}
+
+	if !removed {
+		return model.ErrAlreadyUnsubscribed
+	}
+
+	return nil
+}
+
+// tradeSubscribed reports whether trades of the symbol are subscribed, empty symbols stand for all symbols.
+func (h *UserWebSocketHandler) tradeSubscribed(subscriptions *subscription.Subscriptions, symbol string) bool {
+	return subscriptions.Trade &&
+		(len(subscriptions.TradeSymbols) == 0 || slices.Contains(subscriptions.TradeSymbols, symbol))
+}
+
+// unsubscribeTrades unsubscribes trades of symbols, all trades are unsubscribed when symbols are empty.
+// Trades of all symbols turn into the list of known symbols without the unsubscribed ones.
+// Merge skips symbols which are not subscribed, the request fails only when no symbol is unsubscribed.
+func (h *UserWebSocketHandler) unsubscribeTrades(
+	subscriptions *subscription.Subscriptions,
+	symbols []string,
+	merge bool,
+) error {
+	if !subscriptions.Trade {
+		return model.ErrAlreadyUnsubscribed
+	}
+
+	tradeSymbols := subscriptions.TradeSymbols
+	if len(tradeSymbols) == 0 {
+		tradeSymbols = h.api.allSymbols.GetAll()
+	}
+
+	tradeSymbols = slices.Clone(tradeSymbols)
+	removed := false
+
+	for _, symbol := range symbols {
+		if !slices.Contains(tradeSymbols, symbol) {
+			if merge {
+				continue
+			}
+
+			return model.ErrAlreadyUnsubscribed
+		}
+
+		tradeSymbols = slices.DeleteFunc(tradeSymbols, func(s string) bool { return s == symbol })
+		removed = true
+	}
+
+	if len(symbols) != 0 && !removed {
+		return model.ErrAlreadyUnsubscribed
+	}
+
+	if len(symbols) != 0 && len(tradeSymbols) != 0 {
+		subscriptions.TradeSymbols = tradeSymbols
+
+		return nil
+	}
+
+	subscriptions.Trade = false
+	subscriptions.TradeSymbols = []string{}
+	subscriptions.Delivery = subscription.Delivery{Mode: subscription.RealtimeDelivery}
+
+	return nil
+}
+
+// unsubscribeTopics unsubscribes table topics of symbols, every topic of the table is taken when symbols are empty.
+// Merge skips symbols which are not subscribed, the request fails only when no topic is unsubscribed.
+func unsubscribeTopics(subscriptions *subscription.Subscriptions, table string, symbols []string, merge bool) error {
+	topics := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		topic := bitmex.Topic(table, symbol)
+		if !slices.Contains(subscriptions.Topics, topic) {
+			if merge {
+				continue
+			}
+
+			return model.ErrAlreadyUnsubscribed
+		}
 
-		user.SubscriptionSymbols = append(user.SubscriptionSymbols, symbol)
+		topics = append(topics, topic)
 	}
 
+	if len(symbols) != 0 && len(topics) == 0 {
+		return model.ErrAlreadyUnsubscribed
+	}
+
+	subscriptions.Topics = slices.DeleteFunc(subscriptions.Topics, func(topic string) bool {
+		if len(symbols) == 0 {
+			return strings.HasPrefix(topic, table+":")
+		}
+
+		return slices.Contains(topics, topic)
+	})
+
 	return nil
 }
 
+// Connect
+// @Summary connect to price updates WebSocket
+// @Description version 1 (default) sends trades as {symbol, price, timestamp},
+// @Description version 2 sends trades with every BitMex field in {"type": "trade", "data": {...}}
+// @Description subscriptions are managed with commands {"id": "1", "op": "subscribe", "symbols": [], "channels": []},
+// @Description op is one of subscribe, unsubscribe, list or ping, every command is answered with ack or error frame
+// @Description last trade and quote of subscribed symbols are sent as {"type": "snapshot", "data": {...}} after connect and subscribe
+// @Description encoding query or Sec-WebSocket-Protocol header chooses json (default), msgpack or protobuf frames,
+// @Description binary frames follow the schema of proto/stream.proto, protobuf uses version 2 payloads only
+// @Description batch=true packs frames of one BitMex message into one array, permessage-deflate is used when the client offers it
+// @Description the server pings the client, session without messages and pongs for WS_IDLE_TIMEOUT is closed with code 4000
+// @Description {"type": "tokenExpiring", "expiresAt": "..."} is sent WS_TOKEN_EXPIRY_WARNING ahead of access token expiry,
+// @Description {"id": "1", "op": "reauth", "token": "..."} replaces the token, otherwise the session is closed with code 4003,
+// @Description sessions of removed users are closed with code 4004
+// @Tags User
+// @Security ApiKeyAuth
+// @Param version query int false "Payload version, 1 or 2"
+// @Param encoding query string false "Frame encoding: json, msgpack or protobuf"
+// @Param batch query bool false "Send frames of one BitMex message in one array"
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /connect [get]
+//
 //nolint:varnamelen
 func (h *UserWebSocketHandler) Connect(c *gin.Context) {
+	version, ok := parseVersion(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectVersion)
+
+		return
+	}
+
+	encoding, ok := parseEncoding(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectEncoding)
+
+		return
+	}
+
+	// protobuf schema has no legacy trade
+	if encoding == streamcodec.Protobuf {
+		version = stream.V2
+	}
+
+	codec := streamcodec.New(encoding)
+
+	batch, err := strconv.ParseBool(c.DefaultQuery("batch", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectBatch)
+
+		return
+	}
+
 	upgrader := websocket.Upgrader{
-		ReadBufferSize:  ReadBufferSize,
-		WriteBufferSize: WriteBufferSize,
+		ReadBufferSize:    ReadBufferSize,
+		WriteBufferSize:   WriteBufferSize,
+		Subprotocols:      []string{string(encoding)},
+		EnableCompression: true,
 	}
 
 	upgrader.CheckOrigin = func(r *http.Request) bool { return true }
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	sessionID := uuid.NewV4()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, http.Header{SessionIDHeader: {sessionID.String()}})
 	if err != nil {
 		return
 	}
 	defer conn.Close()
 
-	userID, err := h.api.getUserIDFromHeader(c)
-	if err != nil || userID == uuid.Nil {
-		b, err := json.Marshal(model.ErrUnauthorized)
+	// level is used only when the client has negotiated permessage-deflate
+	if err = conn.SetCompressionLevel(h.api.config.Sessions.CompressionLevel); err != nil {
+		logger.Errorf("Connect.SetCompressionLevel", err)
+	}
+
+	claims, statusError := h.api.authorizeToken(strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", -1))
+	if statusError != nil {
+		h.reject(conn, codec, model.ErrUnauthorized, stream.CloseUnauthorized)
+
+		return
+	}
+
+	userID := claims.BaseClaims.ID
+
+	user, err := h.api.postgresStore.User.Get(userID)
+	if err != nil || user == nil {
+		logger.Errorf("Connect.Get", err)
+		h.reject(conn, codec, model.ErrUnhealthy, stream.CloseInternalError)
+
+		return
+	}
+
+	session := newWSSession(sessionID, conn, userID, version, h.api.config.Sessions)
+	session.codec = codec
+	session.batch = batch
+	session.authorize(claims)
+
+	if !h.api.sessions.Create(session, h.api.sessionLimit(user)) {
+		h.reject(conn, codec, model.ErrTooManySessions, stream.CloseTooManySessions)
+
+		return
+	}
+	defer h.closeSession(session)
+
+	go session.writeLoop(h.api.config.Sessions.WriteTimeout.Duration)
+	go session.pingLoop(h.api.config.Sessions.PingInterval.Duration, h.api.config.Sessions.WriteTimeout.Duration)
+
+	h.sendState(session)
+	h.openSession(session, userSubscriptions(user))
+
+	logger.Infof("user connected %v, session %v", userID, session.id)
+
+	h.readLoop(session)
+}
+
+// readLoop handles commands of the session until the connection is closed. Session sending neither a message
+// nor a pong for the idle timeout is closed with CloseIdleTimeout.
+func (h *UserWebSocketHandler) readLoop(session *wsSession) {
+	idleTimeout := h.api.config.Sessions.IdleTimeout.Duration
+
+	session.conn.SetPongHandler(func(string) error {
+		return session.keepAlive(idleTimeout)
+	})
+
+	for {
+		if err := session.keepAlive(idleTimeout); err != nil {
+			return
+		}
+
+		messageType, message, err := session.conn.ReadMessage()
 		if err != nil {
-			logger.Errorf("error marshal json ", err)
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && !session.closing.Load() {
+				logger.Infof("session %v of user %v closed: idle timeout", session.id, session.userID)
+				session.closeConn(h.api.config.Sessions.WriteTimeout.Duration, stream.CloseIdleTimeout, IdleTimeoutReason)
+			}
+
+			return
+		}
+
+		switch {
+		case messageType == websocket.TextMessage:
+			h.handleCommand(session, message)
+		case messageType == websocket.BinaryMessage && session.codec.Binary():
+			command, err := session.codec.Decode(message)
+			if err != nil {
+				h.sendCommandError(session, stream.Command{}, model.ErrInvalidBody)
+
+				continue
+			}
+
+			h.handleCommand(session, command)
+		}
+	}
+}
+
+// CloseSessions closes /connect sessions with CloseGoingAway, ends SSE streams with shutdown event
+// and gRPC streams with Unavailable status once ctx is done.
+func (h *UserWebSocketHandler) CloseSessions(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	<-ctx.Done()
+
+	h.closeStreams()
+
+	for _, session := range h.api.sessions.GetAll() {
+		if session.conn != nil {
+			session.closeConn(h.api.config.Sessions.WriteTimeout.Duration, stream.CloseGoingAway, ShutdownReason)
+
+			continue
+		}
+
+		session.closeStream()
+	}
+
+	logger.Infof("closeSessions done")
+}
+
+// CheckTokens checks access tokens of /connect sessions every WS_TOKEN_CHECK_INTERVAL until ctx is done.
+func (h *UserWebSocketHandler) CheckTokens(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(h.api.config.Sessions.TokenCheckInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("checkTokens done")
+
+			return
+		case now := <-ticker.C:
+			h.checkTokens(now)
 		}
-		if err = conn.WriteMessage(websocket.TextMessage, b); err != nil {
-			logger.Errorf("error send message ", err)
+	}
+}
+
+// checkTokens closes sessions of removed users with CloseAccountRemoved and sessions with expired token
+// with CloseTokenExpired, sessions whose token expires within WS_TOKEN_EXPIRY_WARNING get tokenExpiring frame.
+// User is removed as well when its role differs from the role of the token, as AuthMiddleware.Authorize checks.
+func (h *UserWebSocketHandler) checkTokens(now time.Time) {
+	writeTimeout := h.api.config.Sessions.WriteTimeout.Duration
+	roles := make(map[uuid.UUID]model.UserRole)
+
+	for _, session := range h.api.sessions.GetAll() {
+		if session.conn == nil || session.closing.Load() {
+			continue
 		}
 
+		role, ok := roles[session.userID]
+		if !ok {
+			if userDB, exists := h.api.postgresStore.Auth.Get(session.userID); exists {
+				role = userDB.Role
+			}
+
+			roles[session.userID] = role
+		}
+
+		expiresAt := session.tokenExpiresAt()
+
+		switch {
+		case role == "" || role != session.tokenRole():
+			logger.Infof("session %v of user %v closed: account removed", session.id, session.userID)
+			session.closeConn(writeTimeout, stream.CloseAccountRemoved, AccountRemovedReason)
+		case !expiresAt.IsZero() && !now.Before(expiresAt):
+			logger.Infof("session %v of user %v closed: token expired", session.id, session.userID)
+			session.closeConn(writeTimeout, stream.CloseTokenExpired, TokenExpiredReason)
+		case session.tokenExpiring(now, h.api.config.Sessions.TokenExpiryWarning.Duration):
+			b, err := json.Marshal(stream.TokenExpiringMessage{Type: stream.TokenExpiringMessageType, ExpiresAt: expiresAt})
+			if err != nil {
+				logger.Errorf("error marshal json ", err)
+
+				continue
+			}
+
+			session.send("", b)
+		}
+	}
+}
+
+// reauth replaces access token of the session with a fresh token of the same user.
+func (h *UserWebSocketHandler) reauth(session *wsSession, token string) (*stream.Token, error) {
+	claims, err := h.api.authorizeToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.BaseClaims.ID != session.userID {
+		return nil, model.ErrUnauthorized
+	}
+
+	session.authorize(claims)
+
+	return &stream.Token{ExpiresAt: session.tokenExpiresAt()}, nil
+}
+
+// parseVersion reads payload version from version query, V1 is used when it is omitted.
+func parseVersion(c *gin.Context) (stream.Version, bool) {
+	version, err := strconv.Atoi(c.DefaultQuery("version", strconv.Itoa(int(stream.V1))))
+	if err != nil || !stream.Version(version).Valid() {
+		return 0, false
+	}
+
+	return stream.Version(version), true
+}
+
+// parseEncoding reads frame encoding from encoding query or from the first known subprotocol
+// of Sec-WebSocket-Protocol header, JSON is used when both are omitted.
+func parseEncoding(c *gin.Context) (streamcodec.Encoding, bool) {
+	if encoding := streamcodec.Encoding(c.Query("encoding")); encoding != "" {
+		return encoding, encoding.Valid()
+	}
+
+	for _, protocol := range websocket.Subprotocols(c.Request) {
+		if encoding := streamcodec.Encoding(protocol); encoding.Valid() {
+			return encoding, true
+		}
+	}
+
+	return streamcodec.JSON, true
+}
+
+// sendState tells new session that upstream is not connected, connected state is not sent.
+func (h *UserWebSocketHandler) sendState(session *wsSession) {
+	state := h.api.bitMexClient.State()
+	if state == bitmexclient.StateConnected {
 		return
 	}
 
-	h.api.userWSConn.Create(conn, userID)
-	defer h.api.userWSConn.Delete(conn)
+	b, err := statusMessage(state)
+	if err != nil {
+		logger.Errorf("error marshal json ", err)
+
+		return
+	}
+
+	session.send("", b)
+}
 
-	logger.Infof("user connected %v", userID)
+// openSession starts streaming subscriptions to the session, known prices of subscribed symbols are sent at once.
+func (h *UserWebSocketHandler) openSession(session *wsSession, subscriptions subscription.Subscriptions) {
+	session.subMu.Lock()
+	defer session.subMu.Unlock()
 
+	h.api.route(session.id, subscription.Subscriptions{}, subscriptions)
+	session.subscriptions = subscriptions
+
+	session.sendSnapshots(h.api.snapshots(subscription.Subscriptions{}, subscriptions))
+}
+
+func (h *UserWebSocketHandler) closeSession(session *wsSession) {
+	session.subMu.Lock()
+	h.api.route(session.id, session.subscriptions, subscription.Subscriptions{})
+	session.subscriptions = subscription.Subscriptions{}
+	session.subMu.Unlock()
+
+	h.api.sessions.Delete(session)
+	session.close()
+}
+
+// reject sends error to the connection refused a session and closes it with close handshake.
+func (h *UserWebSocketHandler) reject(conn *websocket.Conn, codec streamcodec.Codec, statusError model.Error, code int) {
+	writeError(conn, codec, statusError)
+
+	deadline := time.Now().Add(h.api.config.Sessions.WriteTimeout.Duration)
+
+	if err := conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, statusError.Error()), deadline); err != nil {
+		return
+	}
+
+	// wait for close frame of the client, messages before it are dropped
+	_ = conn.SetReadDeadline(deadline)
 	for {
-		messageType, _, err := conn.ReadMessage()
-		if messageType == -1 || err != nil {
+		if _, _, err := conn.ReadMessage(); err != nil {
 			return
 		}
 	}
 }
+
+func writeError(conn *websocket.Conn, codec streamcodec.Codec, statusError model.Error) {
+	b, err := json.Marshal(statusError)
+	if err != nil {
+		logger.Errorf("error marshal json ", err)
+	}
+
+	messageType := websocket.TextMessage
+	if codec.Binary() {
+		messageType = websocket.BinaryMessage
+	}
+
+	if b, err = codec.Encode(b); err != nil {
+		logger.Errorf("error encode message ", err)
+
+		return
+	}
+
+	if err = conn.WriteMessage(messageType, b); err != nil {
+		logger.Errorf("error send message ", err)
+	}
+}
+
+// handleCommand executes client command on the session and replies with ack or error frame,
+// snapshots of subscribed symbols follow the ack.
+// Commands change subscriptions of the session only, stored subscriptions are changed with REST API.
+func (h *UserWebSocketHandler) handleCommand(session *wsSession, message []byte) {
+	var command stream.Command
+	if err := json.Unmarshal(message, &command); err != nil {
+		h.sendCommandError(session, command, model.ErrInvalidBody)
+
+		return
+	}
+
+	var data interface{}
+	var snapshots []stream.Snapshot
+	var err error
+
+	switch command.Op {
+	case stream.SubscribeCommand:
+		snapshots, err = h.applySessionCommand(session, subscription.Subscribe, command)
+	case stream.UnsubscribeCommand:
+		_, err = h.applySessionCommand(session, subscription.Unsubscribe, command)
+	case stream.ListCommand:
+		data = session.Info()
+	case stream.PingCommand:
+	case stream.ReauthCommand:
+		data, err = h.reauth(session, command.Token)
+	default:
+		err = model.ErrIncorrectCommand
+	}
+
+	if err != nil {
+		h.sendCommandError(session, command, err)
+
+		return
+	}
+
+	b, err := json.Marshal(stream.AckMessage{
+		Type: stream.AckMessageType,
+		ID:   command.ID,
+		Op:   command.Op,
+		Data: data,
+	})
+	if err != nil {
+		logger.Errorf("error marshal json ", err)
+
+		return
+	}
+
+	session.send("", b)
+	session.sendSnapshots(snapshots)
+}
+
+// applySessionCommand applies subscribe or unsubscribe command to the session.
+func (h *UserWebSocketHandler) applySessionCommand(
+	session *wsSession,
+	action subscription.Action,
+	command stream.Command,
+) ([]stream.Snapshot, error) {
+	request := &subscription.Request{
+		Action:    action,
+		Symbols:   command.Symbols,
+		Channels:  command.Channels,
+		Delivery:  command.Delivery,
+		Watchlist: command.Watchlist,
+	}
+
+	if err := h.addWatchlistSymbols(session.userID, request); err != nil {
+		return nil, err
+	}
+
+	return h.applySessionAction(session, request)
+}
+
+func (h *UserWebSocketHandler) sendCommandError(session *wsSession, command stream.Command, err error) {
+	statusError := model.ErrUnhealthy
+	if modelError, ok := err.(model.Error); ok { //nolint:errorlint
+		statusError = modelError
+	}
+
+	b, err := json.Marshal(stream.ErrorMessage{
+		Type:    stream.ErrorMessageType,
+		ID:      command.ID,
+		Op:      command.Op,
+		Code:    statusError.Status(),
+		Message: statusError.Error(),
+	})
+	if err != nil {
+		logger.Errorf("error marshal json ", err)
+
+		return
+	}
+
+	session.send("", b)
+}
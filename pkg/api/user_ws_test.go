@@ -0,0 +1,255 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+
+	"bitmex-api/pkg/marketdatapb"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+)
+
+func TestUserWebSocketHandler_Commands(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	command := func(command stream.Command) map[string]interface{} {
+		t.Helper()
+
+		require.NoError(t, conn.WriteJSON(command))
+
+		var reply map[string]interface{}
+		readJSON(t, conn, &reply)
+
+		return reply
+	}
+
+	assert.Equal(t, map[string]interface{}{"type": "ack", "id": "1", "op": "ping"},
+		command(stream.Command{ID: "1", Op: stream.PingCommand}))
+
+	assert.Equal(t, map[string]interface{}{"type": "ack", "id": "2", "op": "subscribe"},
+		command(stream.Command{
+			ID:       "2",
+			Op:       stream.SubscribeCommand,
+			Symbols:  []string{"ETHUSD"},
+			Channels: []subscription.Channel{subscription.TradeChannel, subscription.QuoteChannel},
+		}))
+	require.True(t, p.fake.WaitForTopics(pipelineTimeout, "quote:ETHUSD"))
+
+	assert.Equal(t, map[string]interface{}{
+		"type":    "error",
+		"id":      "3",
+		"op":      "subscribe",
+		"code":    float64(http.StatusBadRequest),
+		"message": model.ErrAlreadySubscribed.Error(),
+	}, command(stream.Command{ID: "3", Op: stream.SubscribeCommand, Symbols: []string{"ETHUSD"}}))
+
+	list := command(stream.Command{ID: "4", Op: stream.ListCommand})
+	assert.Equal(t, "ack", list["type"])
+	assert.Equal(t, map[string]interface{}{
+		"trade":        true,
+		"tradeSymbols": []interface{}{"ETHUSD"},
+		"topics":       []interface{}{"quote:ETHUSD"},
+		"delivery":     map[string]interface{}{"mode": "realtime"},
+	}, list["data"].(map[string]interface{})["subscriptions"])
+	assert.False(t, p.user.Subscription, "commands must not change stored subscriptions")
+
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000}))
+
+	var record bitmex.TradeDataRecord
+	readJSON(t, conn, &record)
+	assert.Equal(t, "ETHUSD", record.Symbol)
+
+	assert.Equal(t, map[string]interface{}{"type": "ack", "id": "5", "op": "unsubscribe"},
+		command(stream.Command{ID: "5", Op: stream.UnsubscribeCommand}))
+	assert.Equal(t, subscription.Subscriptions{
+		TradeSymbols: []string{},
+		Topics:       []string{},
+		Delivery:     subscription.Delivery{Mode: subscription.RealtimeDelivery},
+	}, p.api.sessions.GetByUser(p.userID)[0].Subscriptions())
+
+	assert.Equal(t, "incorrect command", command(stream.Command{ID: "6", Op: "unknown"})["message"])
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+
+	var reply stream.ErrorMessage
+	readJSON(t, conn, &reply)
+	assert.Equal(t, stream.ErrorMessage{
+		Type:    stream.ErrorMessageType,
+		Code:    http.StatusBadRequest,
+		Message: model.ErrInvalidBody.Error(),
+	}, reply)
+}
+
+func TestUserWebSocketHandler_Snapshots(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000, Timestamp: timestamp},
+		bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000, Timestamp: timestamp},
+	))
+	require.Eventually(t, func() bool {
+		_, xbt := p.api.lastValues.Trade("XBTUSD")
+		_, eth := p.api.lastValues.Trade("ETHUSD")
+
+		return xbt && eth
+	}, pipelineTimeout, 5*time.Millisecond)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+pipelineToken)
+
+	url := "ws" + strings.TrimPrefix(p.server.URL, "http") + "/connect"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	defer conn.Close()
+
+	type snapshotMessage struct {
+		Type stream.MessageType `json:"type"`
+		Data stream.Snapshot    `json:"data"`
+	}
+
+	var snapshot snapshotMessage
+	readJSON(t, conn, &snapshot)
+	assert.Equal(t, stream.SnapshotMessageType, snapshot.Type)
+	assert.Equal(t, "XBTUSD", snapshot.Data.Symbol)
+	require.NotNil(t, snapshot.Data.Trade)
+	assert.InDelta(t, 42000, snapshot.Data.Trade.Price, 0)
+	assert.Nil(t, snapshot.Data.Quote)
+
+	require.NoError(t, conn.WriteJSON(stream.Command{
+		ID:       "1",
+		Op:       stream.SubscribeCommand,
+		Symbols:  []string{"ETHUSD"},
+		Channels: []subscription.Channel{subscription.TradeChannel},
+	}))
+
+	var ack stream.AckMessage
+	readJSON(t, conn, &ack)
+	assert.Equal(t, stream.AckMessageType, ack.Type)
+
+	readJSON(t, conn, &snapshot)
+	assert.Equal(t, stream.SnapshotMessageType, snapshot.Type)
+	assert.Equal(t, "ETHUSD", snapshot.Data.Symbol)
+	require.NotNil(t, snapshot.Data.Trade)
+	assert.InDelta(t, 2000, snapshot.Data.Trade.Price, 0)
+}
+
+// dialBinary connects with encoding subprotocol and pings the session with binary command.
+func (p *pipeline) dialBinary(t *testing.T, path, encoding string, ping []byte) (*websocket.Conn, *http.Response) {
+	t.Helper()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+pipelineToken)
+
+	dialer := websocket.Dialer{Subprotocols: []string{encoding}}
+
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(p.server.URL, "http")+path, header)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	t.Cleanup(func() { _ = conn.Close() })
+
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, ping))
+
+	return conn, resp
+}
+
+func readBinary(t *testing.T, conn *websocket.Conn) []byte {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(pipelineTimeout)))
+	messageType, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, websocket.BinaryMessage, messageType)
+
+	return message
+}
+
+func TestUserWebSocketHandler_Protobuf(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+
+	ping, err := proto.Marshal(&marketdatapb.Command{Id: "connect", Op: string(stream.PingCommand)})
+	require.NoError(t, err)
+
+	conn, resp := p.dialBinary(t, "/connect", "protobuf", ping)
+	assert.Equal(t, "protobuf", resp.Header.Get("Sec-WebSocket-Protocol"))
+
+	frame := &marketdatapb.Frame{}
+	for frame.GetAck().GetId() != "connect" {
+		require.NoError(t, proto.Unmarshal(readBinary(t, conn), frame))
+	}
+
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000.5, Size: 100}))
+
+	require.NoError(t, proto.Unmarshal(readBinary(t, conn), frame))
+	assert.Equal(t, "trade", frame.GetType())
+	assert.Equal(t, "XBTUSD", frame.GetTrade().GetSymbol())
+	assert.InDelta(t, 42000.5, frame.GetTrade().GetPrice(), 0)
+	assert.Equal(t, int64(100), frame.GetTrade().GetSize())
+
+	sessions := p.api.sessions.GetByUser(p.userID)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "protobuf", sessions[0].Info().Encoding)
+	assert.Equal(t, 2, sessions[0].Info().Version)
+}
+
+func TestUserWebSocketHandler_MessagePack(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD"}})
+	handle := &codec.MsgpackHandle{}
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	handle.RawToString = true
+
+	var ping []byte
+	require.NoError(t, codec.NewEncoderBytes(&ping, handle).Encode(map[string]string{"id": "connect", "op": "ping"}))
+
+	conn, _ := p.dialBinary(t, "/connect?version=2&encoding=msgpack", "msgpack", ping)
+
+	var frame map[string]interface{}
+	for frame["id"] != "connect" {
+		require.NoError(t, codec.NewDecoderBytes(readBinary(t, conn), handle).Decode(&frame))
+	}
+
+	require.NoError(t, p.fake.SendTrades(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000.5, Size: 100}))
+
+	frame = nil
+	require.NoError(t, codec.NewDecoderBytes(readBinary(t, conn), handle).Decode(&frame))
+	assert.Equal(t, "trade", frame["type"])
+
+	data, ok := frame["data"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "XBTUSD", data["symbol"])
+	assert.EqualValues(t, 100, data["size"])
+}
+
+func TestUserWebSocketHandler_IncorrectEncoding(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	resp, err := http.Get(p.server.URL + "/connect?encoding=xml")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUserWebSocketHandler_Unauthorized(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.dialToken(t, "invalid")
+
+	var statusError model.StatusError
+	readJSON(t, conn, &statusError)
+	assert.Equal(t, model.ErrUnauthorized, statusError)
+
+	err := readClose(t, conn)
+	assert.True(t, websocket.IsCloseError(err, stream.CloseUnauthorized), err)
+}
@@ -0,0 +1,546 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/watchlist"
+)
+
+type WatchlistHandler struct {
+	api *api
+}
+
+func NewWatchlistHandler(a *api) *WatchlistHandler {
+	return &WatchlistHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary create watchlist
+// @Description name is unique among watchlists of the user, repeated symbols are dropped,
+// @Description new watchlist is the last one, subscribe to its symbols with watchlist of
+// @Description PATCH /api/v1/bit-mex/subscription or of subscribe command of /connect
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param Watchlist  body watchlist.Request  true "Watchlist"
+// @Success 200 {object} model.Watchlist
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists [post]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) Create(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Watchlists.Create.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	newWatchlist := &model.Watchlist{UserID: userID}
+	if !h.bindWatchlist(c, newWatchlist) {
+		return
+	}
+
+	h.create(c, newWatchlist)
+}
+
+// List
+// @Summary get watchlists of the user in their order
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Watchlist
+// @Failure 401 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists [get]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) List(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Watchlists.List.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	watchlists, err := h.api.postgresStore.Watchlist.List(userID)
+	if err != nil {
+		logger.Errorf("Watchlists.List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, watchlists)
+}
+
+// Get
+// @Summary get watchlist
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Watchlist ID"
+// @Success 200 {object} model.Watchlist
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists/{id} [get]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) Get(c *gin.Context) {
+	userWatchlist, ok := h.getWatchlist(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, userWatchlist)
+}
+
+// Update
+// @Summary rename watchlist and replace its symbols
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param id         path  string             true  "Watchlist ID"
+// @Param Watchlist  body  watchlist.Request  true  "Watchlist"
+// @Success 200 {object} model.Watchlist
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists/{id} [put]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) Update(c *gin.Context) {
+	userWatchlist, ok := h.getWatchlist(c)
+	if !ok {
+		return
+	}
+
+	if !h.bindWatchlist(c, userWatchlist) {
+		return
+	}
+
+	watchlists, err := h.api.postgresStore.Watchlist.List(userWatchlist.UserID)
+	if err != nil {
+		logger.Errorf("Watchlists.Update.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if nameTaken(watchlists, userWatchlist) {
+		c.JSON(http.StatusBadRequest, model.ErrWatchlistExists)
+
+		return
+	}
+
+	h.update(c, userWatchlist)
+}
+
+// Delete
+// @Summary delete watchlist, subscriptions made with it are kept
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Watchlist ID"
+// @Success 200 {object} model.Watchlist
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists/{id} [delete]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) Delete(c *gin.Context) {
+	userWatchlist, ok := h.getWatchlist(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Watchlist.Delete(userWatchlist.UserID, userWatchlist.ID)
+	if err != nil {
+		logger.Errorf("Watchlists.Delete.Delete", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrWatchlistNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, userWatchlist)
+}
+
+// Reorder
+// @Summary reorder watchlists of the user
+// @Description ids must list every watchlist of the user once, in the new order
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param Order  body watchlist.OrderRequest  true "Order"
+// @Success 200 {array} model.Watchlist
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists/order [put]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) Reorder(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Watchlists.Reorder.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	var request watchlist.OrderRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		logger.Errorf("Watchlists.Reorder.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	watchlists, err := h.api.postgresStore.Watchlist.List(userID)
+	if err != nil {
+		logger.Errorf("Watchlists.Reorder.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !listsEvery(watchlists, request.IDs) {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectWatchlistOrder)
+
+		return
+	}
+
+	err = h.api.postgresStore.Watchlist.Reorder(userID, request.IDs, time.Now().UTC())
+	if err != nil {
+		logger.Errorf("Watchlists.Reorder.Reorder", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, model.ErrIncorrectWatchlistOrder)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	watchlists, err = h.api.postgresStore.Watchlist.List(userID)
+	if err != nil {
+		logger.Errorf("Watchlists.Reorder.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, watchlists)
+}
+
+// Share
+// @Summary share watchlist
+// @Description any user with shareToken of the watchlist can read it with GET /api/v1/watchlists/shared/{token}
+// @Description and copy it with POST /api/v1/watchlists/shared/{token}/copy, shared watchlist keeps its token
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Watchlist ID"
+// @Success 200 {object} model.Watchlist
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists/{id}/share [post]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) Share(c *gin.Context) {
+	userWatchlist, ok := h.getWatchlist(c)
+	if !ok {
+		return
+	}
+
+	if userWatchlist.ShareToken == nil {
+		token, err := model.NewWatchlistShareToken()
+		if err != nil {
+			logger.Errorf("Watchlists.Share.NewWatchlistShareToken", err)
+			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+			return
+		}
+
+		userWatchlist.ShareToken = &token
+	}
+
+	h.update(c, userWatchlist)
+}
+
+// Unshare
+// @Summary stop sharing watchlist, its token is revoked and copies are kept
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Watchlist ID"
+// @Success 200 {object} model.Watchlist
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists/{id}/share [delete]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) Unshare(c *gin.Context) {
+	userWatchlist, ok := h.getWatchlist(c)
+	if !ok {
+		return
+	}
+
+	userWatchlist.ShareToken = nil
+
+	h.update(c, userWatchlist)
+}
+
+// GetShared
+// @Summary get watchlist shared with the token
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param token  path  string  true  "Share token"
+// @Success 200 {object} model.Watchlist
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists/shared/{token} [get]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) GetShared(c *gin.Context) {
+	shared, ok := h.getShared(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, shared)
+}
+
+// CopyShared
+// @Summary copy watchlist shared with the token to watchlists of the user
+// @Description copy is not shared and does not follow changes of the shared watchlist
+// @Produce json
+// @Tags Watchlists
+// @Security ApiKeyAuth
+// @Param token  path   string  true   "Share token"
+// @Param name   query  string  false  "Name of the copy, name of the shared watchlist by default"
+// @Success 200 {object} model.Watchlist
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/watchlists/shared/{token}/copy [post]
+//
+//nolint:varnamelen
+func (h *WatchlistHandler) CopyShared(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Watchlists.CopyShared.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	shared, ok := h.getShared(c)
+	if !ok {
+		return
+	}
+
+	copied := &model.Watchlist{
+		UserID:  userID,
+		Name:    c.DefaultQuery("name", shared.Name),
+		Symbols: shared.Symbols,
+	}
+
+	if !copied.IsValid() {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectWatchlist)
+
+		return
+	}
+
+	h.create(c, copied)
+}
+
+// create stores new watchlist of the user after its other watchlists and writes it to the response.
+func (h *WatchlistHandler) create(c *gin.Context, newWatchlist *model.Watchlist) {
+	watchlists, err := h.api.postgresStore.Watchlist.List(newWatchlist.UserID)
+	if err != nil {
+		logger.Errorf("Watchlists.create.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if len(watchlists) >= model.MaxWatchlists {
+		c.JSON(http.StatusBadRequest, model.ErrTooManyWatchlists)
+
+		return
+	}
+
+	if nameTaken(watchlists, newWatchlist) {
+		c.JSON(http.StatusBadRequest, model.ErrWatchlistExists)
+
+		return
+	}
+
+	if len(watchlists) > 0 {
+		newWatchlist.Position = watchlists[len(watchlists)-1].Position + 1
+	}
+
+	newWatchlist.CreatedAt = time.Now().UTC()
+	newWatchlist.UpdatedAt = newWatchlist.CreatedAt
+
+	if err = h.api.postgresStore.Watchlist.Create(newWatchlist); err != nil {
+		logger.Errorf("Watchlists.create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, newWatchlist)
+}
+
+// update stores name, symbols and share token of the watchlist and writes it to the response.
+func (h *WatchlistHandler) update(c *gin.Context, userWatchlist *model.Watchlist) {
+	userWatchlist.UpdatedAt = time.Now().UTC()
+
+	err := h.api.postgresStore.Watchlist.Update(userWatchlist)
+	if err != nil {
+		logger.Errorf("Watchlists.update.Update", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrWatchlistNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, userWatchlist)
+}
+
+// getWatchlist loads watchlist of id path parameter owned by the user of the request,
+// error response is written on failure.
+func (h *WatchlistHandler) getWatchlist(c *gin.Context) (*model.Watchlist, bool) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Watchlists.getWatchlist.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, false
+	}
+
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("Watchlists.getWatchlist.FromString", err)
+		c.JSON(http.StatusNotFound, model.ErrWatchlistNotFound)
+
+		return nil, false
+	}
+
+	userWatchlist, err := h.api.postgresStore.Watchlist.Get(userID, id)
+	if err != nil {
+		logger.Errorf("Watchlists.getWatchlist.Get", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrWatchlistNotFound)
+
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	return userWatchlist, true
+}
+
+// getShared loads watchlist shared with token path parameter, error response is written on failure.
+func (h *WatchlistHandler) getShared(c *gin.Context) (*model.Watchlist, bool) {
+	shared, err := h.api.postgresStore.Watchlist.GetShared(c.Param("token"))
+	if err != nil {
+		logger.Errorf("Watchlists.getShared.GetShared", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrWatchlistNotFound)
+
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	return shared, true
+}
+
+// bindWatchlist applies watchlist.Request of the body to the watchlist, error response is written on failure.
+func (h *WatchlistHandler) bindWatchlist(c *gin.Context, userWatchlist *model.Watchlist) bool {
+	var request watchlist.Request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Errorf("Watchlists.bindWatchlist.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return false
+	}
+
+	for _, symbol := range request.Symbols {
+		if _, ok := h.api.symbolUser.Get(symbol); !ok {
+			c.JSON(http.StatusBadRequest, model.ErrIncorrectSymbol)
+
+			return false
+		}
+	}
+
+	request.Apply(userWatchlist)
+
+	if !userWatchlist.IsValid() {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectWatchlist)
+
+		return false
+	}
+
+	return true
+}
+
+// nameTaken reports whether another of the watchlists has the name of the watchlist.
+func nameTaken(watchlists []model.Watchlist, userWatchlist *model.Watchlist) bool {
+	for _, w := range watchlists {
+		if w.ID != userWatchlist.ID && w.Name == userWatchlist.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listsEvery reports whether ids list every one of the watchlists once.
+func listsEvery(watchlists []model.Watchlist, ids []uuid.UUID) bool {
+	if len(ids) != len(watchlists) {
+		return false
+	}
+
+	listed := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		listed[id] = true
+	}
+
+	for _, w := range watchlists {
+		if !listed[w.ID] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/candle"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+	"bitmex-api/pkg/model/ui/watchlist"
+)
+
+func TestWatchlistHandler_Create(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	existing := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "perps", Symbols: []string{"XBTUSD"},
+		Position: 3}
+
+	p.watchlistRepo.EXPECT().List(p.userID).Return([]model.Watchlist{existing}, nil).Times(2)
+	p.watchlistRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(newWatchlist *model.Watchlist) error {
+		newWatchlist.ID = uuid.NewV4()
+
+		return nil
+	})
+
+	var created model.Watchlist
+	status := p.do(t, http.MethodPost, "/api/v1/watchlists",
+		watchlist.Request{Name: " majors ", Symbols: []string{"XBTUSD", "ETHUSD", "XBTUSD"}}, &created)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "majors", created.Name)
+	assert.Equal(t, []string{"XBTUSD", "ETHUSD"}, []string(created.Symbols))
+	assert.Equal(t, 4, created.Position)
+	assert.Nil(t, created.ShareToken)
+
+	var statusError model.StatusError
+	status = p.do(t, http.MethodPost, "/api/v1/watchlists", watchlist.Request{Name: "perps"}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrWatchlistExists, statusError)
+
+	status = p.do(t, http.MethodPost, "/api/v1/watchlists",
+		watchlist.Request{Name: "unknown", Symbols: []string{"UNKNOWN"}}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrIncorrectSymbol, statusError)
+
+	status = p.do(t, http.MethodPost, "/api/v1/watchlists", watchlist.Request{Name: " "}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrIncorrectWatchlist, statusError)
+
+	p.watchlistRepo.EXPECT().Get(p.userID, existing.ID).DoAndReturn(func(uuid.UUID, uuid.UUID) (*model.Watchlist, error) {
+		stored := existing
+
+		return &stored, nil
+	})
+	p.watchlistRepo.EXPECT().List(p.userID).Return([]model.Watchlist{existing, created}, nil)
+
+	// rename to the name of another watchlist
+	status = p.do(t, http.MethodPut, "/api/v1/watchlists/"+existing.ID.String(),
+		watchlist.Request{Name: "majors"}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrWatchlistExists, statusError)
+
+	p.watchlistRepo.EXPECT().Get(p.userID, gomock.Any()).Return(nil, model.ErrRecordNotFound)
+
+	status = p.do(t, http.MethodGet, "/api/v1/watchlists/"+uuid.NewV4().String(), nil, &statusError)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, model.ErrWatchlistNotFound, statusError)
+}
+
+func TestWatchlistHandler_Reorder(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	perps := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "perps"}
+	quarterlies := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "quarterlies", Position: 1}
+
+	p.watchlistRepo.EXPECT().List(p.userID).Return([]model.Watchlist{perps, quarterlies}, nil).Times(3)
+
+	var statusError model.StatusError
+	for _, ids := range [][]uuid.UUID{
+		{quarterlies.ID},
+		{quarterlies.ID, quarterlies.ID},
+		{quarterlies.ID, uuid.NewV4()},
+	} {
+		status := p.do(t, http.MethodPut, "/api/v1/watchlists/order", watchlist.OrderRequest{IDs: ids}, &statusError)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, model.ErrIncorrectWatchlistOrder, statusError)
+	}
+
+	reordered := []model.Watchlist{quarterlies, perps}
+	reordered[0].Position, reordered[1].Position = 0, 1
+
+	gomock.InOrder(
+		p.watchlistRepo.EXPECT().List(p.userID).Return([]model.Watchlist{perps, quarterlies}, nil),
+		p.watchlistRepo.EXPECT().Reorder(p.userID, []uuid.UUID{quarterlies.ID, perps.ID}, gomock.Any()).Return(nil),
+		p.watchlistRepo.EXPECT().List(p.userID).Return(reordered, nil),
+	)
+
+	var watchlists []model.Watchlist
+	status := p.do(t, http.MethodPut, "/api/v1/watchlists/order",
+		watchlist.OrderRequest{IDs: []uuid.UUID{quarterlies.ID, perps.ID}}, &watchlists)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, watchlists, 2)
+	assert.Equal(t, quarterlies.ID, watchlists[0].ID)
+}
+
+func TestWatchlistHandler_Share(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	stored := model.Watchlist{ID: uuid.NewV4(), UserID: uuid.NewV4(), Name: "perps", Symbols: []string{"XBTUSD"}}
+
+	p.watchlistRepo.EXPECT().Get(p.userID, stored.ID).DoAndReturn(func(uuid.UUID, uuid.UUID) (*model.Watchlist, error) {
+		owned := stored
+		owned.UserID = p.userID
+
+		return &owned, nil
+	})
+	p.watchlistRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+	var shared model.Watchlist
+	status := p.do(t, http.MethodPost, "/api/v1/watchlists/"+stored.ID.String()+"/share", nil, &shared)
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, shared.ShareToken)
+	assert.Len(t, *shared.ShareToken, 32)
+
+	stored.ShareToken = shared.ShareToken
+	p.watchlistRepo.EXPECT().GetShared(*shared.ShareToken).Return(&stored, nil).Times(2)
+	p.watchlistRepo.EXPECT().List(p.userID).Return([]model.Watchlist{}, nil)
+	p.watchlistRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(copied *model.Watchlist) error {
+		assert.Equal(t, p.userID, copied.UserID)
+		assert.Nil(t, copied.ShareToken)
+		copied.ID = uuid.NewV4()
+
+		return nil
+	})
+
+	var copied model.Watchlist
+	status = p.do(t, http.MethodPost, "/api/v1/watchlists/shared/"+*shared.ShareToken+"/copy?name=mine", nil, &copied)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "mine", copied.Name)
+	assert.Equal(t, []string{"XBTUSD"}, []string(copied.Symbols))
+
+	var viewed model.Watchlist
+	status = p.do(t, http.MethodGet, "/api/v1/watchlists/shared/"+*shared.ShareToken, nil, &viewed)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, stored.ID, viewed.ID)
+
+	p.watchlistRepo.EXPECT().GetShared("revoked").Return(nil, model.ErrRecordNotFound)
+
+	var statusError model.StatusError
+	status = p.do(t, http.MethodGet, "/api/v1/watchlists/shared/revoked", nil, &statusError)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, model.ErrWatchlistNotFound, statusError)
+}
+
+func TestWatchlistHandler_Subscribe(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	majors := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "majors", Symbols: []string{"XBTUSD", "ETHUSD"}}
+	empty := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "empty"}
+
+	p.watchlistRepo.EXPECT().Get(p.userID, majors.ID).Return(&majors, nil).Times(2)
+	p.watchlistRepo.EXPECT().Get(p.userID, empty.ID).Return(&empty, nil)
+	p.watchlistRepo.EXPECT().Get(p.userID, gomock.Any()).Return(nil, model.ErrRecordNotFound)
+
+	// stored subscriptions
+	p.userRepo.EXPECT().UpdateSubscriptions(p.user).Return(nil)
+	p.subscribe(t, subscription.Request{
+		Action:    subscription.Subscribe,
+		Channels:  []subscription.Channel{subscription.QuoteChannel},
+		Watchlist: &majors.ID,
+	})
+	assert.Equal(t, []string{"quote:XBTUSD", "quote:ETHUSD"}, []string(p.user.SubscriptionTopics))
+
+	var ack stream.AckMessage
+	require.NoError(t, conn.WriteJSON(stream.Command{ID: "1", Op: stream.UnsubscribeCommand,
+		Channels: []subscription.Channel{subscription.QuoteChannel}, Watchlist: &majors.ID}))
+	readFrame(t, conn, stream.AckMessageType, &ack)
+	assert.Equal(t, "1", ack.ID)
+	assert.Empty(t, p.api.sessions.GetByUser(p.userID)[0].Subscriptions().Topics)
+
+	var statusError model.StatusError
+	status := p.do(t, http.MethodPatch, "/api/v1/bit-mex/subscription",
+		subscription.Request{Action: subscription.Subscribe, Watchlist: &empty.ID}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrEmptyWatchlist, statusError)
+
+	var reply stream.ErrorMessage
+	unknown := uuid.NewV4()
+	require.NoError(t, conn.WriteJSON(stream.Command{ID: "2", Op: stream.SubscribeCommand, Watchlist: &unknown}))
+	readFrame(t, conn, stream.ErrorMessageType, &reply)
+	assert.Equal(t, http.StatusNotFound, reply.Code)
+	assert.Equal(t, model.ErrWatchlistNotFound.Error(), reply.Message)
+}
+
+func TestWatchlistHandler_SubscribeOverlapping(t *testing.T) {
+	p := initPipeline(t, nil)
+	conn := p.connect(t)
+
+	bitcoin := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "bitcoin", Symbols: []string{"XBTUSD"}}
+	majors := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "majors", Symbols: []string{"XBTUSD", "ETHUSD"}}
+
+	p.watchlistRepo.EXPECT().Get(p.userID, bitcoin.ID).Return(&bitcoin, nil).AnyTimes()
+	p.watchlistRepo.EXPECT().Get(p.userID, majors.ID).Return(&majors, nil).AnyTimes()
+	p.userRepo.EXPECT().UpdateSubscriptions(p.user).Return(nil).Times(3)
+
+	channels := []subscription.Channel{subscription.TradeChannel, subscription.QuoteChannel}
+	p.subscribe(t, subscription.Request{Action: subscription.Subscribe, Channels: channels, Watchlist: &bitcoin.ID})
+
+	// symbols subscribed by the first watchlist are skipped
+	p.subscribe(t, subscription.Request{Action: subscription.Subscribe, Channels: channels, Watchlist: &majors.ID})
+	assert.Equal(t, []string{"XBTUSD", "ETHUSD"}, []string(p.user.SubscriptionSymbols))
+	assert.Equal(t, []string{"quote:XBTUSD", "quote:ETHUSD"}, []string(p.user.SubscriptionTopics))
+
+	// watchlist with nothing new fails
+	var statusError model.StatusError
+	status := p.do(t, http.MethodPatch, "/api/v1/bit-mex/subscription",
+		subscription.Request{Action: subscription.Subscribe, Channels: channels, Watchlist: &bitcoin.ID}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrAlreadySubscribed, statusError)
+
+	// explicit symbol overlapping the watchlist is taken once
+	p.subscribe(t, subscription.Request{Action: subscription.Subscribe, Symbols: []string{"XBTUSD"},
+		Channels: []subscription.Channel{subscription.OrderBookChannel}, Watchlist: &majors.ID})
+	assert.Equal(t, []string{"quote:XBTUSD", "quote:ETHUSD", "orderBook:XBTUSD", "orderBook:ETHUSD"},
+		[]string(p.user.SubscriptionTopics))
+
+	// session commands merge watchlists the same way
+	var reply stream.ErrorMessage
+	require.NoError(t, conn.WriteJSON(stream.Command{ID: "1", Op: stream.SubscribeCommand,
+		Channels: []subscription.Channel{subscription.TradeChannel}, Watchlist: &majors.ID}))
+	readFrame(t, conn, stream.ErrorMessageType, &reply)
+	assert.Equal(t, "1", reply.ID)
+	assert.Equal(t, model.ErrAlreadySubscribed.Error(), reply.Message)
+
+	var ack stream.AckMessage
+	require.NoError(t, conn.WriteJSON(stream.Command{ID: "2", Op: stream.SubscribeCommand, Symbols: []string{"ETHUSD"},
+		Channels: []subscription.Channel{subscription.CandleChannel(candle.Minute1)}, Watchlist: &bitcoin.ID}))
+	readFrame(t, conn, stream.AckMessageType, &ack)
+	assert.Equal(t, "2", ack.ID)
+}
+
+func TestWatchlistHandler_UnsubscribeOverlapping(t *testing.T) {
+	p := initPipeline(t, &model.User{Subscription: true, SubscriptionSymbols: []string{"XBTUSD", "ETHUSD"},
+		SubscriptionTopics: []string{"quote:XBTUSD", "quote:ETHUSD", "orderBook:ETHUSD"}})
+	p.connect(t)
+
+	bitcoin := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "bitcoin", Symbols: []string{"XBTUSD"}}
+	majors := model.Watchlist{ID: uuid.NewV4(), UserID: p.userID, Name: "majors", Symbols: []string{"XBTUSD", "ETHUSD"}}
+
+	p.watchlistRepo.EXPECT().Get(p.userID, bitcoin.ID).Return(&bitcoin, nil).AnyTimes()
+	p.watchlistRepo.EXPECT().Get(p.userID, majors.ID).Return(&majors, nil).AnyTimes()
+	p.userRepo.EXPECT().UpdateSubscriptions(p.user).Return(nil).Times(3)
+
+	// only symbols of the watchlist are unsubscribed
+	channels := []subscription.Channel{subscription.TradeChannel, subscription.QuoteChannel}
+	p.subscribe(t, subscription.Request{Action: subscription.Unsubscribe, Channels: channels, Watchlist: &bitcoin.ID})
+	assert.True(t, p.user.Subscription)
+	assert.Equal(t, []string{"ETHUSD"}, []string(p.user.SubscriptionSymbols))
+	assert.Equal(t, []string{"quote:ETHUSD", "orderBook:ETHUSD"}, []string(p.user.SubscriptionTopics))
+
+	// symbols unsubscribed by the first watchlist are skipped
+	p.subscribe(t, subscription.Request{Action: subscription.Unsubscribe,
+		Channels: []subscription.Channel{subscription.QuoteChannel}, Watchlist: &majors.ID})
+	assert.Equal(t, []string{"orderBook:ETHUSD"}, []string(p.user.SubscriptionTopics))
+
+	// watchlist with nothing left to unsubscribe fails
+	var statusError model.StatusError
+	status := p.do(t, http.MethodPatch, "/api/v1/bit-mex/subscription",
+		subscription.Request{Action: subscription.Unsubscribe, Channels: channels, Watchlist: &bitcoin.ID}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrAlreadyUnsubscribed, statusError)
+
+	// without channels every subscription of the watchlist symbols is removed
+	p.subscribe(t, subscription.Request{Action: subscription.Unsubscribe, Watchlist: &majors.ID})
+	assert.False(t, p.user.Subscription)
+	assert.Empty(t, p.user.SubscriptionSymbols)
+	assert.Empty(t, p.user.SubscriptionTopics)
+}
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/webhook"
+)
+
+const (
+	defaultWebhookDeliveriesLimit = 100
+	maxWebhookDeliveriesLimit     = 1000
+)
+
+type WebhookHandler struct {
+	api *api
+}
+
+func NewWebhookHandler(a *api) *WebhookHandler {
+	return &WebhookHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary create webhook
+// @Description events are POST to url as {"id": "...", "event": "alert", "timestamp": "...", "data": {...}},
+// @Description alert event sends fired price alerts, trade event sends trades of symbols,
+// @Description at most one trade of a symbol every tradeIntervalMs (1000 to 3600000), the latest one is sent,
+// @Description request is signed in X-Webhook-Signature as sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+// @Description with timestamp of X-Webhook-Timestamp, secret is returned only in this response,
+// @Description failed delivery is retried with exponential backoff and kept in dead letters once out of attempts
+// @Produce json
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Param Webhook  body webhook.Request  true "Webhook"
+// @Success 200 {object} model.Webhook
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/webhooks [post]
+//
+//nolint:varnamelen
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Webhooks.Create.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	newWebhook := &model.Webhook{UserID: userID, CreatedAt: time.Now().UTC()}
+	if !h.bindWebhook(c, newWebhook) {
+		return
+	}
+
+	newWebhook.Secret, err = model.NewWebhookSecret()
+	if err != nil {
+		logger.Errorf("Webhooks.Create.NewWebhookSecret", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if err = h.api.postgresStore.Webhook.Create(newWebhook); err != nil {
+		logger.Errorf("Webhooks.Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.webhooks.Put(*newWebhook)
+
+	c.JSON(http.StatusOK, newWebhook)
+}
+
+// List
+// @Summary get webhooks of the user
+// @Produce json
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Webhook
+// @Failure 401 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/webhooks [get]
+//
+//nolint:varnamelen
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Webhooks.List.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	webhooks, err := h.api.postgresStore.Webhook.List(userID)
+	if err != nil {
+		logger.Errorf("Webhooks.List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// Get
+// @Summary get webhook
+// @Produce json
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Webhook ID"
+// @Success 200 {object} model.Webhook
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/webhooks/{id} [get]
+//
+//nolint:varnamelen
+func (h *WebhookHandler) Get(c *gin.Context) {
+	userWebhook, ok := h.getWebhook(c)
+	if !ok {
+		return
+	}
+
+	userWebhook.Secret = ""
+
+	c.JSON(http.StatusOK, userWebhook)
+}
+
+// Update
+// @Summary replace url and events of webhook, secret is kept
+// @Description active defaults to true
+// @Produce json
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Param id       path  string           true  "Webhook ID"
+// @Param Webhook  body  webhook.Request  true  "Webhook"
+// @Success 200 {object} model.Webhook
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/webhooks/{id} [put]
+//
+//nolint:varnamelen
+func (h *WebhookHandler) Update(c *gin.Context) {
+	userWebhook, ok := h.getWebhook(c)
+	if !ok {
+		return
+	}
+
+	if !h.bindWebhook(c, userWebhook) {
+		return
+	}
+
+	err := h.api.postgresStore.Webhook.Update(userWebhook)
+	if err != nil {
+		logger.Errorf("Webhooks.Update.Update", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrWebhookNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.webhooks.Put(*userWebhook)
+
+	userWebhook.Secret = ""
+
+	c.JSON(http.StatusOK, userWebhook)
+}
+
+// Delete
+// @Summary delete webhook with its delivery logs
+// @Produce json
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Param id  path  string  true  "Webhook ID"
+// @Success 200 {object} model.Webhook
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/webhooks/{id} [delete]
+//
+//nolint:varnamelen
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	userWebhook, ok := h.getWebhook(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Webhook.Delete(userWebhook.UserID, userWebhook.ID)
+	if err != nil {
+		logger.Errorf("Webhooks.Delete.Delete", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrWebhookNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.webhooks.Remove(userWebhook.ID)
+
+	userWebhook.Secret = ""
+
+	c.JSON(http.StatusOK, userWebhook)
+}
+
+// Deliveries
+// @Summary get delivery log of webhook, the newest first
+// @Description status is pending, retrying, delivered or failed,
+// @Description lastStatusCode and lastError are the result of the latest attempt
+// @Produce json
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Param id     path   string  true  "Webhook ID"
+// @Param limit  query  int     false "Number of deliveries up to 1000, 100 by default"
+// @Success 200 {array} model.WebhookDelivery
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 404 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+//
+//nolint:varnamelen
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	userWebhook, ok := h.getWebhook(c)
+	if !ok {
+		return
+	}
+
+	limit, ok := h.getLimit(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.api.postgresStore.Webhook.ListDeliveries(userWebhook.UserID, userWebhook.ID, limit)
+	if err != nil {
+		logger.Errorf("Webhooks.Deliveries.ListDeliveries", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// DeadLetters
+// @Summary get deliveries of all webhooks of the user which are out of attempts, the newest first
+// @Produce json
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Param limit  query int  false "Number of deliveries up to 1000, 100 by default"
+// @Success 200 {array} model.WebhookDelivery
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/webhooks/dead-letters [get]
+//
+//nolint:varnamelen
+func (h *WebhookHandler) DeadLetters(c *gin.Context) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Webhooks.DeadLetters.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	limit, ok := h.getLimit(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.api.postgresStore.Webhook.ListDeadLetters(userID, limit)
+	if err != nil {
+		logger.Errorf("Webhooks.DeadLetters.ListDeadLetters", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// getWebhook loads webhook of id path parameter owned by the user of the request,
+// error response is written on failure.
+func (h *WebhookHandler) getWebhook(c *gin.Context) (*model.Webhook, bool) {
+	userID, err := h.api.getUserIDFromHeader(c)
+	if err != nil {
+		logger.Errorf("Webhooks.getWebhook.getUserIDFromHeader", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, false
+	}
+
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("Webhooks.getWebhook.FromString", err)
+		c.JSON(http.StatusNotFound, model.ErrWebhookNotFound)
+
+		return nil, false
+	}
+
+	userWebhook, err := h.api.postgresStore.Webhook.Get(userID, id)
+	if err != nil {
+		logger.Errorf("Webhooks.getWebhook.Get", err)
+
+		if errors.Is(err, model.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrWebhookNotFound)
+
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	return userWebhook, true
+}
+
+// bindWebhook applies webhook.Request of the body to the webhook, error response is written on failure.
+func (h *WebhookHandler) bindWebhook(c *gin.Context, userWebhook *model.Webhook) bool {
+	var request webhook.Request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Errorf("Webhooks.bindWebhook.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return false
+	}
+
+	for _, symbol := range request.Symbols {
+		if _, ok := h.api.symbolUser.Get(symbol); !ok {
+			c.JSON(http.StatusBadRequest, model.ErrIncorrectSymbol)
+
+			return false
+		}
+	}
+
+	request.Apply(userWebhook)
+
+	if !userWebhook.IsValid(h.api.config.Webhooks.AllowHTTP) {
+		c.JSON(http.StatusBadRequest, model.ErrIncorrectWebhook)
+
+		return false
+	}
+
+	return true
+}
+
+// getLimit parses limit query parameter, error response is written on failure.
+func (h *WebhookHandler) getLimit(c *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultWebhookDeliveriesLimit)))
+	if err != nil || limit <= 0 || limit > maxWebhookDeliveriesLimit {
+		logger.Errorf("Webhooks.getLimit.Atoi", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return 0, false
+	}
+
+	return limit, true
+}
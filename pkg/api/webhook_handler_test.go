@@ -0,0 +1,180 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/alert"
+	"bitmex-api/pkg/model/ui/webhook"
+	webhookdelivery "bitmex-api/pkg/webhook"
+)
+
+func TestWebhookHandler_Alert(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		received <- r
+		bodies <- body
+	}))
+	t.Cleanup(receiver.Close)
+
+	p.webhookRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(newWebhook *model.Webhook) error {
+		newWebhook.ID = uuid.NewV4()
+
+		return nil
+	})
+
+	var created model.Webhook
+	status := p.do(t, http.MethodPost, "/api/v1/webhooks", webhook.Request{
+		URL:    receiver.URL,
+		Events: []model.WebhookEvent{model.WebhookAlertEvent},
+	}, &created)
+	require.Equal(t, http.StatusOK, status)
+	assert.Len(t, created.Secret, 64)
+	assert.True(t, created.Active)
+
+	p.alertRepo.EXPECT().Create(gomock.Any()).Return(nil)
+	p.alertRepo.EXPECT().Trigger(gomock.Any(), true).Return(nil)
+
+	status = p.do(t, http.MethodPost, "/api/v1/alerts", alert.Request{
+		Symbol:    "XBTUSD",
+		Condition: model.CrossesAbove,
+		Price:     70000,
+	}, &model.Alert{})
+	require.Equal(t, http.StatusOK, status)
+
+	delivered := make(chan model.WebhookDelivery, 1)
+	p.webhookRepo.EXPECT().CreateDelivery(gomock.Any()).Return(nil)
+	p.webhookRepo.EXPECT().UpdateDelivery(gomock.Any()).DoAndReturn(func(delivery *model.WebhookDelivery) error {
+		delivered <- *delivery
+
+		return nil
+	})
+
+	require.NoError(t, p.fake.SendTrades(
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 69000},
+		bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 70500},
+	))
+
+	select {
+	case delivery := <-delivered:
+		assert.Equal(t, model.WebhookDeliveryDelivered, delivery.Status)
+		assert.Equal(t, created.ID, delivery.WebhookID)
+	case <-time.After(pipelineTimeout):
+		t.Fatal("alert was not delivered to webhook")
+	}
+
+	r, body := <-received, <-bodies
+	signature := webhookdelivery.Sign(created.Secret, r.Header.Get(webhookdelivery.TimestampHeader), body)
+	assert.Equal(t, webhookdelivery.SignaturePrefix+signature, r.Header.Get(webhookdelivery.SignatureHeader))
+	assert.Equal(t, string(model.WebhookAlertEvent), r.Header.Get(webhookdelivery.EventHeader))
+}
+
+func TestWebhookHandler_Validate(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	var statusError model.StatusError
+	status := p.do(t, http.MethodPost, "/api/v1/webhooks", webhook.Request{
+		URL:    "ftp://example.com",
+		Events: []model.WebhookEvent{model.WebhookAlertEvent},
+	}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrIncorrectWebhook, statusError)
+
+	status = p.do(t, http.MethodPost, "/api/v1/webhooks", webhook.Request{
+		URL:             "https://example.com",
+		Events:          []model.WebhookEvent{model.WebhookTradeEvent},
+		Symbols:         []string{"XBTUSD"},
+		TradeIntervalMs: 10,
+	}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrIncorrectWebhook, statusError)
+
+	status = p.do(t, http.MethodPost, "/api/v1/webhooks", webhook.Request{
+		URL:             "https://example.com",
+		Events:          []model.WebhookEvent{model.WebhookTradeEvent},
+		Symbols:         []string{"UNKNOWN"},
+		TradeIntervalMs: 1000,
+	}, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, model.ErrIncorrectSymbol, statusError)
+
+	status = p.do(t, http.MethodGet, "/api/v1/webhooks/dead-letters?limit=1001", nil, &statusError)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status = p.do(t, http.MethodGet, "/api/v1/webhooks/unknown", nil, &statusError)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, model.ErrWebhookNotFound, statusError)
+}
+
+func TestWebhookHandler_UpdateDeliveries(t *testing.T) {
+	p := initPipeline(t, nil)
+
+	stored := model.Webhook{
+		ID:     uuid.NewV4(),
+		UserID: p.userID,
+		URL:    "https://example.com/hook",
+		Secret: "secret",
+		Events: pq.StringArray{string(model.WebhookAlertEvent)},
+		Active: true,
+	}
+	p.webhookRepo.EXPECT().Get(p.userID, stored.ID).DoAndReturn(func(uuid.UUID, uuid.UUID) (*model.Webhook, error) {
+		userWebhook := stored
+
+		return &userWebhook, nil
+	}).Times(3)
+	p.webhookRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+	var updated model.Webhook
+	status := p.do(t, http.MethodPut, "/api/v1/webhooks/"+stored.ID.String(), webhook.Request{
+		URL:             "https://example.com/trades",
+		Events:          []model.WebhookEvent{model.WebhookTradeEvent},
+		Symbols:         []string{"XBTUSD"},
+		TradeIntervalMs: 1000,
+	}, &updated)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "https://example.com/trades", updated.URL)
+	assert.Empty(t, updated.Secret)
+
+	p.webhookRepo.EXPECT().ListDeliveries(p.userID, stored.ID, 10).
+		Return([]model.WebhookDelivery{{ID: uuid.NewV4(), WebhookID: stored.ID}}, nil)
+
+	var deliveries []model.WebhookDelivery
+	status = p.do(t, http.MethodGet, "/api/v1/webhooks/"+stored.ID.String()+"/deliveries?limit=10", nil, &deliveries)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, stored.ID, deliveries[0].WebhookID)
+
+	p.webhookRepo.EXPECT().ListDeadLetters(p.userID, 100).
+		Return([]model.WebhookDelivery{{ID: uuid.NewV4(), Status: model.WebhookDeliveryFailed}}, nil)
+
+	var deadLetters []model.WebhookDelivery
+	status = p.do(t, http.MethodGet, "/api/v1/webhooks/dead-letters", nil, &deadLetters)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, model.WebhookDeliveryFailed, deadLetters[0].Status)
+
+	p.webhookRepo.EXPECT().Delete(p.userID, stored.ID).Return(nil)
+
+	var deleted model.Webhook
+	status = p.do(t, http.MethodDelete, "/api/v1/webhooks/"+stored.ID.String(), nil, &deleted)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, stored.ID, deleted.ID)
+	assert.Empty(t, deleted.Secret)
+}
@@ -0,0 +1,398 @@
+package bitmexclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model/bitmex"
+)
+
+// State is a connection state reported by Client.
+// StateFailed means ReconnectMaxAttempts dials in a row have failed, the client keeps
+// dialing at max backoff and goes back to StateConnected once BitMEX is reachable again.
+type State string
+
+const (
+	StateConnecting   State = "connecting"
+	StateConnected    State = "connected"
+	StateReconnecting State = "reconnecting"
+	StateStale        State = "stale"
+	StateFailed       State = "failed"
+	StateClosed       State = "closed"
+)
+
+const (
+	messagesBufferSize = 1024
+	statesBufferSize   = 16
+	closeTimeout       = time.Second
+)
+
+// Client is a supervised connection to the BitMEX realtime API.
+// It reconnects with exponential backoff and replays subscriptions after every reconnect,
+// a connection that stays silent longer than StaleTimeout is declared stale and dropped.
+type Client struct {
+	config *config.BitMexConfig
+	dialer *websocket.Dialer
+
+	conn   *websocket.Conn
+	connMu sync.Mutex
+
+	topics topics
+
+	state   State
+	stateMu sync.RWMutex
+
+	messages chan []byte
+	states   chan State
+}
+
+type topics struct {
+	topics []string
+
+	mu sync.RWMutex
+}
+
+func New(config *config.BitMexConfig) *Client {
+	return &Client{
+		config: config,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: config.DialTimeout.Duration,
+		},
+		state:    StateConnecting,
+		messages: make(chan []byte, messagesBufferSize),
+		states:   make(chan State, statesBufferSize),
+	}
+}
+
+// Messages returns upstream frames. The channel is closed when Run returns.
+func (c *Client) Messages() <-chan []byte {
+	return c.messages
+}
+
+// States returns connection state transitions.
+func (c *Client) States() <-chan State {
+	return c.states
+}
+
+func (c *Client) State() State {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+
+	return c.state
+}
+
+// Subscribe remembers topics for replay and sends them upstream when connected.
+func (c *Client) Subscribe(topics ...string) error {
+	added := c.topics.Add(topics...)
+	if len(added) == 0 {
+		return nil
+	}
+
+	return c.send(bitmex.Subscribe, added)
+}
+
+// Unsubscribe forgets topics and sends unsubscribe upstream when connected.
+func (c *Client) Unsubscribe(topics ...string) error {
+	removed := c.topics.Remove(topics...)
+	if len(removed) == 0 {
+		return nil
+	}
+
+	return c.send(bitmex.Unsubscribe, removed)
+}
+
+// Resubscribe asks BitMEX to send topics from scratch, e.g. to get new partial of out of sync table.
+func (c *Client) Resubscribe(topics ...string) error {
+	if err := c.send(bitmex.Unsubscribe, topics); err != nil {
+		return err
+	}
+
+	c.topics.Add(topics...)
+
+	return c.send(bitmex.Subscribe, topics)
+}
+
+// Run dials BitMEX and keeps the connection alive until ctx is done,
+// it never gives up on reconnecting, even after reporting StateFailed.
+func (c *Client) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(c.messages)
+
+	attempt := 0
+
+	for {
+		conn, resp, err := c.dialer.DialContext(ctx, c.config.RealtimeURL(), nil)
+		if resp != nil && resp.Body != nil {
+			if err := resp.Body.Close(); err != nil {
+				logger.Errorf("error close response body", err)
+			}
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				c.setState(StateClosed)
+
+				return
+			}
+
+			attempt++
+			logger.Errorf("Dial error", err)
+
+			if c.config.ReconnectMaxAttempts > 0 && attempt >= c.config.ReconnectMaxAttempts {
+				c.setState(StateFailed)
+			} else {
+				c.setState(StateReconnecting)
+			}
+
+			if !sleep(ctx, c.backoff(attempt)) {
+				c.setState(StateClosed)
+
+				return
+			}
+
+			continue
+		}
+
+		attempt = 0
+		c.serve(ctx, conn)
+
+		if ctx.Err() != nil {
+			c.setState(StateClosed)
+			logger.Infof("bitMex client done")
+
+			return
+		}
+
+		c.setState(StateReconnecting)
+	}
+}
+
+func (c *Client) serve(ctx context.Context, conn *websocket.Conn) {
+	c.connMu.Lock()
+	c.conn = conn
+	c.connMu.Unlock()
+
+	c.setState(StateConnected)
+
+	heartbeat := newHeartbeat(c.config.PingInterval.Duration, c.config.StaleTimeout.Duration)
+	heartbeat.touch(conn)
+
+	if err := c.send(bitmex.Subscribe, c.topics.GetAll()); err != nil {
+		logger.Errorf("Resubscribe error", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.shutdown()
+		case <-done:
+		}
+	}()
+
+	go heartbeat.run(ctx, done, c.ping)
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			switch {
+			case ctx.Err() != nil:
+			case isTimeout(err):
+				logger.Errorf("Stale feed", heartbeat.idle())
+				c.setState(StateStale)
+			default:
+				logger.Errorf("Read error", err)
+			}
+
+			break
+		}
+
+		heartbeat.touch(conn)
+
+		if isPong(message) {
+			continue
+		}
+
+		select {
+		case c.messages <- message:
+		case <-ctx.Done():
+		}
+	}
+
+	c.connMu.Lock()
+	c.conn = nil
+	c.connMu.Unlock()
+
+	if err := conn.Close(); err != nil {
+		logger.Debugf("close connection: %v", err)
+	}
+}
+
+func (c *Client) shutdown() {
+	if err := c.send(bitmex.Unsubscribe, c.topics.GetAll()); err != nil {
+		logger.Errorf("Unsubscribe error", err)
+	}
+
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.conn == nil {
+		return
+	}
+
+	err := c.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(closeTimeout))
+	if err != nil {
+		logger.Errorf("Close error", err)
+	}
+
+	if err = c.conn.Close(); err != nil {
+		logger.Errorf("error close connection", err)
+	}
+}
+
+// send writes operation in batches, it does nothing while disconnected as topics are replayed on connect.
+func (c *Client) send(op bitmex.OperationName, topics []string) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	batchSize := c.config.SubscribeBatchSize
+
+	for start := 0; start < len(topics); start += batchSize {
+		end := min(start+batchSize, len(topics))
+
+		message, err := json.Marshal(bitmex.Operation{Op: op, Args: topics[start:end]})
+		if err != nil {
+			return err
+		}
+
+		if err = c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) ping() error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	return c.conn.WriteMessage(websocket.TextMessage, []byte(pingMessage))
+}
+
+func (c *Client) setState(state State) {
+	c.stateMu.Lock()
+	changed := c.state != state
+	c.state = state
+	c.stateMu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	logger.Infof("bitMex connection state: %s", state)
+
+	select {
+	case c.states <- state:
+	default:
+		logger.Errorf("State channel is full", state)
+	}
+}
+
+// backoff returns exponential delay with jitter for given attempt.
+//
+//nolint:gosec
+func (c *Client) backoff(attempt int) time.Duration {
+	minBackoff := c.config.ReconnectMinBackoff.Duration
+	maxBackoff := c.config.ReconnectMaxBackoff.Duration
+
+	delay := maxBackoff
+	if shift := attempt - 1; shift < 32 {
+		if d := minBackoff << shift; d > 0 && d < maxBackoff {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (m *topics) GetAll() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]string(nil), m.topics...)
+}
+
+func (m *topics) Add(topics ...string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	added := make([]string, 0, len(topics))
+
+	for _, topic := range topics {
+		if !slices.Contains(m.topics, topic) && !slices.Contains(added, topic) {
+			added = append(added, topic)
+		}
+	}
+
+	m.topics = append(m.topics, added...)
+
+	return added
+}
+
+func (m *topics) Remove(topics ...string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := make([]string, 0, len(topics))
+	kept := m.topics[:0]
+
+	for _, topic := range m.topics {
+		if slices.Contains(topics, topic) {
+			removed = append(removed, topic)
+
+			continue
+		}
+
+		kept = append(kept, topic)
+	}
+
+	m.topics = kept
+
+	return removed
+}
@@ -0,0 +1,245 @@
+package bitmexclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/model/bitmex"
+)
+
+const (
+	waitTimeout   = 5 * time.Second
+	testBatchSize = 15
+)
+
+type testServer struct {
+	*httptest.Server
+
+	ops   chan bitmex.Operation
+	pings chan struct{}
+	conns chan *websocket.Conn
+
+	pong bool
+}
+
+func newTestServer(t *testing.T, pong bool) *testServer {
+	t.Helper()
+
+	srv := &testServer{
+		ops:   make(chan bitmex.Operation, 100),
+		pings: make(chan struct{}, 100),
+		conns: make(chan *websocket.Conn, 10),
+		pong:  pong,
+	}
+	upgrader := websocket.Upgrader{}
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		srv.conns <- conn
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if string(message) == pingMessage {
+				srv.pings <- struct{}{}
+				if srv.pong {
+					_ = conn.WriteMessage(websocket.TextMessage, []byte(pongMessage))
+				}
+
+				continue
+			}
+
+			var op bitmex.Operation
+			if err = json.Unmarshal(message, &op); err == nil {
+				srv.ops <- op
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func (s *testServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func testConfig(url string) *config.BitMexConfig {
+	return &config.BitMexConfig{
+		WSURL:                url,
+		SubscribeBatchSize:   testBatchSize,
+		ReconnectMinBackoff:  config.Duration{Duration: 10 * time.Millisecond},
+		ReconnectMaxBackoff:  config.Duration{Duration: 50 * time.Millisecond},
+		ReconnectMaxAttempts: 3,
+		PingInterval:         config.Duration{Duration: time.Minute},
+		StaleTimeout:         config.Duration{Duration: time.Minute},
+	}
+}
+
+func waitState(t *testing.T, client *Client, expected State) {
+	t.Helper()
+
+	timeout := time.After(waitTimeout)
+
+	for {
+		select {
+		case state := <-client.States():
+			if state == expected {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("state %s not reached, current %s", expected, client.State())
+		}
+	}
+}
+
+func waitOp(t *testing.T, srv *testServer) bitmex.Operation {
+	t.Helper()
+
+	select {
+	case op := <-srv.ops:
+		return op
+	case <-time.After(waitTimeout):
+		t.Fatal("operation not received")
+	}
+
+	return bitmex.Operation{}
+}
+
+func TestClient_ReconnectReplaysSubscriptions(t *testing.T) {
+	srv := newTestServer(t, false)
+	client := New(testConfig(srv.wsURL()))
+
+	topics := make([]string, 0, testBatchSize+1)
+	for i := 0; i <= testBatchSize; i++ {
+		topics = append(topics, "trade:SYMBOL"+string(rune('A'+i)))
+	}
+	require.NoError(t, client.Subscribe(topics...))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go client.Run(ctx, wg)
+
+	waitState(t, client, StateConnected)
+	assert.Len(t, waitOp(t, srv).Args, testBatchSize)
+	assert.Len(t, waitOp(t, srv).Args, 1)
+
+	conn := <-srv.conns
+	require.NoError(t, conn.Close())
+
+	waitState(t, client, StateReconnecting)
+	waitState(t, client, StateConnected)
+
+	replayed := append(waitOp(t, srv).Args, waitOp(t, srv).Args...)
+	assert.Equal(t, topics, replayed)
+
+	cancel()
+	op := waitOp(t, srv)
+	assert.Equal(t, bitmex.Unsubscribe, op.Op)
+
+	wg.Wait()
+	assert.Equal(t, StateClosed, client.State())
+}
+
+func TestClient_Failed(t *testing.T) {
+	srv := newTestServer(t, false)
+	url := srv.wsURL()
+	srv.Close()
+
+	client := New(testConfig(url))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go client.Run(ctx, wg)
+
+	waitState(t, client, StateFailed)
+
+	cancel()
+	wg.Wait()
+
+	_, ok := <-client.Messages()
+	assert.False(t, ok)
+}
+
+func TestClient_Backoff(t *testing.T) {
+	client := New(testConfig(""))
+
+	for attempt := 1; attempt < 100; attempt++ {
+		delay := client.backoff(attempt)
+
+		assert.GreaterOrEqual(t, delay, 5*time.Millisecond)
+		assert.LessOrEqual(t, delay, 50*time.Millisecond)
+	}
+}
+
+func TestClient_Heartbeat(t *testing.T) {
+	srv := newTestServer(t, true)
+	conf := testConfig(srv.wsURL())
+	conf.PingInterval.Duration = 20 * time.Millisecond
+	conf.StaleTimeout.Duration = 100 * time.Millisecond
+
+	client := New(conf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go client.Run(ctx, wg)
+
+	waitState(t, client, StateConnected)
+
+	for i := 0; i < 10; i++ {
+		select {
+		case <-srv.pings:
+		case <-time.After(waitTimeout):
+			t.Fatal("ping not received")
+		}
+	}
+
+	assert.Equal(t, StateConnected, client.State())
+	assert.Len(t, client.Messages(), 0, "pong must not be forwarded")
+
+	cancel()
+	wg.Wait()
+}
+
+func TestClient_StaleFeedReconnects(t *testing.T) {
+	srv := newTestServer(t, false)
+	conf := testConfig(srv.wsURL())
+	conf.PingInterval.Duration = 20 * time.Millisecond
+	conf.StaleTimeout.Duration = 100 * time.Millisecond
+
+	client := New(conf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go client.Run(ctx, wg)
+
+	waitState(t, client, StateConnected)
+	<-srv.pings
+	waitState(t, client, StateStale)
+	waitState(t, client, StateReconnecting)
+	waitState(t, client, StateConnected)
+
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,326 @@
+// Package fakebitmex is an in-process stand-in for the BitMEX REST and realtime API used by tests.
+package fakebitmex
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/model/bitmex"
+)
+
+const (
+	activeInstrumentsPath = "/api/v1/instrument/active"
+	realtimePath          = "/realtime"
+	pollInterval          = 5 * time.Millisecond
+	batchSize             = 15
+	orderBookDepth        = 10
+)
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	instruments []bitmex.SymbolInfo
+	ops         []bitmex.Operation
+	topics      []string
+	conns       map[*websocket.Conn]*sync.Mutex
+}
+
+// NewServer starts fake exchange which lists given symbols as active instruments.
+func NewServer(symbols ...string) *Server {
+	srv := &Server{
+		conns: make(map[*websocket.Conn]*sync.Mutex),
+	}
+	srv.SetInstruments(symbols...)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(activeInstrumentsPath, srv.handleInstruments)
+	mux.HandleFunc(realtimePath, srv.handleRealtime)
+
+	srv.Server = httptest.NewServer(mux)
+
+	return srv
+}
+
+func (s *Server) RestURL() string {
+	return s.URL
+}
+
+func (s *Server) WSURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + realtimePath
+}
+
+// Config returns client configuration pointing to the fake with short timeouts.
+func (s *Server) Config() *config.BitMexConfig {
+	return &config.BitMexConfig{
+		Environment:          "fake",
+		RestURL:              s.RestURL(),
+		WSURL:                s.WSURL(),
+		SubscribeBatchSize:   batchSize,
+		DialTimeout:          config.Duration{Duration: time.Second},
+		HTTPTimeout:          config.Duration{Duration: time.Second},
+		ReconnectMinBackoff:  config.Duration{Duration: 10 * time.Millisecond},
+		ReconnectMaxBackoff:  config.Duration{Duration: 50 * time.Millisecond},
+		ReconnectMaxAttempts: 3,
+		PingInterval:         config.Duration{Duration: time.Minute},
+		StaleTimeout:         config.Duration{Duration: time.Minute},
+		OrderBookTable:       bitmex.OrderBookL2_25Table,
+		OrderBookDepth:       orderBookDepth,
+	}
+}
+
+func (s *Server) SetInstruments(symbols ...string) {
+	instruments := make([]bitmex.SymbolInfo, 0, len(symbols))
+	for _, symbol := range symbols {
+		instruments = append(instruments, bitmex.SymbolInfo{Symbol: symbol, State: "Open"})
+	}
+
+	s.mu.Lock()
+	s.instruments = instruments
+	s.mu.Unlock()
+}
+
+// Ops returns every subscribe and unsubscribe operation received so far.
+func (s *Server) Ops() []bitmex.Operation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]bitmex.Operation(nil), s.ops...)
+}
+
+// Topics returns currently subscribed topics.
+func (s *Server) Topics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.topics...)
+}
+
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.conns)
+}
+
+// WaitForTopics waits until all topics are subscribed.
+func (s *Server) WaitForTopics(timeout time.Duration, topics ...string) bool {
+	return s.wait(timeout, func() bool {
+		subscribed := s.Topics()
+		for _, topic := range topics {
+			if !slices.Contains(subscribed, topic) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// WaitForOps waits until at least count operations are received.
+func (s *Server) WaitForOps(timeout time.Duration, count int) bool {
+	return s.wait(timeout, func() bool {
+		return len(s.Ops()) >= count
+	})
+}
+
+func (s *Server) WaitForConnections(timeout time.Duration, count int) bool {
+	return s.wait(timeout, func() bool {
+		return s.Connections() >= count
+	})
+}
+
+func (s *Server) SendTrades(records ...bitmex.TradeDataRecord) error {
+	return s.SendTable(bitmex.TradeTable, bitmex.InsertAction, records)
+}
+
+func (s *Server) SendQuotes(records ...bitmex.QuoteDataRecord) error {
+	return s.SendTable(bitmex.QuoteTable, bitmex.InsertAction, records)
+}
+
+// SendOrderBook sends orderBookL2_25 frame with partial, insert, update or delete action.
+func (s *Server) SendOrderBook(action string, records ...bitmex.OrderBookL2Record) error {
+	return s.SendTable(bitmex.OrderBookL2_25Table, action, records)
+}
+
+// SendTable sends table frame like {"table": "quote", "action": "insert", "data": [...]}.
+func (s *Server) SendTable(table, action string, data interface{}) error {
+	return s.SendJSON(map[string]interface{}{
+		"table":  table,
+		"action": action,
+		"data":   data,
+	})
+}
+
+// SendError sends error frame the way BitMEX reports rejected requests.
+func (s *Server) SendError(status int, message string) error {
+	return s.SendJSON(bitmex.ResponseMessage{
+		Status: status,
+		Error:  message,
+	})
+}
+
+func (s *Server) SendJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.SendRaw(data)
+}
+
+// SendRaw writes frame as is to every connected client.
+func (s *Server) SendRaw(data []byte) error {
+	s.mu.Lock()
+	conns := make(map[*websocket.Conn]*sync.Mutex, len(s.conns))
+	for conn, mu := range s.conns {
+		conns[conn] = mu
+	}
+	s.mu.Unlock()
+
+	for conn, mu := range conns {
+		if err := write(conn, mu, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DropConnections closes every realtime connection without close handshake.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		_ = conn.Close()
+		delete(s.conns, conn)
+	}
+
+	s.topics = nil
+}
+
+func (s *Server) Close() {
+	s.DropConnections()
+	s.Server.Close()
+}
+
+func (s *Server) wait(timeout time.Duration, condition func() bool) bool {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+
+		time.Sleep(pollInterval)
+	}
+
+	return condition()
+}
+
+func (s *Server) handleInstruments(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	instruments := s.instruments
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(instruments)
+}
+
+func (s *Server) handleRealtime(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	mu := &sync.Mutex{}
+
+	s.mu.Lock()
+	s.conns[conn] = mu
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if _, ok := s.conns[conn]; ok {
+			delete(s.conns, conn)
+			s.topics = nil
+		}
+		s.mu.Unlock()
+
+		_ = conn.Close()
+	}()
+
+	_ = write(conn, mu, []byte(`{"info":"Welcome to the fake BitMEX Realtime API."}`))
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if string(message) == "ping" {
+			_ = write(conn, mu, []byte("pong"))
+
+			continue
+		}
+
+		var op bitmex.Operation
+		if err = json.Unmarshal(message, &op); err != nil {
+			continue
+		}
+
+		s.apply(op)
+
+		for _, topic := range op.Args {
+			response := bitmex.ResponseMessage{Success: true, Request: op}
+			if op.Op == bitmex.Subscribe {
+				response.Subscribe = topic
+			} else {
+				response.Unsubscribe = topic
+			}
+
+			data, err := json.Marshal(response)
+			if err != nil {
+				continue
+			}
+
+			_ = write(conn, mu, data)
+		}
+	}
+}
+
+func (s *Server) apply(op bitmex.Operation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ops = append(s.ops, op)
+
+	for _, topic := range op.Args {
+		switch op.Op {
+		case bitmex.Subscribe:
+			if !slices.Contains(s.topics, topic) {
+				s.topics = append(s.topics, topic)
+			}
+		case bitmex.Unsubscribe:
+			s.topics = slices.DeleteFunc(s.topics, func(t string) bool { return t == topic })
+		}
+	}
+}
+
+func write(conn *websocket.Conn, mu *sync.Mutex, data []byte) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
@@ -0,0 +1,92 @@
+package bitmexclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"bitmex-api/pkg/logger"
+)
+
+const (
+	pingMessage = "ping"
+	pongMessage = "pong"
+)
+
+// heartbeat tracks upstream activity of a single connection.
+// BitMEX expects a text "ping" when nothing was received for a while and answers with "pong".
+type heartbeat struct {
+	pingInterval time.Duration
+	staleTimeout time.Duration
+
+	lastMessage atomic.Int64
+}
+
+func newHeartbeat(pingInterval, staleTimeout time.Duration) *heartbeat {
+	h := &heartbeat{
+		pingInterval: pingInterval,
+		staleTimeout: staleTimeout,
+	}
+	h.lastMessage.Store(time.Now().UnixNano())
+
+	return h
+}
+
+// touch registers received message and moves read deadline forward.
+func (h *heartbeat) touch(conn *websocket.Conn) {
+	now := time.Now()
+	h.lastMessage.Store(now.UnixNano())
+
+	if h.staleTimeout <= 0 {
+		return
+	}
+
+	if err := conn.SetReadDeadline(now.Add(h.staleTimeout)); err != nil {
+		logger.Errorf("SetReadDeadline error", err)
+	}
+}
+
+func (h *heartbeat) idle() time.Duration {
+	return time.Since(time.Unix(0, h.lastMessage.Load()))
+}
+
+// run sends ping every time connection is idle for pingInterval until done is closed.
+func (h *heartbeat) run(ctx context.Context, done <-chan struct{}, ping func() error) {
+	if h.pingInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(h.pingInterval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			if h.idle() < h.pingInterval {
+				continue
+			}
+
+			if err := ping(); err != nil {
+				logger.Errorf("Ping error", err)
+			}
+		}
+	}
+}
+
+func isPong(message []byte) bool {
+	return string(message) == pongMessage
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
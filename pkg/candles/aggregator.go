@@ -0,0 +1,153 @@
+// Package candles aggregates BitMEX trades into OHLCV bars.
+package candles
+
+import (
+	"sync"
+	"time"
+
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/candle"
+)
+
+type seriesKey struct {
+	symbol   string
+	interval candle.Interval
+}
+
+// series is open bar of symbol interval and its closed bars, oldest first.
+type series struct {
+	current *candle.Bar
+	closed  []candle.Bar
+}
+
+// Aggregator builds bars of every candle.Intervals for every traded symbol.
+type Aggregator struct {
+	history int
+	series  map[seriesKey]*series
+
+	mu sync.RWMutex
+}
+
+// NewAggregator keeps up to history closed bars of each symbol interval.
+func NewAggregator(history int) *Aggregator {
+	return &Aggregator{
+		history: history,
+		series:  make(map[seriesKey]*series),
+	}
+}
+
+// Add folds trade into bars of every interval.
+// It returns changed bars, bars closed by the trade go before the ones it opened.
+// Trades older than the open bar are dropped, its previous bar is already final.
+func (a *Aggregator) Add(trade bitmex.TradeDataRecord) []candle.Bar {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	bars := make([]candle.Bar, 0, len(candle.Intervals))
+
+	for _, interval := range candle.Intervals {
+		duration, _ := interval.Duration()
+		start := trade.Timestamp.UTC().Truncate(duration)
+
+		key := seriesKey{symbol: trade.Symbol, interval: interval}
+		s, ok := a.series[key]
+		if !ok {
+			s = &series{}
+			a.series[key] = s
+		}
+
+		if s.current != nil && start.Before(s.current.Start) {
+			continue
+		}
+
+		if s.current != nil && start.After(s.current.Start) {
+			bars = append(bars, a.close(s))
+		}
+
+		if s.current == nil {
+			if len(s.closed) != 0 && !start.After(s.closed[len(s.closed)-1].Start) {
+				continue
+			}
+
+			s.current = &candle.Bar{
+				Symbol:   trade.Symbol,
+				Interval: interval,
+				Start:    start,
+				Open:     trade.Price,
+				High:     trade.Price,
+				Low:      trade.Price,
+			}
+		}
+
+		s.current.High = max(s.current.High, trade.Price)
+		s.current.Low = min(s.current.Low, trade.Price)
+		s.current.Close = trade.Price
+		s.current.Volume += trade.Size
+		s.current.Trades++
+
+		bars = append(bars, *s.current)
+	}
+
+	return bars
+}
+
+// Close finalizes open bars whose interval ended by now and returns them.
+func (a *Aggregator) Close(now time.Time) []candle.Bar {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	bars := make([]candle.Bar, 0)
+
+	for key, s := range a.series {
+		if s.current == nil {
+			continue
+		}
+
+		duration, _ := key.interval.Duration()
+		if s.current.Start.Add(duration).After(now) {
+			continue
+		}
+
+		bars = append(bars, a.close(s))
+	}
+
+	return bars
+}
+
+// Bars returns up to limit latest bars of symbol interval including the open one, oldest first.
+func (a *Aggregator) Bars(symbol string, interval candle.Interval, limit int) []candle.Bar {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	bars := make([]candle.Bar, 0)
+
+	s, ok := a.series[seriesKey{symbol: symbol, interval: interval}]
+	if !ok {
+		return bars
+	}
+
+	bars = append(bars, s.closed...)
+	if s.current != nil {
+		bars = append(bars, *s.current)
+	}
+
+	if limit > 0 && len(bars) > limit {
+		bars = bars[len(bars)-limit:]
+	}
+
+	return bars
+}
+
+func (a *Aggregator) close(s *series) candle.Bar {
+	bar := *s.current
+	bar.Closed = true
+
+	s.closed = append(s.closed, bar)
+	if len(s.closed) > a.history {
+		s.closed = s.closed[len(s.closed)-a.history:]
+	}
+
+	s.current = nil
+
+	return bar
+}
@@ -0,0 +1,96 @@
+package candles
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/candle"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func trade(offset time.Duration, price float64, size int64) bitmex.TradeDataRecord {
+	return bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: price, Size: size, Timestamp: start.Add(offset)}
+}
+
+func intervals(bars []candle.Bar) []candle.Interval {
+	result := make([]candle.Interval, 0, len(bars))
+	for _, bar := range bars {
+		result = append(result, bar.Interval)
+	}
+
+	return result
+}
+
+func TestAggregator_Add(t *testing.T) {
+	aggregator := NewAggregator(2)
+
+	bars := aggregator.Add(trade(10*time.Second, 100, 1))
+	require.Len(t, bars, len(candle.Intervals))
+	aggregator.Add(trade(20*time.Second, 105, 2))
+	aggregator.Add(trade(30*time.Second, 95, 3))
+
+	bars = aggregator.Add(trade(70*time.Second, 101, 4))
+	require.Len(t, bars, len(candle.Intervals)+1)
+	assert.Equal(t, candle.Bar{
+		Symbol:   "XBTUSD",
+		Interval: candle.Minute1,
+		Start:    start,
+		Open:     100,
+		High:     105,
+		Low:      95,
+		Close:    95,
+		Volume:   6,
+		Trades:   3,
+		Closed:   true,
+	}, bars[0])
+	assert.Equal(t, candle.Bar{
+		Symbol:   "XBTUSD",
+		Interval: candle.Minute1,
+		Start:    start.Add(time.Minute),
+		Open:     101,
+		High:     101,
+		Low:      101,
+		Close:    101,
+		Volume:   4,
+		Trades:   1,
+	}, bars[1])
+
+	assert.NotContains(t, intervals(aggregator.Add(trade(50*time.Second, 1, 1))), candle.Minute1)
+
+	fiveMinutes := aggregator.Bars("XBTUSD", candle.Minute5, 0)
+	require.Len(t, fiveMinutes, 1)
+	assert.Equal(t, int64(11), fiveMinutes[0].Volume)
+	assert.Equal(t, float64(1), fiveMinutes[0].Low)
+	assert.False(t, fiveMinutes[0].Closed)
+}
+
+func TestAggregator_Close(t *testing.T) {
+	aggregator := NewAggregator(2)
+
+	aggregator.Add(trade(0, 100, 1))
+	assert.Empty(t, aggregator.Close(start.Add(59*time.Second)))
+
+	bars := aggregator.Close(start.Add(time.Minute))
+	require.Len(t, bars, 1)
+	assert.Equal(t, candle.Minute1, bars[0].Interval)
+	assert.True(t, bars[0].Closed)
+
+	assert.NotContains(t, intervals(aggregator.Add(trade(30*time.Second, 100, 1))), candle.Minute1)
+
+	aggregator.Add(trade(time.Minute, 101, 1))
+	aggregator.Add(trade(2*time.Minute, 102, 1))
+	aggregator.Add(trade(3*time.Minute, 103, 1))
+
+	bars = aggregator.Bars("XBTUSD", candle.Minute1, 0)
+	require.Len(t, bars, 3)
+	assert.Equal(t, []float64{101, 102, 103}, []float64{bars[0].Close, bars[1].Close, bars[2].Close})
+	assert.False(t, bars[2].Closed)
+
+	assert.Len(t, aggregator.Bars("XBTUSD", candle.Minute1, 1), 1)
+	assert.Empty(t, aggregator.Bars("ETHUSD", candle.Minute1, 1))
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	TestnetEnvironment = "testnet"
+	MainnetEnvironment = "mainnet"
+)
+
+var (
+	ErrInvalidBatchSize      = errors.New("bitmex subscribe batch size must be positive")
+	ErrInvalidOrderBookTable = errors.New("bitmex order book table must be orderBookL2 or orderBookL2_25")
+	ErrInvalidBackoff        = errors.New("bitmex reconnect min backoff must be positive and not above max backoff")
+	ErrInvalidStaleTimeout   = errors.New("bitmex ping interval must be positive and shorter than stale timeout")
+)
+
+type bitMexEndpoints struct {
+	restURL string
+	wsURL   string
+}
+
+//nolint:gochecknoglobals
+var bitMexEnvironments = map[string]bitMexEndpoints{
+	TestnetEnvironment: {
+		restURL: "https://testnet.bitmex.com",
+		wsURL:   "wss://testnet.bitmex.com/realtime",
+	},
+	MainnetEnvironment: {
+		restURL: "https://www.bitmex.com",
+		wsURL:   "wss://ws.bitmex.com/realtime",
+	},
+}
+
+// RestBaseURL returns REST base URL of the environment unless BITMEX_REST_URL overrides it.
+func (c *BitMexConfig) RestBaseURL() string {
+	if c.RestURL != "" {
+		return strings.TrimSuffix(c.RestURL, "/")
+	}
+
+	return bitMexEnvironments[c.Environment].restURL
+}
+
+// RealtimeURL returns WebSocket URL of the environment unless BITMEX_WS_URL overrides it.
+func (c *BitMexConfig) RealtimeURL() string {
+	if c.WSURL != "" {
+		return c.WSURL
+	}
+
+	return bitMexEnvironments[c.Environment].wsURL
+}
+
+// Validate checks that both endpoints are known, custom environments must override both URLs.
+func (c *BitMexConfig) Validate() error {
+	if c.RestBaseURL() == "" || c.RealtimeURL() == "" {
+		return fmt.Errorf("unknown bitmex environment %q, set BITMEX_REST_URL and BITMEX_WS_URL", c.Environment)
+	}
+
+	if c.SubscribeBatchSize <= 0 {
+		return ErrInvalidBatchSize
+	}
+
+	if c.ReconnectMinBackoff.Duration <= 0 || c.ReconnectMaxBackoff.Duration < c.ReconnectMinBackoff.Duration {
+		return ErrInvalidBackoff
+	}
+
+	if c.PingInterval.Duration <= 0 || c.StaleTimeout.Duration <= c.PingInterval.Duration {
+		return ErrInvalidStaleTimeout
+	}
+
+	if c.OrderBookTable != "orderBookL2" && c.OrderBookTable != "orderBookL2_25" {
+		return ErrInvalidOrderBookTable
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ type Configs struct {
 	DBPostgresConfig DBPostgresConfig
 	Server           ServerConfig
 	Keys             Path
+	SMTP             SMTPConfig
 }
 
 type DBPostgresConfig struct {
@@ -26,7 +27,79 @@ type Path struct {
 
 type ServerConfig struct {
 	ServerPort  string   `env:"SERVER_PORT"`
+	GRPCPort    string   `env:"GRPC_PORT"    envDefault:":9090"`
 	ReadTimeout Duration `env:"READ_TIMEOUT"`
+	BitMex      BitMexConfig
+	Candles     CandlesConfig
+	Trades      TradesConfig
+	Sessions    SessionsConfig
+	SSE         SSEConfig
+	Webhooks    WebhooksConfig
+	Paper       PaperConfig
+}
+
+type BitMexConfig struct {
+	Environment          string   `env:"BITMEX_ENV"                    envDefault:"testnet"`
+	RestURL              string   `env:"BITMEX_REST_URL"`
+	WSURL                string   `env:"BITMEX_WS_URL"`
+	SubscribeBatchSize   int      `env:"BITMEX_SUBSCRIBE_BATCH_SIZE"   envDefault:"15"`
+	DialTimeout          Duration `env:"BITMEX_DIAL_TIMEOUT"           envDefault:"10s"`
+	HTTPTimeout          Duration `env:"BITMEX_HTTP_TIMEOUT"           envDefault:"10s"`
+	ReconnectMinBackoff  Duration `env:"BITMEX_RECONNECT_MIN_BACKOFF"  envDefault:"1s"`
+	ReconnectMaxBackoff  Duration `env:"BITMEX_RECONNECT_MAX_BACKOFF"  envDefault:"30s"`
+	ReconnectMaxAttempts int      `env:"BITMEX_RECONNECT_MAX_ATTEMPTS" envDefault:"10"`
+	PingInterval         Duration `env:"BITMEX_PING_INTERVAL"          envDefault:"5s"`
+	StaleTimeout         Duration `env:"BITMEX_STALE_TIMEOUT"          envDefault:"10s"`
+	OrderBookTable       string   `env:"BITMEX_ORDER_BOOK_TABLE"       envDefault:"orderBookL2_25"`
+	OrderBookDepth       int      `env:"BITMEX_ORDER_BOOK_DEPTH"       envDefault:"10"`
+}
+
+type CandlesConfig struct {
+	History    int      `env:"CANDLES_HISTORY"     envDefault:"500"`
+	CloseDelay Duration `env:"CANDLES_CLOSE_DELAY" envDefault:"2s"`
+}
+
+type TradesConfig struct {
+	BatchSize         int      `env:"TRADES_BATCH_SIZE"         envDefault:"500"`
+	BufferSize        int      `env:"TRADES_BUFFER_SIZE"        envDefault:"10000"`
+	FlushInterval     Duration `env:"TRADES_FLUSH_INTERVAL"     envDefault:"1s"`
+	Retention         Duration `env:"TRADES_RETENTION"          envDefault:"720h"`
+	RetentionInterval Duration `env:"TRADES_RETENTION_INTERVAL" envDefault:"1h"`
+}
+
+type SessionsConfig struct {
+	MaxPerUser         int      `env:"WS_MAX_SESSIONS_PER_USER" envDefault:"5"`
+	SendQueueSize      int      `env:"WS_SEND_QUEUE_SIZE"       envDefault:"256"`
+	OverflowPolicy     string   `env:"WS_OVERFLOW_POLICY"       envDefault:"dropOldest"`
+	WriteTimeout       Duration `env:"WS_WRITE_TIMEOUT"         envDefault:"10s"`
+	PingInterval       Duration `env:"WS_PING_INTERVAL"         envDefault:"30s"`
+	IdleTimeout        Duration `env:"WS_IDLE_TIMEOUT"          envDefault:"60s"`
+	CompressionLevel   int      `env:"WS_COMPRESSION_LEVEL"     envDefault:"1"`
+	MaxBatchSize       int      `env:"WS_MAX_BATCH_SIZE"        envDefault:"100"`
+	TokenCheckInterval Duration `env:"WS_TOKEN_CHECK_INTERVAL"  envDefault:"10s"`
+	TokenExpiryWarning Duration `env:"WS_TOKEN_EXPIRY_WARNING"  envDefault:"5m"`
+}
+
+type SSEConfig struct {
+	ReplaySize        int      `env:"SSE_REPLAY_SIZE"        envDefault:"1000"`
+	ReplayTTL         Duration `env:"SSE_REPLAY_TTL"         envDefault:"30s"`
+	KeepAliveInterval Duration `env:"SSE_KEEPALIVE_INTERVAL" envDefault:"15s"`
+}
+
+type WebhooksConfig struct {
+	Workers       int      `env:"WEBHOOK_WORKERS"        envDefault:"4"`
+	QueueSize     int      `env:"WEBHOOK_QUEUE_SIZE"     envDefault:"1000"`
+	Timeout       Duration `env:"WEBHOOK_TIMEOUT"        envDefault:"10s"`
+	MaxAttempts   int      `env:"WEBHOOK_MAX_ATTEMPTS"   envDefault:"5"`
+	Backoff       Duration `env:"WEBHOOK_BACKOFF"        envDefault:"1s"`
+	MaxBackoff    Duration `env:"WEBHOOK_MAX_BACKOFF"    envDefault:"5m"`
+	RetryInterval Duration `env:"WEBHOOK_RETRY_INTERVAL" envDefault:"1s"`
+	AllowHTTP     bool     `env:"WEBHOOK_ALLOW_HTTP"     envDefault:"false"`
+}
+
+type PaperConfig struct {
+	InitialBalance float64 `env:"PAPER_INITIAL_BALANCE" envDefault:"100000"`
+	MaxOpenOrders  int     `env:"PAPER_MAX_OPEN_ORDERS" envDefault:"100"`
 }
 
 func New() (*Configs, error) {
@@ -35,5 +108,33 @@ func New() (*Configs, error) {
 		return nil, err
 	}
 
+	if err := config.Server.BitMex.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Server.Trades.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Server.Sessions.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Server.SSE.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Server.Webhooks.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Server.Paper.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.SMTP.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
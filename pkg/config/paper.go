@@ -0,0 +1,14 @@
+package config
+
+import "errors"
+
+var ErrInvalidPaperConfig = errors.New("paper initial balance and max open orders must be positive")
+
+// Validate checks paper trading account settings.
+func (c *PaperConfig) Validate() error {
+	if c.InitialBalance <= 0 || c.MaxOpenOrders <= 0 {
+		return ErrInvalidPaperConfig
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"compress/flate"
+	"errors"
+	"fmt"
+
+	"bitmex-api/pkg/sendqueue"
+)
+
+var (
+	ErrInvalidSendQueueSize = errors.New("websocket send queue size must be positive")
+	ErrInvalidCompression   = errors.New("websocket compression level must be from -2 to 9")
+	ErrInvalidMaxBatchSize  = errors.New("websocket max batch size must be positive")
+	ErrInvalidKeepAlive     = errors.New("websocket ping interval must be positive and shorter than idle timeout")
+	ErrInvalidTokenCheck    = errors.New("websocket token check interval and expiry warning must be positive")
+	ErrInvalidReplaySize    = errors.New("sse replay size must be positive")
+	ErrInvalidSSEIntervals  = errors.New("sse replay ttl and keepalive interval must be positive")
+)
+
+// Validate checks send queue, compression, batching, keepalive and token check settings of user sessions.
+func (c *SessionsConfig) Validate() error {
+	if c.SendQueueSize <= 0 {
+		return ErrInvalidSendQueueSize
+	}
+
+	if !sendqueue.Policy(c.OverflowPolicy).Valid() {
+		return fmt.Errorf("unknown websocket overflow policy %q, use dropOldest, conflate or disconnect", c.OverflowPolicy)
+	}
+
+	if c.CompressionLevel < flate.HuffmanOnly || c.CompressionLevel > flate.BestCompression {
+		return ErrInvalidCompression
+	}
+
+	if c.MaxBatchSize <= 0 {
+		return ErrInvalidMaxBatchSize
+	}
+
+	if c.PingInterval.Duration <= 0 || c.PingInterval.Duration >= c.IdleTimeout.Duration {
+		return ErrInvalidKeepAlive
+	}
+
+	if c.TokenCheckInterval.Duration <= 0 || c.TokenExpiryWarning.Duration <= 0 {
+		return ErrInvalidTokenCheck
+	}
+
+	return nil
+}
+
+// Validate checks replay and keepalive settings of event streams.
+func (c *SSEConfig) Validate() error {
+	if c.ReplaySize <= 0 {
+		return ErrInvalidReplaySize
+	}
+
+	if c.ReplayTTL.Duration <= 0 || c.KeepAliveInterval.Duration <= 0 {
+		return ErrInvalidSSEIntervals
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	"net/mail"
+)
+
+var (
+	ErrInvalidSMTPQueue = errors.New("smtp queue size and timeout must be positive")
+	ErrInvalidSMTPFrom  = errors.New("smtp from must be an email address")
+)
+
+// SMTPConfig is the mail server of email notifications, empty Host turns email notifications off.
+// VerifyURL is the link of verification email, the token is added to it as token query parameter.
+type SMTPConfig struct {
+	Host      string   `env:"SMTP_HOST"`
+	Port      string   `env:"SMTP_PORT"        envDefault:"587"`
+	Username  string   `env:"SMTP_USERNAME"`
+	Password  string   `env:"SMTP_PASSWORD"`
+	From      string   `env:"SMTP_FROM"        envDefault:"noreply@localhost"`
+	Timeout   Duration `env:"SMTP_TIMEOUT"     envDefault:"10s"`
+	QueueSize int      `env:"SMTP_QUEUE_SIZE"  envDefault:"100"`
+	VerifyURL string   `env:"EMAIL_VERIFY_URL" envDefault:"http://localhost:8000/api/v1/email/verify"`
+}
+
+// Validate checks queue and sender settings of email notifications.
+func (c *SMTPConfig) Validate() error {
+	if c.QueueSize <= 0 || c.Timeout.Duration <= 0 {
+		return ErrInvalidSMTPQueue
+	}
+
+	if _, err := mail.ParseAddress(c.From); c.Host != "" && err != nil {
+		return ErrInvalidSMTPFrom
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package config
+
+import "errors"
+
+var (
+	ErrInvalidTradesBatch    = errors.New("trade history batch size and buffer size must be positive")
+	ErrInvalidTradesInterval = errors.New("trade history flush and retention intervals must be positive")
+)
+
+// Validate checks batching and retention settings of trade history, zero retention keeps trades forever.
+func (c *TradesConfig) Validate() error {
+	if c.BatchSize <= 0 || c.BufferSize <= 0 {
+		return ErrInvalidTradesBatch
+	}
+
+	if c.FlushInterval.Duration <= 0 || c.RetentionInterval.Duration <= 0 {
+		return ErrInvalidTradesInterval
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import "errors"
+
+var (
+	ErrInvalidWebhookWorkers = errors.New("webhook workers and queue size must be positive")
+	ErrInvalidWebhookRetries = errors.New("webhook max attempts, timeout, backoff and retry interval must be positive")
+)
+
+// Validate checks delivery and retry settings of webhooks.
+func (c *WebhooksConfig) Validate() error {
+	if c.Workers <= 0 || c.QueueSize <= 0 {
+		return ErrInvalidWebhookWorkers
+	}
+
+	if c.MaxAttempts <= 0 || c.Timeout.Duration <= 0 || c.RetryInterval.Duration <= 0 ||
+		c.Backoff.Duration <= 0 || c.MaxBackoff.Duration < c.Backoff.Duration {
+		return ErrInvalidWebhookRetries
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// Package lastvalue keeps the last trade and quote of every symbol for subscription snapshots.
+package lastvalue
+
+import (
+	"sync"
+
+	"bitmex-api/pkg/model/bitmex"
+)
+
+type Cache struct {
+	trades map[string]bitmex.TradeDataRecord
+	quotes map[string]bitmex.QuoteDataRecord
+
+	mu sync.RWMutex
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		trades: make(map[string]bitmex.TradeDataRecord),
+		quotes: make(map[string]bitmex.QuoteDataRecord),
+	}
+}
+
+// PutTrade stores trade unless a later trade of the symbol is stored already.
+func (c *Cache) PutTrade(record bitmex.TradeDataRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if last, ok := c.trades[record.Symbol]; ok && record.Timestamp.Before(last.Timestamp) {
+		return
+	}
+
+	c.trades[record.Symbol] = record
+}
+
+// PutQuote stores quote unless a later quote of the symbol is stored already.
+func (c *Cache) PutQuote(record bitmex.QuoteDataRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if last, ok := c.quotes[record.Symbol]; ok && record.Timestamp.Before(last.Timestamp) {
+		return
+	}
+
+	c.quotes[record.Symbol] = record
+}
+
+func (c *Cache) Trade(symbol string) (bitmex.TradeDataRecord, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	record, ok := c.trades[symbol]
+
+	return record, ok
+}
+
+func (c *Cache) Quote(symbol string) (bitmex.QuoteDataRecord, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	record, ok := c.quotes[symbol]
+
+	return record, ok
+}
@@ -0,0 +1,46 @@
+package lastvalue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"bitmex-api/pkg/model/bitmex"
+)
+
+func TestCache_Trade(t *testing.T) {
+	cache := NewCache()
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, ok := cache.Trade("XBTUSD")
+	assert.False(t, ok)
+
+	cache.PutTrade(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42000, Timestamp: timestamp})
+	cache.PutTrade(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 41000, Timestamp: timestamp.Add(-time.Second)})
+	cache.PutTrade(bitmex.TradeDataRecord{Symbol: "ETHUSD", Price: 2000, Timestamp: timestamp})
+
+	record, ok := cache.Trade("XBTUSD")
+	assert.True(t, ok)
+	assert.InDelta(t, 42000, record.Price, 0)
+
+	cache.PutTrade(bitmex.TradeDataRecord{Symbol: "XBTUSD", Price: 42001, Timestamp: timestamp})
+
+	record, _ = cache.Trade("XBTUSD")
+	assert.InDelta(t, 42001, record.Price, 0)
+}
+
+func TestCache_Quote(t *testing.T) {
+	cache := NewCache()
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cache.PutQuote(bitmex.QuoteDataRecord{Symbol: "XBTUSD", BidPrice: 41999, Timestamp: timestamp})
+	cache.PutQuote(bitmex.QuoteDataRecord{Symbol: "XBTUSD", BidPrice: 41000, Timestamp: timestamp.Add(-time.Second)})
+
+	record, ok := cache.Quote("XBTUSD")
+	assert.True(t, ok)
+	assert.InDelta(t, 41999, record.BidPrice, 0)
+
+	_, ok = cache.Quote("ETHUSD")
+	assert.False(t, ok)
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// AlertCondition is the rule of price alert.
+type AlertCondition string
+
+const (
+	// CrossesAbove fires when trade price rises from below Price to Price or above it.
+	CrossesAbove AlertCondition = "crossesAbove"
+	// CrossesBelow fires when trade price falls from above Price to Price or below it.
+	CrossesBelow AlertCondition = "crossesBelow"
+	// MovesPercent fires when trade price moves by Percent or more either way within WindowMs.
+	MovesPercent AlertCondition = "movesPercent"
+)
+
+// MaxAlertWindow is the longest window of MovesPercent alert.
+const MaxAlertWindow = 24 * time.Hour
+
+// Alert is price alert rule of the user. Alert without Repeat is deactivated once it fires.
+type Alert struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID      uuid.UUID      `json:"-"`
+	Symbol      string         `json:"symbol"`
+	Condition   AlertCondition `json:"condition"`
+	Price       float64        `json:"price,omitempty"`
+	Percent     float64        `json:"percent,omitempty"`
+	WindowMs    int64          `json:"windowMs,omitempty"`
+	Repeat      bool           `json:"repeat"`
+	Active      bool           `json:"active"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	TriggeredAt *time.Time     `json:"triggeredAt,omitempty"`
+}
+
+func (a *Alert) TableName() string {
+	return "alerts"
+}
+
+func (a *Alert) BeforeCreate(*gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsValid checks parameters of the condition and clears parameters of other conditions.
+func (a *Alert) IsValid() bool {
+	switch a.Condition {
+	case CrossesAbove, CrossesBelow:
+		a.Percent, a.WindowMs = 0, 0
+
+		return a.Price > 0
+	case MovesPercent:
+		a.Price = 0
+
+		return a.Percent > 0 && a.WindowMs > 0 && a.WindowMs <= MaxAlertWindow.Milliseconds()
+	default:
+		return false
+	}
+}
+
+// Window is the window of MovesPercent alert.
+func (a *Alert) Window() time.Duration {
+	return time.Duration(a.WindowMs) * time.Millisecond
+}
+
+// AlertEvent is fired alert kept in alert history. Price is the trade price that fired the alert,
+// ReferencePrice is Price of crossing alert and the lowest or the highest price of the window of moving alert.
+type AlertEvent struct {
+	ID             int64          `gorm:"primaryKey" json:"id"`
+	AlertID        uuid.UUID      `json:"alertID"`
+	UserID         uuid.UUID      `json:"-"`
+	Symbol         string         `json:"symbol"`
+	Condition      AlertCondition `json:"condition"`
+	Price          float64        `json:"price"`
+	ReferencePrice float64        `json:"referencePrice"`
+	Timestamp      time.Time      `json:"timestamp"`
+}
+
+func (e *AlertEvent) TableName() string {
+	return "alert_events"
+}
@@ -0,0 +1,23 @@
+package bitmex
+
+type OperationName string
+
+const (
+	Subscribe   OperationName = "subscribe"
+	Unsubscribe OperationName = "unsubscribe"
+)
+
+type Operation struct {
+	Op   OperationName `json:"op"`
+	Args []string      `json:"args"`
+}
+
+// ResponseMessage is a reply to an operation, failed requests carry status and error.
+type ResponseMessage struct {
+	Success     bool      `json:"success,omitempty"`
+	Subscribe   string    `json:"subscribe,omitempty"`
+	Unsubscribe string    `json:"unsubscribe,omitempty"`
+	Status      int       `json:"status,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Request     Operation `json:"request"`
+}
@@ -0,0 +1,17 @@
+package bitmex
+
+import "time"
+
+const (
+	BuySide  = "Buy"
+	SellSide = "Sell"
+)
+
+type OrderBookL2Record struct {
+	Symbol    string    `json:"symbol"`
+	ID        int64     `json:"id"`
+	Side      string    `json:"side"`
+	Size      int64     `json:"size"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+}
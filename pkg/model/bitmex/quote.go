@@ -0,0 +1,18 @@
+package bitmex
+
+import "time"
+
+type QuoteMessage struct {
+	Table  string            `json:"table"`
+	Action string            `json:"action"`
+	Data   []QuoteDataRecord `json:"data"`
+}
+
+type QuoteDataRecord struct {
+	Symbol    string    `json:"symbol"`
+	BidSize   int64     `json:"bidSize"`
+	BidPrice  float64   `json:"bidPrice"`
+	AskPrice  float64   `json:"askPrice"`
+	AskSize   int64     `json:"askSize"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -0,0 +1,29 @@
+package bitmex
+
+import "encoding/json"
+
+const (
+	TradeTable          = "trade"
+	QuoteTable          = "quote"
+	OrderBookL2Table    = "orderBookL2"
+	OrderBookL2_25Table = "orderBookL2_25"
+)
+
+const (
+	PartialAction = "partial"
+	InsertAction  = "insert"
+	UpdateAction  = "update"
+	DeleteAction  = "delete"
+)
+
+// TableMessage is a realtime data frame, Data is decoded according to Table.
+type TableMessage struct {
+	Table  string          `json:"table"`
+	Action string          `json:"action"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Topic returns subscription topic for table and symbol, e.g. trade:XBTUSD.
+func Topic(table, symbol string) string {
+	return table + ":" + symbol
+}
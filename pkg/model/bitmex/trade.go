@@ -9,7 +9,14 @@ type TradeMessage struct {
 }
 
 type TradeDataRecord struct {
-	Symbol    string    `json:"symbol"`
-	Price     float64   `json:"price"`
-	Timestamp time.Time `json:"timestamp"`
+	Symbol          string    `json:"symbol"`
+	Side            string    `json:"side"`
+	Size            int64     `json:"size"`
+	Price           float64   `json:"price"`
+	TickDirection   string    `json:"tickDirection"`
+	TrdMatchID      string    `json:"trdMatchID"`
+	GrossValue      int64     `json:"grossValue"`
+	HomeNotional    float64   `json:"homeNotional"`
+	ForeignNotional float64   `json:"foreignNotional"`
+	Timestamp       time.Time `json:"timestamp"`
 }
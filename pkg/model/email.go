@@ -0,0 +1,33 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// EmailVerificationTTL is how long the token of verification email is valid.
+	EmailVerificationTTL = 24 * time.Hour
+
+	emailTokenSize = 32
+)
+
+// NewEmailToken returns random email verification token and its hash kept in the store.
+func NewEmailToken() (string, string, error) {
+	token := make([]byte, emailTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", "", err
+	}
+
+	encoded := hex.EncodeToString(token)
+
+	return encoded, HashEmailToken(encoded), nil
+}
+
+func HashEmailToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
@@ -13,6 +13,36 @@ var (
 	ErrAlreadyUnsubscribed = NewError(http.StatusBadRequest, "you have already unsubscribed")
 	ErrAlreadySubscribed   = NewError(http.StatusBadRequest, "you have already subscribed")
 	ErrIncorrectSymbol     = NewError(http.StatusBadRequest, "incorrect symbol")
+	ErrIncorrectChannel    = NewError(http.StatusBadRequest, "incorrect channel")
+	ErrOrderBookNotReady   = NewError(http.StatusServiceUnavailable, "order book is not ready, try again later")
+	ErrIncorrectVersion    = NewError(http.StatusBadRequest, "incorrect payload version")
+	ErrIncorrectEncoding   = NewError(http.StatusBadRequest, "incorrect encoding, use json, msgpack or protobuf")
+	ErrIncorrectBatch      = NewError(http.StatusBadRequest, "incorrect batch, use true or false")
+	ErrIncorrectInterval   = NewError(http.StatusBadRequest, "incorrect candle interval")
+	ErrIncorrectTimeRange  = NewError(http.StatusBadRequest, "incorrect time range, use RFC3339 from before to")
+	ErrIncorrectCursor     = NewError(http.StatusBadRequest, "incorrect cursor")
+	ErrIncorrectCommand    = NewError(http.StatusBadRequest, "incorrect command")
+	ErrIncorrectDelivery   = NewError(http.StatusBadRequest, "incorrect delivery, throttle interval must be from 100 to 60000 ms")
+	ErrTooManySessions     = NewError(http.StatusTooManyRequests, "too many open sessions")
+	ErrUserNotFound        = NewError(http.StatusNotFound, "user not found")
+	ErrIncorrectAlert      = NewError(http.StatusBadRequest, "incorrect alert condition or its parameters")
+	ErrAlertNotFound       = NewError(http.StatusNotFound, "alert not found")
+	ErrIncorrectWebhook    = NewError(http.StatusBadRequest, "incorrect webhook url, events or their parameters")
+	ErrWebhookNotFound     = NewError(http.StatusNotFound, "webhook not found")
+	ErrIncorrectEmail      = NewError(http.StatusBadRequest, "incorrect email")
+	ErrInvalidEmailToken   = NewError(http.StatusBadRequest, "email verification token is invalid or expired")
+	ErrEmailDisabled       = NewError(http.StatusServiceUnavailable, "email notifications are disabled")
+	ErrIncorrectPaperOrder = NewError(http.StatusBadRequest, "incorrect paper order side, type, quantity or prices")
+	ErrPaperOrderNotFound  = NewError(http.StatusNotFound, "paper order not found")
+	ErrPaperOrderNotOpen   = NewError(http.StatusBadRequest, "paper order is already filled, cancelled or rejected")
+	ErrTooManyPaperOrders  = NewError(http.StatusBadRequest, "too many open paper orders")
+
+	ErrIncorrectWatchlist      = NewError(http.StatusBadRequest, "incorrect watchlist name or symbols")
+	ErrWatchlistNotFound       = NewError(http.StatusNotFound, "watchlist not found")
+	ErrWatchlistExists         = NewError(http.StatusBadRequest, "watchlist with the name exists")
+	ErrTooManyWatchlists       = NewError(http.StatusBadRequest, "too many watchlists")
+	ErrIncorrectWatchlistOrder = NewError(http.StatusBadRequest, "order must list every watchlist once")
+	ErrEmptyWatchlist          = NewError(http.StatusBadRequest, "watchlist has no symbols")
 )
 
 const (
@@ -0,0 +1,209 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type OrderSide string
+
+const (
+	Buy  OrderSide = "buy"
+	Sell OrderSide = "sell"
+)
+
+// OrderType is the execution rule of paper order.
+type OrderType string
+
+const (
+	// MarketOrder fills at the price of the next trade of the symbol.
+	MarketOrder OrderType = "market"
+	// LimitOrder fills at the trade price once it is at Price or better.
+	LimitOrder OrderType = "limit"
+	// StopOrder becomes market order once trade price reaches StopPrice, above it for buy and below it for sell.
+	StopOrder OrderType = "stop"
+)
+
+type OrderStatus string
+
+const (
+	OrderNew             OrderStatus = "new"
+	OrderPartiallyFilled OrderStatus = "partiallyFilled"
+	OrderFilled          OrderStatus = "filled"
+	OrderCancelled       OrderStatus = "cancelled"
+	OrderRejected        OrderStatus = "rejected"
+)
+
+// PaperResetReason is the reason of orders cancelled by the reset of paper account.
+const PaperResetReason = "account reset"
+
+var (
+	ErrInsufficientBalance  = errors.New("insufficient paper balance")
+	ErrInsufficientPosition = errors.New("insufficient paper position")
+	ErrPaperOrderClosed     = errors.New("paper order is not open")
+)
+
+// PaperOrder is simulated order of the user filled against the trade stream.
+// Order fills partially when trade size is less than the rest of the order.
+type PaperOrder struct {
+	ID             uuid.UUID   `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID         uuid.UUID   `json:"-"`
+	Symbol         string      `json:"symbol"`
+	Side           OrderSide   `json:"side"`
+	Type           OrderType   `json:"type"`
+	Quantity       float64     `json:"quantity"`
+	Price          float64     `json:"price,omitempty"`
+	StopPrice      float64     `json:"stopPrice,omitempty"`
+	FilledQuantity float64     `json:"filledQuantity"`
+	AvgFillPrice   float64     `json:"avgFillPrice,omitempty"`
+	Status         OrderStatus `json:"status"`
+	Reason         string      `json:"reason,omitempty"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+}
+
+func (o *PaperOrder) TableName() string {
+	return "paper_orders"
+}
+
+func (o *PaperOrder) BeforeCreate(*gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsValid checks side, quantity and prices of the order type and clears prices of other types.
+func (o *PaperOrder) IsValid() bool {
+	if (o.Side != Buy && o.Side != Sell) || o.Quantity <= 0 {
+		return false
+	}
+
+	switch o.Type {
+	case MarketOrder:
+		o.Price, o.StopPrice = 0, 0
+
+		return true
+	case LimitOrder:
+		o.StopPrice = 0
+
+		return o.Price > 0
+	case StopOrder:
+		o.Price = 0
+
+		return o.StopPrice > 0
+	default:
+		return false
+	}
+}
+
+// Open reports whether the order waits for fills.
+func (o *PaperOrder) Open() bool {
+	return o.Status == OrderNew || o.Status == OrderPartiallyFilled
+}
+
+func (o *PaperOrder) Remaining() float64 {
+	return o.Quantity - o.FilledQuantity
+}
+
+// Apply adds the fill to filled quantity and average fill price of the order.
+func (o *PaperOrder) Apply(fill *PaperFill) {
+	value := o.AvgFillPrice*o.FilledQuantity + fill.Price*fill.Quantity
+
+	o.FilledQuantity += fill.Quantity
+	o.AvgFillPrice = value / o.FilledQuantity
+	o.UpdatedAt = fill.Timestamp
+
+	if o.Remaining() > 0 {
+		o.Status = OrderPartiallyFilled
+	} else {
+		o.Status = OrderFilled
+	}
+}
+
+// PaperFill is execution of paper order at the price of the trade that filled it.
+type PaperFill struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	OrderID   uuid.UUID `json:"orderID"`
+	UserID    uuid.UUID `json:"-"`
+	Symbol    string    `json:"symbol"`
+	Side      OrderSide `json:"side"`
+	Quantity  float64   `json:"quantity"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (f *PaperFill) TableName() string {
+	return "paper_fills"
+}
+
+// PaperAccount is paper balance of the user in quote currency, it is created with the initial balance
+// on the first use and every fill costs or returns quantity times price.
+type PaperAccount struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primary_key;" json:"-"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (a *PaperAccount) TableName() string {
+	return "paper_accounts"
+}
+
+// Fill settles the fill against the balance and the position of its symbol. Buy needs the balance for its cost,
+// sell needs the position for its quantity, so positions are never short.
+func (a *PaperAccount) Fill(position *PaperPosition, fill *PaperFill) error {
+	value := fill.Price * fill.Quantity
+
+	switch fill.Side {
+	case Buy:
+		if a.Balance < value {
+			return ErrInsufficientBalance
+		}
+
+		a.Balance -= value
+		position.AvgPrice = (position.AvgPrice*position.Quantity + value) / (position.Quantity + fill.Quantity)
+		position.Quantity += fill.Quantity
+	case Sell:
+		if position.Quantity < fill.Quantity {
+			return ErrInsufficientPosition
+		}
+
+		a.Balance += value
+		position.RealizedPnl += (fill.Price - position.AvgPrice) * fill.Quantity
+		position.Quantity -= fill.Quantity
+
+		if position.Quantity == 0 {
+			position.AvgPrice = 0
+		}
+	}
+
+	a.UpdatedAt, position.UpdatedAt = fill.Timestamp, fill.Timestamp
+
+	return nil
+}
+
+// PaperPosition is paper holding of the user in the symbol, RealizedPnl is the profit of sells
+// over AvgPrice of the bought quantity.
+type PaperPosition struct {
+	UserID      uuid.UUID `gorm:"type:uuid;primary_key;" json:"-"`
+	Symbol      string    `gorm:"primary_key;" json:"symbol"`
+	Quantity    float64   `json:"quantity"`
+	AvgPrice    float64   `json:"avgPrice"`
+	RealizedPnl float64   `json:"realizedPnl"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (p *PaperPosition) TableName() string {
+	return "paper_positions"
+}
+
+// ExecutionReport is change of paper order pushed to the user stream, Fill is set when the order is filled.
+type ExecutionReport struct {
+	Order PaperOrder `json:"order"`
+	Fill  *PaperFill `json:"fill,omitempty"`
+}
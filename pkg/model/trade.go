@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid trade cursor")
+
+// Trade is persisted BitMex trade tick.
+type Trade struct {
+	ID              int64     `gorm:"primaryKey" json:"-"`
+	Symbol          string    `json:"symbol"`
+	Side            string    `json:"side"`
+	Size            int64     `json:"size"`
+	Price           float64   `json:"price"`
+	TickDirection   string    `json:"tickDirection"`
+	TrdMatchID      string    `gorm:"column:trd_match_id" json:"trdMatchID"`
+	GrossValue      int64     `json:"grossValue"`
+	HomeNotional    float64   `json:"homeNotional"`
+	ForeignNotional float64   `json:"foreignNotional"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+func (t *Trade) TableName() string {
+	return "trades"
+}
+
+// TradeCursor points at the last trade of a page, trades are ordered by timestamp and id.
+type TradeCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+func (c TradeCursor) Encode() string {
+	raw := strconv.FormatInt(c.Timestamp.UnixNano(), 10) + ":" + strconv.FormatInt(c.ID, 10)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseTradeCursor(cursor string) (TradeCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return TradeCursor{}, ErrInvalidCursor
+	}
+
+	timestamp, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return TradeCursor{}, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return TradeCursor{}, ErrInvalidCursor
+	}
+
+	tradeID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return TradeCursor{}, ErrInvalidCursor
+	}
+
+	return TradeCursor{Timestamp: time.Unix(0, nanos).UTC(), ID: tradeID}, nil
+}
+
+// TradeQuery selects trades of symbol in [From, To), zero bounds are open.
+type TradeQuery struct {
+	Symbol string
+	From   time.Time
+	To     time.Time
+	After  *TradeCursor
+	Limit  int
+}
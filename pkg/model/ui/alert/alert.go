@@ -0,0 +1,26 @@
+package alert
+
+import "bitmex-api/pkg/model"
+
+// Request creates or replaces price alert, Active defaults to true.
+// Price is used by crossesAbove and crossesBelow, Percent and WindowMs by movesPercent.
+type Request struct {
+	Symbol    string               `json:"symbol"`
+	Condition model.AlertCondition `json:"condition"`
+	Price     float64              `json:"price,omitempty"`
+	Percent   float64              `json:"percent,omitempty"`
+	WindowMs  int64                `json:"windowMs,omitempty"`
+	Repeat    bool                 `json:"repeat"`
+	Active    *bool                `json:"active,omitempty"`
+}
+
+// Apply sets the rule of the request to the alert.
+func (r *Request) Apply(alert *model.Alert) {
+	alert.Symbol = r.Symbol
+	alert.Condition = r.Condition
+	alert.Price = r.Price
+	alert.Percent = r.Percent
+	alert.WindowMs = r.WindowMs
+	alert.Repeat = r.Repeat
+	alert.Active = r.Active == nil || *r.Active
+}
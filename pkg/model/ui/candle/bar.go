@@ -0,0 +1,45 @@
+package candle
+
+import "time"
+
+type Interval string
+
+const (
+	Minute1  Interval = "1m"
+	Minute5  Interval = "5m"
+	Minute15 Interval = "15m"
+	Hour1    Interval = "1h"
+	Day1     Interval = "1d"
+)
+
+// Intervals lists every interval bars are aggregated for.
+var Intervals = []Interval{Minute1, Minute5, Minute15, Hour1, Day1}
+
+var durations = map[Interval]time.Duration{
+	Minute1:  time.Minute,
+	Minute5:  5 * time.Minute,
+	Minute15: 15 * time.Minute,
+	Hour1:    time.Hour,
+	Day1:     24 * time.Hour,
+}
+
+// Duration returns length of the interval, false is returned for unknown interval.
+func (i Interval) Duration() (time.Duration, bool) {
+	d, ok := durations[i]
+
+	return d, ok
+}
+
+// Bar is OHLCV candle, Closed is set once its interval is over and the bar won't change anymore.
+type Bar struct {
+	Symbol   string    `json:"symbol"`
+	Interval Interval  `json:"interval"`
+	Start    time.Time `json:"start"`
+	Open     float64   `json:"open"`
+	High     float64   `json:"high"`
+	Low      float64   `json:"low"`
+	Close    float64   `json:"close"`
+	Volume   int64     `json:"volume"`
+	Trades   int       `json:"trades"`
+	Closed   bool      `json:"closed"`
+}
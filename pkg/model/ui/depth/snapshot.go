@@ -0,0 +1,17 @@
+package depth
+
+import "time"
+
+type Level struct {
+	ID    int64   `json:"id"`
+	Price float64 `json:"price"`
+	Size  int64   `json:"size"`
+}
+
+// Snapshot is top of the order book, bids are sorted from best to worst as well as asks.
+type Snapshot struct {
+	Symbol    string    `json:"symbol"`
+	Bids      []Level   `json:"bids"`
+	Asks      []Level   `json:"asks"`
+	Timestamp time.Time `json:"timestamp"`
+}
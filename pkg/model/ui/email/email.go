@@ -0,0 +1,18 @@
+package email
+
+// Request replaces email of the user, the new email gets notifications once it is verified.
+type Request struct {
+	Email string `json:"email"`
+}
+
+// NotificationsRequest turns email of fired price alerts on or off,
+// password change and login emails are always sent to verified email.
+type NotificationsRequest struct {
+	Alerts bool `json:"alerts"`
+}
+
+type Status struct {
+	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+	Alerts   bool   `json:"alerts"`
+}
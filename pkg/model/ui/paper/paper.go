@@ -0,0 +1,23 @@
+package paper
+
+import "bitmex-api/pkg/model"
+
+// OrderRequest places paper order, Price is used by limit orders and StopPrice by stop orders.
+type OrderRequest struct {
+	Symbol    string          `json:"symbol"`
+	Side      model.OrderSide `json:"side"`
+	Type      model.OrderType `json:"type"`
+	Quantity  float64         `json:"quantity"`
+	Price     float64         `json:"price,omitempty"`
+	StopPrice float64         `json:"stopPrice,omitempty"`
+}
+
+// Apply sets the request to the order.
+func (r *OrderRequest) Apply(order *model.PaperOrder) {
+	order.Symbol = r.Symbol
+	order.Side = r.Side
+	order.Type = r.Type
+	order.Quantity = r.Quantity
+	order.Price = r.Price
+	order.StopPrice = r.StopPrice
+}
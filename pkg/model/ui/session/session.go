@@ -0,0 +1,48 @@
+package session
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model/ui/subscription"
+)
+
+type Transport string
+
+const (
+	WebSocketTransport Transport = "websocket"
+	SSETransport       Transport = "sse"
+	GRPCTransport      Transport = "grpc"
+)
+
+// Info describes open /connect, SSE or gRPC session of the user, ExpiresAt is expiry of the access token of /connect session.
+type Info struct {
+	ID            uuid.UUID                  `json:"id"`
+	Transport     Transport                  `json:"transport"`
+	Encoding      string                     `json:"encoding"`
+	Version       int                        `json:"version"`
+	Batch         bool                       `json:"batch"`
+	ConnectedAt   time.Time                  `json:"connectedAt"`
+	ExpiresAt     *time.Time                 `json:"expiresAt,omitempty"`
+	Subscriptions subscription.Subscriptions `json:"subscriptions"`
+	Queue         Queue                      `json:"queue"`
+}
+
+// Queue describes outbound queue of the session, lags are in milliseconds.
+// LagMs is age of the oldest frame waiting for the client, MaxLagMs is the longest wait of a sent frame.
+type Queue struct {
+	Queued    int    `json:"queued"`
+	Capacity  int    `json:"capacity"`
+	Sent      uint64 `json:"sent"`
+	Dropped   uint64 `json:"dropped"`
+	Conflated uint64 `json:"conflated"`
+	LagMs     int64  `json:"lagMs"`
+	MaxLagMs  int64  `json:"maxLagMs"`
+}
+
+// LimitRequest sets how many sessions the user may open, null resets it to the server default and 0 lifts the limit.
+type LimitRequest struct {
+	UserID      uuid.UUID `json:"userId"`
+	MaxSessions *int      `json:"maxSessions"`
+}
@@ -0,0 +1,22 @@
+package stream
+
+// Close codes of /connect sessions closed by the server, 1000-1011 are standard codes of RFC 6455,
+// 4000-4999 are reserved for applications.
+const (
+	// CloseGoingAway closes sessions on server shutdown.
+	CloseGoingAway = 1001
+	// ClosePolicyViolation closes slow consumer evicted by disconnect overflow policy.
+	ClosePolicyViolation = 1008
+	// CloseInternalError rejects session when the user can not be loaded.
+	CloseInternalError = 1011
+	// CloseIdleTimeout closes session that sent neither a message nor a pong for the idle timeout.
+	CloseIdleTimeout = 4000
+	// CloseUnauthorized rejects session without valid access token.
+	CloseUnauthorized = 4001
+	// CloseTooManySessions rejects session over the session limit of the user.
+	CloseTooManySessions = 4002
+	// CloseTokenExpired closes session whose access token has expired without reauth.
+	CloseTokenExpired = 4003
+	// CloseAccountRemoved closes session of the user that has been removed or whose role has changed.
+	CloseAccountRemoved = 4004
+)
@@ -0,0 +1,47 @@
+package stream
+
+import (
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model/ui/subscription"
+)
+
+type CommandOp string
+
+const (
+	SubscribeCommand   CommandOp = "subscribe"
+	UnsubscribeCommand CommandOp = "unsubscribe"
+	ListCommand        CommandOp = "list"
+	PingCommand        CommandOp = "ping"
+	ReauthCommand      CommandOp = "reauth"
+)
+
+// Command is sent by client over /connect, ID is echoed in the ack or error frame of the command.
+// Symbols, Channels, Delivery and Watchlist of subscribe and unsubscribe have the same meaning as in
+// subscription.Request, Token of reauth is a fresh access token of the same user.
+type Command struct {
+	ID        string                 `json:"id"`
+	Op        CommandOp              `json:"op"`
+	Symbols   []string               `json:"symbols"`
+	Channels  []subscription.Channel `json:"channels"`
+	Delivery  *subscription.Delivery `json:"delivery,omitempty"`
+	Watchlist *uuid.UUID             `json:"watchlist,omitempty"`
+	Token     string                 `json:"token,omitempty"`
+}
+
+// AckMessage confirms command, Data is set for list and reauth commands.
+type AckMessage struct {
+	Type MessageType `json:"type"`
+	ID   string      `json:"id"`
+	Op   CommandOp   `json:"op"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// ErrorMessage rejects command, Code is HTTP status of the same error of REST API.
+type ErrorMessage struct {
+	Type    MessageType `json:"type"`
+	ID      string      `json:"id"`
+	Op      CommandOp   `json:"op"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+}
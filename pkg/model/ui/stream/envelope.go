@@ -0,0 +1,16 @@
+package stream
+
+import "bitmex-api/pkg/model/bitmex"
+
+// Envelope wraps typed data pushed to user WebSocket.
+type Envelope struct {
+	Type MessageType `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Snapshot is the last known trade and quote of the symbol, sent right after the session subscribes to it.
+type Snapshot struct {
+	Symbol string                  `json:"symbol"`
+	Trade  *bitmex.TradeDataRecord `json:"trade,omitempty"`
+	Quote  *bitmex.QuoteDataRecord `json:"quote,omitempty"`
+}
@@ -0,0 +1,45 @@
+package stream
+
+import "time"
+
+type MessageType string
+
+const (
+	StatusMessageType        MessageType = "status"
+	TradeMessageType         MessageType = "trade"
+	QuoteMessageType         MessageType = "quote"
+	OrderBookMessageType     MessageType = "orderBook"
+	CandleMessageType        MessageType = "candle"
+	AckMessageType           MessageType = "ack"
+	ErrorMessageType         MessageType = "error"
+	DisconnectMessageType    MessageType = "disconnect"
+	TradeWindowMessageType   MessageType = "tradeWindow"
+	SnapshotMessageType      MessageType = "snapshot"
+	TokenExpiringMessageType MessageType = "tokenExpiring"
+	AlertMessageType         MessageType = "alert"
+	ExecutionMessageType     MessageType = "execution"
+)
+
+type StatusMessage struct {
+	Type      MessageType `json:"type"`
+	State     string      `json:"state"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// DisconnectMessage is the last frame of the session closed by the server.
+type DisconnectMessage struct {
+	Type   MessageType `json:"type"`
+	Reason string      `json:"reason"`
+}
+
+// TokenExpiringMessage is sent once ahead of ExpiresAt of the access token of the session,
+// the session is closed at ExpiresAt unless it sends reauth command with a fresh token.
+type TokenExpiringMessage struct {
+	Type      MessageType `json:"type"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+// Token is data of reauth ack, ExpiresAt is expiry of the new access token of the session.
+type Token struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,47 @@
+package stream
+
+import (
+	"time"
+
+	"bitmex-api/pkg/model/bitmex"
+)
+
+// Version is version of payload pushed to user WebSocket, chosen with version query of /connect.
+type Version int
+
+const (
+	// V1 sends trades as bare LegacyTrade objects.
+	V1 Version = 1
+	// V2 sends trades with every BitMex field wrapped in Envelope.
+	V2 Version = 2
+)
+
+func (v Version) Valid() bool {
+	return v == V1 || v == V2
+}
+
+// LegacyTrade is trade payload of V1 clients.
+type LegacyTrade struct {
+	Symbol    string    `json:"symbol"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func NewLegacyTrade(record bitmex.TradeDataRecord) LegacyTrade {
+	return LegacyTrade{
+		Symbol:    record.Symbol,
+		Price:     record.Price,
+		Timestamp: record.Timestamp,
+	}
+}
+
+// TradeWindow sums trades of the symbol over throttle interval, Price and Timestamp are of the last trade.
+type TradeWindow struct {
+	Symbol    string    `json:"symbol"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+	Count     int       `json:"count"`
+	Volume    int64     `json:"volume"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+}
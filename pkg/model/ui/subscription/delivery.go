@@ -0,0 +1,81 @@
+package subscription
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type DeliveryMode string
+
+const (
+	// RealtimeDelivery forwards every trade.
+	RealtimeDelivery DeliveryMode = "realtime"
+	// ThrottledDelivery sends at most one trade update per symbol every IntervalMs.
+	ThrottledDelivery DeliveryMode = "throttled"
+
+	MinThrottleInterval = 100 * time.Millisecond
+	MaxThrottleInterval = time.Minute
+)
+
+var errDeliveryType = errors.New("delivery must be stored as json")
+
+// Delivery decides how trades are delivered. Throttled delivery conflates trades of the symbol over IntervalMs
+// into the latest one, with Aggregate the window is sent as stream.TradeWindow with trade count and volume.
+type Delivery struct {
+	Mode       DeliveryMode `json:"mode"`
+	IntervalMs int          `json:"intervalMs,omitempty"`
+	Aggregate  bool         `json:"aggregate,omitempty"`
+}
+
+func (d Delivery) Valid() bool {
+	switch d.Mode {
+	case "", RealtimeDelivery:
+		return d.IntervalMs == 0 && !d.Aggregate
+	case ThrottledDelivery:
+		return d.Interval() >= MinThrottleInterval && d.Interval() <= MaxThrottleInterval
+	}
+
+	return false
+}
+
+func (d Delivery) Throttled() bool {
+	return d.Mode == ThrottledDelivery
+}
+
+func (d Delivery) Interval() time.Duration {
+	return time.Duration(d.IntervalMs) * time.Millisecond
+}
+
+// Normalize returns delivery with empty mode replaced by realtime.
+func (d Delivery) Normalize() Delivery {
+	if d.Mode == "" {
+		d.Mode = RealtimeDelivery
+	}
+
+	return d
+}
+
+func (d Delivery) Value() (driver.Value, error) {
+	return json.Marshal(d.Normalize())
+}
+
+func (d *Delivery) Scan(src interface{}) error {
+	var data []byte
+
+	switch src := src.(type) {
+	case nil:
+		*d = Delivery{Mode: RealtimeDelivery}
+
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return errDeliveryType
+	}
+
+	return json.Unmarshal(data, d)
+}
@@ -1,5 +1,13 @@
 package subscription
 
+import (
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model/ui/candle"
+)
+
 type Action string
 
 const (
@@ -7,7 +15,50 @@ const (
 	Unsubscribe Action = "unsubscribe"
 )
 
+type Channel string
+
+const (
+	TradeChannel     Channel = "trade"
+	QuoteChannel     Channel = "quote"
+	OrderBookChannel Channel = "orderBook"
+
+	// CandleChannelPrefix is followed by bar interval, e.g. candle1m.
+	CandleChannelPrefix = "candle"
+)
+
+func CandleChannel(interval candle.Interval) Channel {
+	return Channel(CandleChannelPrefix + string(interval))
+}
+
+// CandleInterval returns bar interval of candle channel, false is returned for other channels.
+func (c Channel) CandleInterval() (candle.Interval, bool) {
+	interval, ok := strings.CutPrefix(string(c), CandleChannelPrefix)
+	if !ok {
+		return "", false
+	}
+
+	_, ok = candle.Interval(interval).Duration()
+
+	return candle.Interval(interval), ok
+}
+
+// Request applies action to every channel of the listed symbols, empty symbols mean all symbols.
+// Subscribe without channels subscribes to trades, unsubscribe without channels drops every subscription
+// of the listed symbols.
+// Delivery of subscribe replaces delivery of trades, subscribe already subscribed trades to change it only.
+// Symbols of Watchlist of the user are added to Symbols.
 type Request struct {
-	Action  Action   `json:"action"`
-	Symbols []string `json:"symbols"`
+	Action    Action     `json:"action"`
+	Symbols   []string   `json:"symbols"`
+	Channels  []Channel  `json:"channels"`
+	Delivery  *Delivery  `json:"delivery,omitempty"`
+	Watchlist *uuid.UUID `json:"watchlist,omitempty" swaggertype:"string"`
+}
+
+// Subscriptions lists user subscriptions, Trade with empty TradeSymbols means trades of all symbols.
+type Subscriptions struct {
+	Trade        bool     `json:"trade"`
+	TradeSymbols []string `json:"tradeSymbols"`
+	Topics       []string `json:"topics"`
+	Delivery     Delivery `json:"delivery"`
 }
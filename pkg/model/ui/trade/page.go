@@ -0,0 +1,9 @@
+package trade
+
+import "bitmex-api/pkg/model"
+
+// Page is page of trade history, NextCursor is empty on the last page.
+type Page struct {
+	Trades     []model.Trade `json:"trades"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
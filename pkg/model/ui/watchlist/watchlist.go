@@ -0,0 +1,24 @@
+package watchlist
+
+import (
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+)
+
+// Request creates watchlist or renames it and replaces its symbols.
+type Request struct {
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"`
+}
+
+// Apply sets name and symbols of the request to the watchlist.
+func (r *Request) Apply(watchlist *model.Watchlist) {
+	watchlist.Name = r.Name
+	watchlist.Symbols = r.Symbols
+}
+
+// OrderRequest lists every watchlist of the user in the new order.
+type OrderRequest struct {
+	IDs []uuid.UUID `json:"ids" swaggertype:"array,string"`
+}
@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"github.com/lib/pq"
+
+	"bitmex-api/pkg/model"
+)
+
+// Request creates or replaces webhook, Active defaults to true.
+// Symbols and TradeIntervalMs are used by trade event.
+type Request struct {
+	URL             string               `json:"url"`
+	Events          []model.WebhookEvent `json:"events"`
+	Symbols         []string             `json:"symbols,omitempty"`
+	TradeIntervalMs int64                `json:"tradeIntervalMs,omitempty"`
+	Active          *bool                `json:"active,omitempty"`
+}
+
+// Apply sets URL and events of the request to the webhook.
+func (r *Request) Apply(webhook *model.Webhook) {
+	events := make(pq.StringArray, 0, len(r.Events))
+	for _, event := range r.Events {
+		events = append(events, string(event))
+	}
+
+	webhook.URL = r.URL
+	webhook.Events = events
+	webhook.Symbols = r.Symbols
+	webhook.TradeIntervalMs = r.TradeIntervalMs
+	webhook.Active = r.Active == nil || *r.Active
+}
@@ -1,20 +1,32 @@
 package model
 
 import (
+	"time"
+
 	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 	"gorm.io/gorm"
+
+	"bitmex-api/pkg/model/ui/subscription"
 )
 
 type User struct {
-	ID                  uuid.UUID      `json:"-"`
-	UserID              uuid.UUID      `json:"-"`
-	Name                string         `json:"name"`
-	Surname             string         `json:"surname"`
-	Phone               string         `json:"phone"`
-	Address             string         `json:"address"`
-	Subscription        bool           `json:"subscription"`
-	SubscriptionSymbols pq.StringArray `gorm:"type:text[]"  json:"subscriptionSymbols"`
+	ID                   uuid.UUID             `json:"-"`
+	UserID               uuid.UUID             `json:"-"`
+	Name                 string                `json:"name"`
+	Surname              string                `json:"surname"`
+	Phone                string                `json:"phone"`
+	Address              string                `json:"address"`
+	Subscription         bool                  `json:"subscription"`
+	SubscriptionSymbols  pq.StringArray        `gorm:"type:text[]"  json:"subscriptionSymbols"`
+	SubscriptionTopics   pq.StringArray        `gorm:"type:text[]"  json:"subscriptionTopics"`
+	SubscriptionDelivery subscription.Delivery `gorm:"type:jsonb"   json:"subscriptionDelivery"`
+	MaxSessions          *int                  `json:"maxSessions"`
+	Email                string                `json:"email,omitempty"`
+	EmailVerified        bool                  `json:"emailVerified"`
+	EmailAlerts          bool                  `json:"emailAlerts"`
+	EmailTokenHash       string                `json:"-"`
+	EmailTokenExpiresAt  *time.Time            `json:"-"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxWatchlists is the number of watchlists a user can have.
+	MaxWatchlists = 50
+	// MaxWatchlistSymbols is the number of symbols of a watchlist.
+	MaxWatchlistSymbols = 200
+	// MaxWatchlistName is the length of watchlist name in characters.
+	MaxWatchlistName = 64
+
+	watchlistShareTokenSize = 16
+)
+
+// Watchlist is named list of symbols of the user, lists are ordered by Position.
+// Watchlist with ShareToken can be read and copied by other users who know the token.
+type Watchlist struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID     uuid.UUID      `json:"-"`
+	Name       string         `json:"name"`
+	Symbols    pq.StringArray `gorm:"type:text[]" json:"symbols" swaggertype:"array,string"`
+	Position   int            `json:"position"`
+	ShareToken *string        `json:"shareToken,omitempty"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+}
+
+func (w *Watchlist) TableName() string {
+	return "watchlists"
+}
+
+func (w *Watchlist) BeforeCreate(*gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsValid trims the name, drops repeated symbols and checks the name and the number of symbols.
+func (w *Watchlist) IsValid() bool {
+	w.Name = strings.TrimSpace(w.Name)
+
+	symbols := make(pq.StringArray, 0, len(w.Symbols))
+	for _, symbol := range w.Symbols {
+		if symbol == "" {
+			return false
+		}
+
+		if !slices.Contains(symbols, symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	w.Symbols = symbols
+
+	return w.Name != "" && utf8.RuneCountInString(w.Name) <= MaxWatchlistName && len(w.Symbols) <= MaxWatchlistSymbols
+}
+
+// NewWatchlistShareToken returns random hex encoded share token.
+func NewWatchlistShareToken() (string, error) {
+	token := make([]byte, watchlistShareTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
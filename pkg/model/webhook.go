@@ -0,0 +1,148 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookEvent is the kind of event delivered to webhook.
+type WebhookEvent string
+
+const (
+	// WebhookAlertEvent delivers fired price alerts of the user.
+	WebhookAlertEvent WebhookEvent = "alert"
+	// WebhookTradeEvent delivers trades of Symbols, at most one trade of a symbol every TradeIntervalMs.
+	WebhookTradeEvent WebhookEvent = "trade"
+)
+
+const (
+	// MinWebhookTradeInterval is the shortest interval of trade event of webhook.
+	MinWebhookTradeInterval = time.Second
+	// MaxWebhookTradeInterval is the longest interval of trade event of webhook.
+	MaxWebhookTradeInterval = time.Hour
+
+	webhookSecretSize = 32
+)
+
+// Webhook is HTTP endpoint of the user receiving events as JSON POST signed with Secret.
+// Secret is returned only once, when the webhook is created.
+type Webhook struct {
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID          uuid.UUID      `json:"-"`
+	URL             string         `json:"url"`
+	Secret          string         `json:"secret,omitempty"`
+	Events          pq.StringArray `gorm:"type:text[]" json:"events"            swaggertype:"array,string"`
+	Symbols         pq.StringArray `gorm:"type:text[]" json:"symbols,omitempty" swaggertype:"array,string"`
+	TradeIntervalMs int64          `json:"tradeIntervalMs,omitempty"`
+	Active          bool           `json:"active"`
+	CreatedAt       time.Time      `json:"createdAt"`
+}
+
+func (w *Webhook) TableName() string {
+	return "webhooks"
+}
+
+func (w *Webhook) BeforeCreate(*gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// NewWebhookSecret returns random hex encoded signing secret.
+func NewWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// IsValid checks URL and events of the webhook and clears trade parameters of webhook without trade event.
+// Plain HTTP URL is accepted only with allowHTTP.
+func (w *Webhook) IsValid(allowHTTP bool) bool {
+	endpoint, err := url.Parse(w.URL)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "https" && (!allowHTTP || endpoint.Scheme != "http")) {
+		return false
+	}
+
+	if len(w.Events) == 0 {
+		return false
+	}
+
+	for _, event := range w.Events {
+		switch WebhookEvent(event) {
+		case WebhookAlertEvent:
+		case WebhookTradeEvent:
+			if len(w.Symbols) == 0 || w.TradeInterval() < MinWebhookTradeInterval ||
+				w.TradeInterval() > MaxWebhookTradeInterval {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	if !w.Has(WebhookTradeEvent) {
+		w.Symbols, w.TradeIntervalMs = nil, 0
+	}
+
+	return true
+}
+
+// Has reports whether the webhook receives the event.
+func (w *Webhook) Has(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if WebhookEvent(e) == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TradeInterval is the interval of trade event.
+func (w *Webhook) TradeInterval() time.Duration {
+	return time.Duration(w.TradeIntervalMs) * time.Millisecond
+}
+
+// WebhookDeliveryStatus is the state of delivery of an event to webhook.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryRetrying  WebhookDeliveryStatus = "retrying"
+	// WebhookDeliveryFailed is delivery out of attempts, it is kept in the dead-letter list.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is the log of delivery of an event to webhook.
+// LastStatusCode and LastError are the result of the latest attempt.
+type WebhookDelivery struct {
+	ID             uuid.UUID             `gorm:"type:uuid;primary_key;" json:"id"`
+	WebhookID      uuid.UUID             `json:"webhookID"`
+	UserID         uuid.UUID             `json:"-"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        json.RawMessage       `gorm:"type:jsonb" json:"payload" swaggertype:"object"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	LastStatusCode int                   `json:"lastStatusCode,omitempty"`
+	LastError      string                `json:"lastError,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+	NextAttemptAt  *time.Time            `json:"nextAttemptAt,omitempty"`
+}
+
+func (d *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,228 @@
+// Package fakesmtp is an in-process stand-in for a mail server used by tests.
+package fakesmtp
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"bitmex-api/pkg/config"
+)
+
+const (
+	host    = "127.0.0.1"
+	timeout = 5 * time.Second
+	// inbox is the number of received messages kept until they are read.
+	inbox = 100
+)
+
+// Mail is received message, Auth is the username of PLAIN authentication.
+type Mail struct {
+	Auth    string
+	From    string
+	To      []string
+	Message *mail.Message
+	Body    string
+}
+
+// Server accepts every message and every PLAIN authentication, it supports no TLS.
+type Server struct {
+	Mails chan Mail
+
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// NewServer starts mail server on a random local port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &Server{
+		Mails:    make(chan Mail, inbox),
+		listener: listener,
+	}
+
+	srv.wg.Add(1)
+
+	go srv.serve()
+
+	return srv, nil
+}
+
+// Config returns SMTP config of the server authenticating as user.
+func (s *Server) Config(user string) *config.SMTPConfig {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+
+	return &config.SMTPConfig{
+		Host:      host,
+		Port:      port,
+		Username:  user,
+		Password:  "password",
+		From:      "noreply@example.com",
+		Timeout:   config.Duration{Duration: timeout},
+		QueueSize: inbox,
+		VerifyURL: "http://localhost/api/v1/email/verify",
+	}
+}
+
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+
+			_ = conn.SetDeadline(time.Now().Add(timeout))
+			s.session(bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)))
+		}()
+	}
+}
+
+//nolint:cyclop
+func (s *Server) session(rw *bufio.ReadWriter) {
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(rw, format+"\r\n", args...)
+		rw.Flush()
+	}
+
+	reply("220 %s fake smtp", host)
+
+	var current Mail
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-%s", host)
+			reply("250 AUTH PLAIN")
+		case "HELO":
+			reply("250 %s", host)
+		case "AUTH":
+			_, credentials, _ := strings.Cut(arg, " ")
+			current.Auth = plainUser(credentials)
+			reply("235 authenticated")
+		case "MAIL":
+			current.From = address(arg)
+			reply("250 ok")
+		case "RCPT":
+			current.To = append(current.To, address(arg))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+
+			if !s.receive(rw, current) {
+				return
+			}
+
+			current = Mail{Auth: current.Auth}
+
+			reply("250 queued")
+		case "RSET":
+			current = Mail{Auth: current.Auth}
+			reply("250 ok")
+		case "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// receive reads message data up to the terminating dot and puts the mail to Mails.
+func (s *Server) receive(rw *bufio.ReadWriter, current Mail) bool {
+	var data strings.Builder
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return false
+		}
+
+		if line == ".\r\n" {
+			break
+		}
+
+		data.WriteString(strings.TrimPrefix(line, "."))
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(data.String()))
+	if err != nil {
+		return false
+	}
+
+	current.Message = message
+	current.Body = readAll(message)
+
+	select {
+	case s.Mails <- current:
+	default:
+	}
+
+	return true
+}
+
+// readAll returns body of the message with LF line endings.
+func readAll(message *mail.Message) string {
+	var body strings.Builder
+
+	scanner := bufio.NewScanner(message.Body)
+	for scanner.Scan() {
+		body.WriteString(scanner.Text())
+		body.WriteString("\n")
+	}
+
+	return body.String()
+}
+
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+
+	return strings.Trim(strings.TrimSpace(addr), "<>")
+}
+
+// plainUser returns the username of base64 encoded PLAIN credentials.
+func plainUser(credentials string) string {
+	decoded, err := base64.StdEncoding.DecodeString(credentials)
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) != 3 { //nolint:gomnd
+		return ""
+	}
+
+	return parts[1]
+}
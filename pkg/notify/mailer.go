@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+)
+
+const (
+	VerificationTemplate    = "verification"
+	AlertTemplate           = "alert"
+	PasswordChangedTemplate = "password_changed"
+	LoginTemplate           = "login"
+
+	timestampLayout = "2006-01-02 15:04:05 MST"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var templateFuncs = template.FuncMap{
+	"price": func(price float64) string {
+		return strconv.FormatFloat(price, 'f', -1, 64)
+	},
+	"timestamp": func(at time.Time) string {
+		return at.UTC().Format(timestampLayout)
+	},
+}
+
+// Recipient resolves the address of the message when it is sent, nothing is sent when it returns false.
+type Recipient func() (string, bool)
+
+// Address is Recipient of the known address.
+func Address(to string) Recipient {
+	return func() (string, bool) {
+		return to, true
+	}
+}
+
+// queued is the message waiting for its recipient to be resolved.
+type queued struct {
+	to      Recipient
+	message Message
+}
+
+// Mailer renders notifications from templates and sends them by Notifier from the queue of QueueSize,
+// so requests and the trade stream never wait for the mail server. Nil Notifier turns notifications off.
+type Mailer struct {
+	notifier  Notifier
+	config    *config.SMTPConfig
+	templates map[string]*template.Template
+	queue     chan queued
+}
+
+func NewMailer(notifier Notifier, config *config.SMTPConfig) *Mailer {
+	templates := make(map[string]*template.Template)
+	for _, name := range []string{VerificationTemplate, AlertTemplate, PasswordChangedTemplate, LoginTemplate} {
+		templates[name] = template.Must(
+			template.New(name).Funcs(templateFuncs).ParseFS(templateFiles, "templates/"+name+".tmpl"),
+		)
+	}
+
+	return &Mailer{
+		notifier:  notifier,
+		config:    config,
+		templates: templates,
+		queue:     make(chan queued, config.QueueSize),
+	}
+}
+
+func (m *Mailer) Enabled() bool {
+	return m.notifier != nil
+}
+
+// Verification sends the link confirming email of the user.
+func (m *Mailer) Verification(to, token string) {
+	m.send(Address(to), VerificationTemplate, struct {
+		Email string
+		Link  string
+		Hours float64
+	}{
+		Email: to,
+		Link:  m.verifyLink(token),
+		Hours: model.EmailVerificationTTL.Hours(),
+	})
+}
+
+// Alert sends the fired alert, the recipient is resolved by Run, so the caller never waits for its lookup.
+func (m *Mailer) Alert(to Recipient, event model.AlertEvent) {
+	m.send(to, AlertTemplate, event)
+}
+
+// PasswordChanged sends notification of the password change, the recipient is resolved by Run.
+func (m *Mailer) PasswordChanged(to Recipient, at time.Time) {
+	m.send(to, PasswordChangedTemplate, struct{ At time.Time }{At: at})
+}
+
+// Login sends notification of a login from client, which is the address and the agent of the client.
+// The recipient is resolved by Run.
+func (m *Mailer) Login(to Recipient, at time.Time, client string) {
+	m.send(to, LoginTemplate, struct {
+		At     time.Time
+		Client string
+	}{At: at, Client: client})
+}
+
+func (m *Mailer) verifyLink(token string) string {
+	separator := "?"
+	if strings.Contains(m.config.VerifyURL, "?") {
+		separator = "&"
+	}
+
+	return m.config.VerifyURL + separator + "token=" + url.QueryEscape(token)
+}
+
+// send renders template and queues the message. The message is dropped when the queue is full.
+func (m *Mailer) send(to Recipient, name string, data interface{}) {
+	if !m.Enabled() {
+		return
+	}
+
+	var subject, body bytes.Buffer
+
+	err := m.templates[name].ExecuteTemplate(&subject, "subject", data)
+	if err == nil {
+		err = m.templates[name].ExecuteTemplate(&body, "body", data)
+	}
+
+	if err != nil {
+		logger.Errorf("email template error", err)
+
+		return
+	}
+
+	select {
+	case m.queue <- queued{to: to, message: Message{Subject: subject.String(), Body: body.String()}}:
+	default:
+		logger.Errorf("email queue is full, message dropped", name)
+	}
+}
+
+// Run resolves recipients and sends queued messages until ctx is done, failed message is logged and dropped.
+func (m *Mailer) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("mailer done")
+
+			return
+		case q := <-m.queue:
+			m.notify(q)
+		}
+	}
+}
+
+func (m *Mailer) notify(q queued) {
+	to, ok := q.to()
+	if !ok {
+		return
+	}
+
+	q.message.To = to
+	if err := m.notifier.Notify(q.message); err != nil {
+		logger.Errorf("email send error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/model"
+)
+
+// recorder is Notifier keeping messages, messages to fail@example.com fail.
+type recorder chan Message
+
+func (r recorder) Notify(message Message) error {
+	r <- message
+
+	if message.To == "fail@example.com" {
+		return errors.New("mailbox unavailable")
+	}
+
+	return nil
+}
+
+func testConfig() *config.SMTPConfig {
+	return &config.SMTPConfig{QueueSize: 10, VerifyURL: "https://example.com/api/v1/email/verify"}
+}
+
+func run(t *testing.T, mailer *Mailer) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go mailer.Run(ctx, wg)
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+}
+
+func receive(t *testing.T, messages recorder) Message {
+	t.Helper()
+
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("message was not sent")
+	}
+
+	return Message{}
+}
+
+func TestMailer_Templates(t *testing.T) {
+	messages := make(recorder, 10)
+	mailer := NewMailer(messages, testConfig())
+	run(t, mailer)
+
+	mailer.Verification("fail@example.com", "a+b")
+	message := receive(t, messages)
+	assert.Equal(t, "fail@example.com", message.To)
+	assert.Equal(t, "Confirm your email", message.Subject)
+	assert.Contains(t, message.Body, "https://example.com/api/v1/email/verify?token=a%2Bb\n")
+	assert.Contains(t, message.Body, "within 24 hours")
+
+	// failed message does not stop the mailer
+	mailer.Alert(Address("user@example.com"), model.AlertEvent{
+		AlertID:        uuid.NewV4(),
+		Symbol:         "XBTUSD",
+		Condition:      model.CrossesAbove,
+		Price:          70000.5,
+		ReferencePrice: 70000,
+		Timestamp:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	message = receive(t, messages)
+	assert.Equal(t, "XBTUSD alert fired at 70000.5", message.Subject)
+	assert.Equal(t, "Your crossesAbove alert on XBTUSD fired.\n\n"+
+		"Price: 70000.5\nReference price: 70000\nTime: 2024-01-02 03:04:05 UTC\n", message.Body)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("EET", 2*60*60))
+
+	mailer.PasswordChanged(Address("user@example.com"), at)
+	message = receive(t, messages)
+	assert.Equal(t, "Your password was changed", message.Subject)
+	assert.Contains(t, message.Body, "changed at 2024-01-02 01:04:05 UTC.")
+
+	mailer.Login(Address("user@example.com"), at, "127.0.0.1 curl/8.0")
+	message = receive(t, messages)
+	assert.Equal(t, "New login to your account", message.Subject)
+	assert.Contains(t, message.Body, "from 127.0.0.1 curl/8.0.")
+}
+
+func TestMailer_Disabled(t *testing.T) {
+	mailer := NewMailer(nil, testConfig())
+	require.False(t, mailer.Enabled())
+	run(t, mailer)
+
+	mailer.PasswordChanged(Address("user@example.com"), time.Now())
+	assert.Empty(t, mailer.queue)
+}
+
+func TestMailer_Recipient(t *testing.T) {
+	messages := make(recorder, 10)
+	mailer := NewMailer(messages, testConfig())
+
+	// recipient is resolved by Run, not by the caller
+	resolved := make(chan struct{}, 2)
+	event := model.AlertEvent{Symbol: "XBTUSD", Condition: model.CrossesAbove, Price: 1}
+
+	mailer.Alert(func() (string, bool) {
+		resolved <- struct{}{}
+
+		return "", false
+	}, event)
+	mailer.Alert(func() (string, bool) {
+		resolved <- struct{}{}
+
+		return "user@example.com", true
+	}, event)
+	assert.Empty(t, resolved)
+
+	run(t, mailer)
+
+	// message without recipient is skipped
+	message := receive(t, messages)
+	assert.Equal(t, "user@example.com", message.To)
+	assert.Len(t, resolved, 2)
+}
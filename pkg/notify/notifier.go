@@ -0,0 +1,14 @@
+// Package notify renders notifications of users and sends them by email.
+package notify
+
+// Message is plain text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages, SMTP is the default implementation.
+type Notifier interface {
+	Notify(message Message) error
+}
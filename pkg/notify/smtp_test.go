@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/notify/fakesmtp"
+)
+
+func TestSMTP_Notify(t *testing.T) {
+	server, err := fakesmtp.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	err = NewSMTP(server.Config("mailer")).Notify(Message{
+		To:      "user@example.com",
+		Subject: "Price alert ✓",
+		Body:    "first line\nsecond line\n",
+	})
+	require.NoError(t, err)
+
+	select {
+	case mail := <-server.Mails:
+		assert.Equal(t, "mailer", mail.Auth)
+		assert.Equal(t, "noreply@example.com", mail.From)
+		assert.Equal(t, []string{"user@example.com"}, mail.To)
+		assert.Equal(t, "=?utf-8?q?Price_alert_=E2=9C=93?=", mail.Message.Header.Get("Subject"))
+		assert.Equal(t, "text/plain; charset=utf-8", mail.Message.Header.Get("Content-Type"))
+		assert.Equal(t, "first line\nsecond line\n", mail.Body)
+	case <-time.After(time.Second):
+		t.Fatal("message was not received")
+	}
+}
+
+func TestSMTP_NotifyUnavailable(t *testing.T) {
+	server, err := fakesmtp.NewServer()
+	require.NoError(t, err)
+
+	config := server.Config("")
+	server.Close()
+
+	assert.Error(t, NewSMTP(config).Notify(Message{To: "user@example.com"}))
+}
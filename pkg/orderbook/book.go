@@ -0,0 +1,222 @@
+// Package orderbook mirrors BitMEX orderBookL2 tables into sorted local books.
+package orderbook
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/depth"
+)
+
+var (
+	ErrNotSynced      = errors.New("order book is waiting for partial")
+	ErrUnknownAction  = errors.New("unknown order book action")
+	ErrUnknownSide    = errors.New("unknown order book side")
+	ErrUnknownLevel   = errors.New("order book level does not exist")
+	ErrDuplicateLevel = errors.New("order book level already exists")
+	ErrCrossedBook    = errors.New("order book is crossed")
+)
+
+type levelRef struct {
+	side  string
+	price float64
+}
+
+// Book is an order book of a single symbol.
+// Bids are kept sorted by price descending and asks ascending, so the best levels are always first.
+type Book struct {
+	symbol    string
+	synced    bool
+	bids      []depth.Level
+	asks      []depth.Level
+	index     map[int64]levelRef
+	timestamp time.Time
+}
+
+func NewBook(symbol string) *Book {
+	return &Book{
+		symbol: symbol,
+		index:  make(map[int64]levelRef),
+	}
+}
+
+func (b *Book) Synced() bool {
+	return b.synced
+}
+
+// Apply applies table action. Any inconsistency resets the book, it stays unsynced until next partial.
+func (b *Book) Apply(action string, records []bitmex.OrderBookL2Record) error {
+	if action != bitmex.PartialAction && !b.synced {
+		return ErrNotSynced
+	}
+
+	err := b.apply(action, records)
+	if err == nil {
+		err = b.checkCrossed()
+	}
+
+	if err != nil {
+		b.reset()
+
+		return err
+	}
+
+	b.synced = true
+
+	for _, record := range records {
+		if record.Timestamp.After(b.timestamp) {
+			b.timestamp = record.Timestamp
+		}
+	}
+
+	return nil
+}
+
+// Snapshot returns up to limit best levels of each side, zero limit means the whole book.
+func (b *Book) Snapshot(limit int) depth.Snapshot {
+	bids, asks := b.bids, b.asks
+	if limit > 0 {
+		bids = bids[:min(limit, len(bids))]
+		asks = asks[:min(limit, len(asks))]
+	}
+
+	return depth.Snapshot{
+		Symbol:    b.symbol,
+		Bids:      slices.Clone(bids),
+		Asks:      slices.Clone(asks),
+		Timestamp: b.timestamp,
+	}
+}
+
+//nolint:cyclop
+func (b *Book) apply(action string, records []bitmex.OrderBookL2Record) error {
+	switch action {
+	case bitmex.PartialAction:
+		b.reset()
+
+		for _, record := range records {
+			if err := b.insert(record); err != nil {
+				return err
+			}
+		}
+	case bitmex.InsertAction:
+		for _, record := range records {
+			if err := b.insert(record); err != nil {
+				return err
+			}
+		}
+	case bitmex.UpdateAction:
+		for _, record := range records {
+			if err := b.update(record); err != nil {
+				return err
+			}
+		}
+	case bitmex.DeleteAction:
+		for _, record := range records {
+			if err := b.delete(record); err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrUnknownAction
+	}
+
+	return nil
+}
+
+func (b *Book) insert(record bitmex.OrderBookL2Record) error {
+	if _, ok := b.index[record.ID]; ok {
+		return ErrDuplicateLevel
+	}
+
+	levels, err := b.side(record.Side)
+	if err != nil {
+		return err
+	}
+
+	i := b.search(record.Side, record.Price)
+	*levels = slices.Insert(*levels, i, depth.Level{ID: record.ID, Price: record.Price, Size: record.Size})
+	b.index[record.ID] = levelRef{side: record.Side, price: record.Price}
+
+	return nil
+}
+
+func (b *Book) update(record bitmex.OrderBookL2Record) error {
+	levels, i, err := b.find(record.ID)
+	if err != nil {
+		return err
+	}
+
+	(*levels)[i].Size = record.Size
+
+	return nil
+}
+
+func (b *Book) delete(record bitmex.OrderBookL2Record) error {
+	levels, i, err := b.find(record.ID)
+	if err != nil {
+		return err
+	}
+
+	*levels = slices.Delete(*levels, i, i+1)
+	delete(b.index, record.ID)
+
+	return nil
+}
+
+func (b *Book) find(id int64) (*[]depth.Level, int, error) {
+	ref, ok := b.index[id]
+	if !ok {
+		return nil, 0, ErrUnknownLevel
+	}
+
+	levels, err := b.side(ref.side)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	i := b.search(ref.side, ref.price)
+	if i >= len(*levels) || (*levels)[i].ID != id {
+		return nil, 0, ErrUnknownLevel
+	}
+
+	return levels, i, nil
+}
+
+// search returns position of price in side keeping the side sorted.
+func (b *Book) search(side string, price float64) int {
+	if side == bitmex.BuySide {
+		return sort.Search(len(b.bids), func(i int) bool { return b.bids[i].Price <= price })
+	}
+
+	return sort.Search(len(b.asks), func(i int) bool { return b.asks[i].Price >= price })
+}
+
+func (b *Book) side(side string) (*[]depth.Level, error) {
+	switch side {
+	case bitmex.BuySide:
+		return &b.bids, nil
+	case bitmex.SellSide:
+		return &b.asks, nil
+	default:
+		return nil, ErrUnknownSide
+	}
+}
+
+func (b *Book) checkCrossed() error {
+	if len(b.bids) != 0 && len(b.asks) != 0 && b.bids[0].Price >= b.asks[0].Price {
+		return ErrCrossedBook
+	}
+
+	return nil
+}
+
+func (b *Book) reset() {
+	b.synced = false
+	b.bids = nil
+	b.asks = nil
+	b.index = make(map[int64]levelRef)
+}
@@ -0,0 +1,127 @@
+package orderbook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/depth"
+)
+
+func level(id int64, side string, price float64, size int64) bitmex.OrderBookL2Record {
+	return bitmex.OrderBookL2Record{Symbol: "XBTUSD", ID: id, Side: side, Price: price, Size: size}
+}
+
+func partial() []bitmex.OrderBookL2Record {
+	return []bitmex.OrderBookL2Record{
+		level(1, bitmex.SellSide, 101, 10),
+		level(2, bitmex.BuySide, 99, 20),
+		level(3, bitmex.SellSide, 103, 30),
+		level(4, bitmex.BuySide, 98, 40),
+	}
+}
+
+func TestBook_Apply(t *testing.T) {
+	book := NewBook("XBTUSD")
+
+	assert.ErrorIs(t, book.Apply(bitmex.UpdateAction, []bitmex.OrderBookL2Record{level(1, bitmex.SellSide, 0, 5)}),
+		ErrNotSynced)
+
+	require.NoError(t, book.Apply(bitmex.PartialAction, partial()))
+	require.NoError(t, book.Apply(bitmex.InsertAction, []bitmex.OrderBookL2Record{
+		level(5, bitmex.SellSide, 102, 50),
+		level(6, bitmex.BuySide, 100, 60),
+	}))
+	require.NoError(t, book.Apply(bitmex.UpdateAction, []bitmex.OrderBookL2Record{level(4, bitmex.BuySide, 0, 45)}))
+	require.NoError(t, book.Apply(bitmex.DeleteAction, []bitmex.OrderBookL2Record{level(1, bitmex.SellSide, 0, 0)}))
+
+	assert.Equal(t, depth.Snapshot{
+		Symbol: "XBTUSD",
+		Bids:   []depth.Level{{ID: 6, Price: 100, Size: 60}, {ID: 2, Price: 99, Size: 20}, {ID: 4, Price: 98, Size: 45}},
+		Asks:   []depth.Level{{ID: 5, Price: 102, Size: 50}, {ID: 3, Price: 103, Size: 30}},
+	}, book.Snapshot(0))
+
+	assert.Equal(t, depth.Snapshot{
+		Symbol: "XBTUSD",
+		Bids:   []depth.Level{{ID: 6, Price: 100, Size: 60}},
+		Asks:   []depth.Level{{ID: 5, Price: 102, Size: 50}},
+	}, book.Snapshot(1))
+}
+
+func TestBook_ApplyOutOfSync(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Action  string
+		Records []bitmex.OrderBookL2Record
+		Err     error
+	}{
+		{
+			Name:    "UpdateUnknownLevel",
+			Action:  bitmex.UpdateAction,
+			Records: []bitmex.OrderBookL2Record{level(10, bitmex.BuySide, 0, 1)},
+			Err:     ErrUnknownLevel,
+		},
+		{
+			Name:    "DeleteUnknownLevel",
+			Action:  bitmex.DeleteAction,
+			Records: []bitmex.OrderBookL2Record{level(10, bitmex.BuySide, 0, 0)},
+			Err:     ErrUnknownLevel,
+		},
+		{
+			Name:    "InsertExistingLevel",
+			Action:  bitmex.InsertAction,
+			Records: []bitmex.OrderBookL2Record{level(1, bitmex.SellSide, 101, 1)},
+			Err:     ErrDuplicateLevel,
+		},
+		{
+			Name:    "InsertCrossingLevel",
+			Action:  bitmex.InsertAction,
+			Records: []bitmex.OrderBookL2Record{level(10, bitmex.BuySide, 102, 1)},
+			Err:     ErrCrossedBook,
+		},
+		{
+			Name:    "UnknownSide",
+			Action:  bitmex.InsertAction,
+			Records: []bitmex.OrderBookL2Record{level(10, "", 100, 1)},
+			Err:     ErrUnknownSide,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			book := NewBook("XBTUSD")
+			require.NoError(t, book.Apply(bitmex.PartialAction, partial()))
+
+			assert.ErrorIs(t, book.Apply(test.Action, test.Records), test.Err)
+			assert.False(t, book.Synced())
+			assert.Empty(t, book.Snapshot(0).Bids)
+
+			require.NoError(t, book.Apply(bitmex.PartialAction, partial()))
+			assert.True(t, book.Synced())
+		})
+	}
+}
+
+func TestManager_Apply(t *testing.T) {
+	manager := NewManager()
+
+	records := append(partial(), bitmex.OrderBookL2Record{Symbol: "ETHUSD", ID: 1, Side: bitmex.BuySide, Price: 10, Size: 1})
+	updated, resync := manager.Apply(bitmex.PartialAction, records)
+	assert.Equal(t, []string{"XBTUSD", "ETHUSD"}, updated)
+	assert.Empty(t, resync)
+
+	updated, resync = manager.Apply(bitmex.UpdateAction, []bitmex.OrderBookL2Record{
+		{Symbol: "ETHUSD", ID: 2, Side: bitmex.BuySide, Size: 1},
+	})
+	assert.Empty(t, updated)
+	assert.Equal(t, []string{"ETHUSD"}, resync)
+
+	_, ok := manager.Snapshot("ETHUSD", 10)
+	assert.False(t, ok)
+
+	snapshot, ok := manager.Snapshot("XBTUSD", 10)
+	assert.True(t, ok)
+	assert.Len(t, snapshot.Bids, 2)
+}
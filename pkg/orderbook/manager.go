@@ -0,0 +1,78 @@
+package orderbook
+
+import (
+	"errors"
+	"sync"
+
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/depth"
+)
+
+// Manager keeps books of every mirrored symbol.
+type Manager struct {
+	books map[string]*Book
+
+	mu sync.RWMutex
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		books: make(map[string]*Book),
+	}
+}
+
+// Apply applies table action to the books of its symbols.
+// It returns symbols whose books changed and symbols that went out of sync and need new partial.
+func (m *Manager) Apply(action string, records []bitmex.OrderBookL2Record) ([]string, []string) {
+	symbols := make([]string, 0, 1)
+	bySymbol := make(map[string][]bitmex.OrderBookL2Record)
+
+	for _, record := range records {
+		if _, ok := bySymbol[record.Symbol]; !ok {
+			symbols = append(symbols, record.Symbol)
+		}
+
+		bySymbol[record.Symbol] = append(bySymbol[record.Symbol], record)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updated := make([]string, 0, len(symbols))
+	resync := make([]string, 0)
+
+	for _, symbol := range symbols {
+		book, ok := m.books[symbol]
+		if !ok {
+			book = NewBook(symbol)
+			m.books[symbol] = book
+		}
+
+		err := book.Apply(action, bySymbol[symbol])
+
+		switch {
+		case err == nil:
+			updated = append(updated, symbol)
+		case errors.Is(err, ErrNotSynced):
+		default:
+			logger.Errorf("order book "+symbol, err)
+			resync = append(resync, symbol)
+		}
+	}
+
+	return updated, resync
+}
+
+// Snapshot returns top levels of symbol book, false is returned until the book is synced.
+func (m *Manager) Snapshot(symbol string, limit int) (depth.Snapshot, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, ok := m.books[symbol]
+	if !ok || !book.Synced() {
+		return depth.Snapshot{}, false
+	}
+
+	return book.Snapshot(limit), true
+}
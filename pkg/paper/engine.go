@@ -0,0 +1,226 @@
+// Package paper matches simulated orders of users against the trade stream.
+package paper
+
+import (
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+)
+
+// Execution is fill of paper order by a trade, Order is the order after the fill and Previous is the order before it.
+type Execution struct {
+	Previous model.PaperOrder
+	Order    model.PaperOrder
+	Fill     model.PaperFill
+}
+
+// Engine keeps open paper orders by symbol and fills them in the order they were put.
+// Size of the trade is shared by the orders it fills, trade without size fills every matching order in full.
+// Order whose fill failed to settle is restored, executions of it made before are stale then.
+type Engine struct {
+	orders map[string][]*model.PaperOrder
+	// restored are restored orders by ID, the next execution of the order must follow their filled quantity
+	restored map[uuid.UUID]restored
+
+	mu sync.Mutex
+}
+
+// restored is the owner and the filled quantity of restored order.
+type restored struct {
+	userID uuid.UUID
+	filled float64
+}
+
+func NewEngine() *Engine {
+	return &Engine{
+		orders:   make(map[string][]*model.PaperOrder),
+		restored: make(map[uuid.UUID]restored),
+	}
+}
+
+// Load puts open orders.
+func (e *Engine) Load(orders []model.PaperOrder) {
+	for _, order := range orders {
+		e.Put(order)
+	}
+}
+
+// Put adds order or replaces order with the same ID, order which is not open is removed.
+func (e *Engine) Put(order model.PaperOrder) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.remove(func(o *model.PaperOrder) bool { return o.ID == order.ID })
+
+	if order.Open() {
+		e.orders[order.Symbol] = append(e.orders[order.Symbol], &order)
+	}
+}
+
+// Remove removes the order and reports whether it was open.
+func (e *Engine) Remove(id uuid.UUID) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.restored, id)
+
+	return e.remove(func(o *model.PaperOrder) bool { return o.ID == id }) > 0
+}
+
+// Restore puts the order of the execution back as it was before the execution, so the fill is made again
+// by the next matching trade.
+func (e *Engine) Restore(execution Execution) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order := execution.Previous
+	e.remove(func(o *model.PaperOrder) bool { return o.ID == order.ID })
+	e.orders[order.Symbol] = append(e.orders[order.Symbol], &order)
+	e.restored[order.ID] = restored{userID: order.UserID, filled: order.FilledQuantity}
+}
+
+// Settle reports whether the execution can be settled, execution of restored order is stale
+// unless it follows the filled quantity the order was restored to or the last execution settled since.
+func (e *Engine) Settle(execution Execution) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r, ok := e.restored[execution.Order.ID]
+	if !ok {
+		return true
+	}
+
+	if r.filled != execution.Previous.FilledQuantity {
+		return false
+	}
+
+	if execution.Order.Open() {
+		e.restored[execution.Order.ID] = restored{userID: r.userID, filled: execution.Order.FilledQuantity}
+	} else {
+		delete(e.restored, execution.Order.ID)
+	}
+
+	return true
+}
+
+// RemoveUser removes every order of the user.
+func (e *Engine) RemoveUser(userID uuid.UUID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.remove(func(o *model.PaperOrder) bool { return o.UserID == userID })
+
+	for id, r := range e.restored {
+		if r.userID == userID {
+			delete(e.restored, id)
+		}
+	}
+}
+
+// remove removes matching orders and returns the number of removed orders.
+func (e *Engine) remove(match func(o *model.PaperOrder) bool) int {
+	var removed int
+
+	for symbol, orders := range e.orders {
+		kept := orders[:0:0]
+
+		for _, o := range orders {
+			if match(o) {
+				removed++
+			} else {
+				kept = append(kept, o)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(e.orders, symbol)
+		} else {
+			e.orders[symbol] = kept
+		}
+	}
+
+	return removed
+}
+
+// Add fills orders of the trade symbol which match the trade price and returns the fills in the order
+// the orders were put. Filled orders are removed from the engine.
+func (e *Engine) Add(record bitmex.TradeDataRecord) []Execution {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var executions []Execution
+
+	available := float64(record.Size)
+	orders := e.orders[record.Symbol][:0:0]
+
+	for _, o := range e.orders[record.Symbol] {
+		if !matches(o, record.Price) || (record.Size > 0 && available <= 0) {
+			orders = append(orders, o)
+
+			continue
+		}
+
+		quantity := o.Remaining()
+		if record.Size > 0 {
+			quantity = min(quantity, available)
+			available -= quantity
+		}
+
+		fill := model.PaperFill{
+			OrderID:   o.ID,
+			UserID:    o.UserID,
+			Symbol:    o.Symbol,
+			Side:      o.Side,
+			Quantity:  quantity,
+			Price:     record.Price,
+			Timestamp: record.Timestamp,
+		}
+
+		previous := *o
+		o.Apply(&fill)
+
+		executions = append(executions, Execution{Previous: previous, Order: *o, Fill: fill})
+
+		if o.Open() {
+			orders = append(orders, o)
+		}
+	}
+
+	if len(orders) == 0 {
+		delete(e.orders, record.Symbol)
+	} else {
+		e.orders[record.Symbol] = orders
+	}
+
+	return executions
+}
+
+// matches reports whether the order fills at the trade price. Partially filled stop order has been triggered,
+// so it fills as market order.
+func matches(order *model.PaperOrder, price float64) bool {
+	switch order.Type {
+	case model.MarketOrder:
+		return true
+	case model.LimitOrder:
+		if order.Side == model.Buy {
+			return price <= order.Price
+		}
+
+		return price >= order.Price
+	case model.StopOrder:
+		if order.FilledQuantity > 0 {
+			return true
+		}
+
+		if order.Side == model.Buy {
+			return price >= order.StopPrice
+		}
+
+		return price <= order.StopPrice
+	}
+
+	return false
+}
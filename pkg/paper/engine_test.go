@@ -0,0 +1,160 @@
+package paper
+
+import (
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func trade(symbol string, price float64, size int64) bitmex.TradeDataRecord {
+	return bitmex.TradeDataRecord{Symbol: symbol, Price: price, Size: size, Timestamp: start}
+}
+
+func order(side model.OrderSide, orderType model.OrderType, quantity float64) model.PaperOrder {
+	return model.PaperOrder{
+		ID:       uuid.NewV4(),
+		UserID:   uuid.NewV4(),
+		Symbol:   "XBTUSD",
+		Side:     side,
+		Type:     orderType,
+		Quantity: quantity,
+		Status:   model.OrderNew,
+	}
+}
+
+func TestEngine_Market(t *testing.T) {
+	engine := NewEngine()
+	market := order(model.Buy, model.MarketOrder, 2)
+	engine.Put(market)
+
+	assert.Empty(t, engine.Add(trade("ETHUSD", 3000, 0)))
+
+	executions := engine.Add(trade("XBTUSD", 70000, 0))
+	require.Len(t, executions, 1)
+	assert.Equal(t, market, executions[0].Previous)
+	assert.Equal(t, model.OrderFilled, executions[0].Order.Status)
+	assert.InDelta(t, 2, executions[0].Order.FilledQuantity, 0)
+	assert.InDelta(t, 70000, executions[0].Order.AvgFillPrice, 0)
+	assert.Equal(t, model.PaperFill{
+		OrderID:   market.ID,
+		UserID:    market.UserID,
+		Symbol:    "XBTUSD",
+		Side:      model.Buy,
+		Quantity:  2,
+		Price:     70000,
+		Timestamp: start,
+	}, executions[0].Fill)
+
+	// filled order is removed
+	assert.Empty(t, engine.Add(trade("XBTUSD", 70000, 0)))
+}
+
+func TestEngine_Limit(t *testing.T) {
+	engine := NewEngine()
+	buy := order(model.Buy, model.LimitOrder, 1)
+	buy.Price = 69000
+	sell := order(model.Sell, model.LimitOrder, 1)
+	sell.Price = 71000
+	engine.Load([]model.PaperOrder{buy, sell})
+
+	assert.Empty(t, engine.Add(trade("XBTUSD", 70000, 0)))
+
+	executions := engine.Add(trade("XBTUSD", 68500, 0))
+	require.Len(t, executions, 1)
+	assert.Equal(t, buy.ID, executions[0].Order.ID)
+	assert.InDelta(t, 68500, executions[0].Fill.Price, 0)
+
+	executions = engine.Add(trade("XBTUSD", 71000, 0))
+	require.Len(t, executions, 1)
+	assert.Equal(t, sell.ID, executions[0].Order.ID)
+}
+
+func TestEngine_Stop(t *testing.T) {
+	engine := NewEngine()
+	stop := order(model.Sell, model.StopOrder, 3)
+	stop.StopPrice = 60000
+	engine.Put(stop)
+
+	assert.Empty(t, engine.Add(trade("XBTUSD", 61000, 0)))
+
+	// triggered stop fills as market order by the size of trades
+	executions := engine.Add(trade("XBTUSD", 59000, 2))
+	require.Len(t, executions, 1)
+	assert.Equal(t, model.OrderPartiallyFilled, executions[0].Order.Status)
+	assert.InDelta(t, 2, executions[0].Fill.Quantity, 0)
+
+	executions = engine.Add(trade("XBTUSD", 62000, 5))
+	require.Len(t, executions, 1)
+	assert.Equal(t, model.OrderFilled, executions[0].Order.Status)
+	assert.InDelta(t, 1, executions[0].Fill.Quantity, 0)
+	assert.InDelta(t, (2*59000+62000)/3.0, executions[0].Order.AvgFillPrice, 0.001)
+}
+
+func TestEngine_SharedSize(t *testing.T) {
+	engine := NewEngine()
+	first := order(model.Buy, model.MarketOrder, 2)
+	second := order(model.Buy, model.MarketOrder, 2)
+	engine.Load([]model.PaperOrder{first, second})
+
+	executions := engine.Add(trade("XBTUSD", 70000, 3))
+	require.Len(t, executions, 2)
+	assert.InDelta(t, 2, executions[0].Fill.Quantity, 0)
+	assert.Equal(t, second.ID, executions[1].Order.ID)
+	assert.InDelta(t, 1, executions[1].Fill.Quantity, 0)
+
+	assert.True(t, engine.Remove(second.ID))
+	assert.False(t, engine.Remove(second.ID))
+	assert.Empty(t, engine.Add(trade("XBTUSD", 70000, 3)))
+}
+
+func TestEngine_RemoveUser(t *testing.T) {
+	engine := NewEngine()
+	first := order(model.Buy, model.MarketOrder, 1)
+	second := order(model.Buy, model.MarketOrder, 1)
+	engine.Load([]model.PaperOrder{first, second})
+
+	engine.RemoveUser(first.UserID)
+
+	executions := engine.Add(trade("XBTUSD", 70000, 0))
+	require.Len(t, executions, 1)
+	assert.Equal(t, second.ID, executions[0].Order.ID)
+
+	// restored orders of the user are forgotten
+	engine.Restore(executions[0])
+	engine.RemoveUser(second.UserID)
+	assert.Empty(t, engine.restored)
+	assert.Empty(t, engine.Add(trade("XBTUSD", 70000, 0)))
+}
+
+func TestEngine_Restore(t *testing.T) {
+	engine := NewEngine()
+	limit := order(model.Buy, model.LimitOrder, 3)
+	limit.Price = 70000
+	engine.Put(limit)
+
+	first := engine.Add(trade("XBTUSD", 70000, 1))
+	second := engine.Add(trade("XBTUSD", 70000, 1))
+	require.Len(t, first, 1)
+	require.Len(t, second, 1)
+
+	// first fill failed to settle, the fill after it is stale
+	assert.True(t, engine.Settle(first[0]))
+	engine.Restore(first[0])
+	assert.False(t, engine.Settle(second[0]))
+
+	executions := engine.Add(trade("XBTUSD", 70000, 0))
+	require.Len(t, executions, 1)
+	assert.InDelta(t, 3, executions[0].Fill.Quantity, 0)
+	assert.True(t, engine.Settle(executions[0]))
+
+	// filled order is not tracked anymore
+	assert.Empty(t, engine.restored)
+}
@@ -0,0 +1,94 @@
+// Package replay keeps recent frames of a stream so a reconnecting client can resume after the last frame it got.
+package replay
+
+import "sync"
+
+// Event is frame of the stream, IDs grow by one starting with 1.
+type Event struct {
+	ID   uint64
+	Data []byte
+}
+
+// Buffer is ring of the last size events with any number of readers.
+type Buffer struct {
+	size   int
+	events []Event
+	lastID uint64
+	closed bool
+	notify chan struct{}
+
+	mu sync.Mutex
+}
+
+func New(size int) *Buffer {
+	return &Buffer{
+		size:   size,
+		events: make([]Event, 0, size),
+		notify: make(chan struct{}),
+	}
+}
+
+// Append stores data as the next event dropping the oldest one when the buffer is full.
+func (b *Buffer) Append(data []byte) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+
+	if len(b.events) == b.size {
+		b.events = b.events[1:]
+	}
+	b.events = append(b.events, Event{ID: b.lastID, Data: data})
+
+	if !b.closed {
+		close(b.notify)
+		b.notify = make(chan struct{})
+	}
+
+	return b.lastID
+}
+
+// After returns buffered events following the event id, missed is true when some of them are dropped already.
+func (b *Buffer) After(id uint64) ([]Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.events) == 0 || id >= b.lastID {
+		return nil, false
+	}
+
+	first := b.events[0].ID
+	if id+1 < first {
+		return append([]Event{}, b.events...), true
+	}
+
+	return append([]Event{}, b.events[id+1-first:]...), false
+}
+
+// Wait returns channel closed by the next Append or by Close, it is closed already once the buffer is closed.
+func (b *Buffer) Wait() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.notify
+}
+
+// Close wakes every reader, buffered events stay readable.
+func (b *Buffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	close(b.notify)
+}
+
+func (b *Buffer) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.closed
+}
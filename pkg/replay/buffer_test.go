@@ -0,0 +1,82 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func data(events []Event) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, string(event.Data))
+	}
+
+	return result
+}
+
+func TestBuffer_After(t *testing.T) {
+	buffer := New(3)
+
+	events, missed := buffer.After(0)
+	assert.Empty(t, events)
+	assert.False(t, missed)
+
+	for _, frame := range []string{"1", "2", "3", "4"} {
+		buffer.Append([]byte(frame))
+	}
+
+	events, missed = buffer.After(2)
+	assert.Equal(t, []string{"3", "4"}, data(events))
+	assert.Equal(t, uint64(3), events[0].ID)
+	assert.False(t, missed)
+
+	events, missed = buffer.After(1)
+	assert.Equal(t, []string{"2", "3", "4"}, data(events))
+	assert.False(t, missed)
+
+	events, missed = buffer.After(0)
+	assert.Equal(t, []string{"2", "3", "4"}, data(events))
+	assert.True(t, missed)
+
+	events, missed = buffer.After(4)
+	assert.Empty(t, events)
+	assert.False(t, missed)
+}
+
+func TestBuffer_Wait(t *testing.T) {
+	buffer := New(3)
+
+	wait := buffer.Wait()
+	select {
+	case <-wait:
+		t.Fatal("Wait is released before Append")
+	default:
+	}
+
+	buffer.Append([]byte("1"))
+
+	select {
+	case <-wait:
+	case <-time.After(time.Second):
+		t.Fatal("Wait was not released by Append")
+	}
+
+	wait = buffer.Wait()
+	buffer.Close()
+
+	select {
+	case <-wait:
+	case <-time.After(time.Second):
+		t.Fatal("Wait was not released by Close")
+	}
+
+	assert.True(t, buffer.Closed())
+
+	select {
+	case <-buffer.Wait():
+	default:
+		t.Fatal("Wait of closed buffer must not block")
+	}
+}
@@ -0,0 +1,216 @@
+// Package sendqueue buffers frames of one WebSocket connection between producers and its writer goroutine.
+package sendqueue
+
+import (
+	"sync"
+	"time"
+)
+
+// Policy decides what happens to a message pushed into full queue.
+type Policy string
+
+const (
+	// DropOldest removes the oldest queued message to make room.
+	DropOldest Policy = "dropOldest"
+	// Conflate replaces queued message of the same key, e.g. previous quote of the symbol,
+	// messages without key or without queued pair fall back to DropOldest.
+	Conflate Policy = "conflate"
+	// Disconnect closes the queue, the writer then evicts the connection.
+	Disconnect Policy = "disconnect"
+)
+
+func (p Policy) Valid() bool {
+	switch p {
+	case DropOldest, Conflate, Disconnect:
+		return true
+	}
+
+	return false
+}
+
+// Message is one frame, Key groups frames that Conflate may merge, empty Key is never merged.
+type Message struct {
+	Key        string
+	Data       []byte
+	EnqueuedAt time.Time
+}
+
+// Stats describes queue of the connection, Lag is age of the oldest queued message
+// and MaxLag is the longest time a sent message has waited.
+type Stats struct {
+	Queued    int
+	Capacity  int
+	Sent      uint64
+	Dropped   uint64
+	Conflated uint64
+	Lag       time.Duration
+	MaxLag    time.Duration
+}
+
+// Queue is bounded FIFO with single consumer.
+type Queue struct {
+	size     int
+	policy   Policy
+	messages []Message
+
+	closed     bool
+	overflowed bool
+	done       chan struct{}
+	notify     chan struct{}
+
+	sent      uint64
+	dropped   uint64
+	conflated uint64
+	maxLag    time.Duration
+
+	mu sync.Mutex
+}
+
+func New(size int, policy Policy) *Queue {
+	return &Queue{
+		size:     size,
+		policy:   policy,
+		messages: make([]Message, 0, size),
+		done:     make(chan struct{}),
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+// Push enqueues message, it returns false when the queue is closed or Disconnect policy has just closed it.
+func (q *Queue) Push(key string, data []byte) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+
+	message := Message{Key: key, Data: data, EnqueuedAt: time.Now()}
+
+	if len(q.messages) >= q.size {
+		switch q.policy {
+		case Disconnect:
+			q.overflowed = true
+			q.closeLocked()
+
+			return false
+		case Conflate:
+			if q.conflateLocked(message) {
+				return true
+			}
+
+			q.dropOldestLocked()
+		default:
+			q.dropOldestLocked()
+		}
+	}
+
+	q.messages = append(q.messages, message)
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+// conflateLocked replaces data of queued message with the same key keeping its place in the queue.
+func (q *Queue) conflateLocked(message Message) bool {
+	if message.Key == "" {
+		return false
+	}
+
+	for i := len(q.messages) - 1; i >= 0; i-- {
+		if q.messages[i].Key == message.Key {
+			q.messages[i].Data = message.Data
+			q.conflated++
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func (q *Queue) dropOldestLocked() {
+	q.messages = q.messages[1:]
+	q.dropped++
+}
+
+// Pop blocks until message is queued, it returns false once the queue is closed.
+func (q *Queue) Pop() (Message, bool) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+
+			return Message{}, false
+		}
+
+		if len(q.messages) > 0 {
+			message := q.messages[0]
+			q.messages = q.messages[1:]
+			q.sent++
+
+			if lag := time.Since(message.EnqueuedAt); lag > q.maxLag {
+				q.maxLag = lag
+			}
+			q.mu.Unlock()
+
+			return message, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-q.done:
+		}
+	}
+}
+
+// Close drops queued messages and releases Pop.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closeLocked()
+}
+
+func (q *Queue) closeLocked() {
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	q.messages = nil
+	close(q.done)
+}
+
+// Overflowed reports that the queue was closed by Disconnect policy.
+func (q *Queue) Overflowed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.overflowed
+}
+
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := Stats{
+		Queued:    len(q.messages),
+		Capacity:  q.size,
+		Sent:      q.sent,
+		Dropped:   q.dropped,
+		Conflated: q.conflated,
+		MaxLag:    q.maxLag,
+	}
+
+	if len(q.messages) > 0 {
+		stats.Lag = time.Since(q.messages[0].EnqueuedAt)
+	}
+
+	return stats
+}
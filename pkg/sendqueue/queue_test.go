@@ -0,0 +1,116 @@
+package sendqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func popAll(t *testing.T, q *Queue) []string {
+	t.Helper()
+
+	var data []string
+	for q.Stats().Queued > 0 {
+		message, ok := q.Pop()
+		assert.True(t, ok)
+		data = append(data, string(message.Data))
+	}
+
+	return data
+}
+
+func TestQueue_DropOldest(t *testing.T) {
+	q := New(2, DropOldest)
+
+	assert.True(t, q.Push("trade:XBTUSD", []byte("1")))
+	assert.True(t, q.Push("trade:XBTUSD", []byte("2")))
+	assert.True(t, q.Push("trade:XBTUSD", []byte("3")))
+
+	assert.Equal(t, []string{"2", "3"}, popAll(t, q))
+
+	stats := q.Stats()
+	assert.Equal(t, uint64(2), stats.Sent)
+	assert.Equal(t, uint64(1), stats.Dropped)
+	assert.Equal(t, 2, stats.Capacity)
+}
+
+func TestQueue_Conflate(t *testing.T) {
+	q := New(3, Conflate)
+
+	assert.True(t, q.Push("quote:XBTUSD", []byte("q1")))
+	assert.True(t, q.Push("quote:ETHUSD", []byte("e1")))
+	assert.True(t, q.Push("", []byte("ack")))
+	assert.True(t, q.Push("quote:XBTUSD", []byte("q2")))
+	assert.True(t, q.Push("", []byte("status")))
+
+	assert.Equal(t, []string{"e1", "ack", "status"}, popAll(t, q))
+
+	stats := q.Stats()
+	assert.Equal(t, uint64(1), stats.Conflated)
+	assert.Equal(t, uint64(1), stats.Dropped)
+}
+
+func TestQueue_Disconnect(t *testing.T) {
+	q := New(1, Disconnect)
+
+	assert.True(t, q.Push("trade:XBTUSD", []byte("1")))
+	assert.False(t, q.Overflowed())
+	assert.False(t, q.Push("trade:XBTUSD", []byte("2")))
+	assert.True(t, q.Overflowed())
+
+	_, ok := q.Pop()
+	assert.False(t, ok)
+	assert.False(t, q.Push("trade:XBTUSD", []byte("3")))
+}
+
+func TestQueue_PopWaits(t *testing.T) {
+	q := New(1, DropOldest)
+
+	popped := make(chan string)
+	go func() {
+		message, _ := q.Pop()
+		popped <- string(message.Data)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Push("", []byte("1"))
+
+	select {
+	case data := <-popped:
+		assert.Equal(t, "1", data)
+	case <-time.After(time.Second):
+		t.Fatal("Pop was not released by Push")
+	}
+
+	closed := make(chan bool)
+	go func() {
+		_, ok := q.Pop()
+		closed <- ok
+	}()
+
+	q.Close()
+
+	select {
+	case ok := <-closed:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("Pop was not released by Close")
+	}
+}
+
+func TestQueue_Lag(t *testing.T) {
+	q := New(2, DropOldest)
+
+	q.Push("", []byte("1"))
+	time.Sleep(5 * time.Millisecond)
+
+	assert.GreaterOrEqual(t, q.Stats().Lag, 5*time.Millisecond)
+
+	_, ok := q.Pop()
+	assert.True(t, ok)
+
+	stats := q.Stats()
+	assert.Zero(t, stats.Lag)
+	assert.GreaterOrEqual(t, stats.MaxLag, 5*time.Millisecond)
+}
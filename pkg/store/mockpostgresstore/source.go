@@ -1,4 +1,4 @@
 package mockpostgresstore
 
 //nolint:lll
-//go:generate mockgen -destination=./store.go -package=mockpostgresstore bitmex-api/pkg/store UserRepository,AuthRepository
+//go:generate mockgen -destination=./store.go -package=mockpostgresstore bitmex-api/pkg/store UserRepository,AuthRepository,TradeRepository,AlertRepository,WebhookRepository,PaperRepository,WatchlistRepository
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: bitmex-api/pkg/store (interfaces: UserRepository,AuthRepository)
+// Source: bitmex-api/pkg/store (interfaces: UserRepository,AuthRepository,TradeRepository,AlertRepository,WebhookRepository,PaperRepository,WatchlistRepository)
 
 // Package mockpostgresstore is a generated GoMock package.
 package mockpostgresstore
@@ -7,6 +7,7 @@ package mockpostgresstore
 import (
 	model "bitmex-api/pkg/model"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/satori/go.uuid"
@@ -65,6 +66,20 @@ func (mr *MockUserRepositoryMockRecorder) GetAll() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockUserRepository)(nil).GetAll))
 }
 
+// SetEmail mocks base method.
+func (m *MockUserRepository) SetEmail(arg0 uuid.UUID, arg1, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmail", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmail indicates an expected call of SetEmail.
+func (mr *MockUserRepositoryMockRecorder) SetEmail(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmail", reflect.TypeOf((*MockUserRepository)(nil).SetEmail), arg0, arg1, arg2, arg3)
+}
+
 // Update mocks base method.
 func (m *MockUserRepository) Update(arg0 *model.User) error {
 	m.ctrl.T.Helper()
@@ -79,6 +94,63 @@ func (mr *MockUserRepositoryMockRecorder) Update(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), arg0)
 }
 
+// UpdateEmailAlerts mocks base method.
+func (m *MockUserRepository) UpdateEmailAlerts(arg0 uuid.UUID, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmailAlerts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmailAlerts indicates an expected call of UpdateEmailAlerts.
+func (mr *MockUserRepositoryMockRecorder) UpdateEmailAlerts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmailAlerts", reflect.TypeOf((*MockUserRepository)(nil).UpdateEmailAlerts), arg0, arg1)
+}
+
+// UpdateMaxSessions mocks base method.
+func (m *MockUserRepository) UpdateMaxSessions(arg0 uuid.UUID, arg1 *int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMaxSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMaxSessions indicates an expected call of UpdateMaxSessions.
+func (mr *MockUserRepositoryMockRecorder) UpdateMaxSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMaxSessions", reflect.TypeOf((*MockUserRepository)(nil).UpdateMaxSessions), arg0, arg1)
+}
+
+// UpdateSubscriptions mocks base method.
+func (m *MockUserRepository) UpdateSubscriptions(arg0 *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubscriptions", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSubscriptions indicates an expected call of UpdateSubscriptions.
+func (mr *MockUserRepositoryMockRecorder) UpdateSubscriptions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubscriptions", reflect.TypeOf((*MockUserRepository)(nil).UpdateSubscriptions), arg0)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUserRepository) VerifyEmail(arg0 string, arg1 time.Time) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", arg0, arg1)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserRepositoryMockRecorder) VerifyEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserRepository)(nil).VerifyEmail), arg0, arg1)
+}
+
 // MockAuthRepository is a mock of AuthRepository interface.
 type MockAuthRepository struct {
 	ctrl     *gomock.Controller
@@ -173,3 +245,701 @@ func (mr *MockAuthRepositoryMockRecorder) GetByUsername(arg0 interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUsername", reflect.TypeOf((*MockAuthRepository)(nil).GetByUsername), arg0)
 }
+
+// MockTradeRepository is a mock of TradeRepository interface.
+type MockTradeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTradeRepositoryMockRecorder
+}
+
+// MockTradeRepositoryMockRecorder is the mock recorder for MockTradeRepository.
+type MockTradeRepositoryMockRecorder struct {
+	mock *MockTradeRepository
+}
+
+// NewMockTradeRepository creates a new mock instance.
+func NewMockTradeRepository(ctrl *gomock.Controller) *MockTradeRepository {
+	mock := &MockTradeRepository{ctrl: ctrl}
+	mock.recorder = &MockTradeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTradeRepository) EXPECT() *MockTradeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateBatch mocks base method.
+func (m *MockTradeRepository) CreateBatch(arg0 []model.Trade) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockTradeRepositoryMockRecorder) CreateBatch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockTradeRepository)(nil).CreateBatch), arg0)
+}
+
+// DeleteBefore mocks base method.
+func (m *MockTradeRepository) DeleteBefore(arg0 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBefore", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBefore indicates an expected call of DeleteBefore.
+func (mr *MockTradeRepositoryMockRecorder) DeleteBefore(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBefore", reflect.TypeOf((*MockTradeRepository)(nil).DeleteBefore), arg0)
+}
+
+// List mocks base method.
+func (m *MockTradeRepository) List(arg0 model.TradeQuery) ([]model.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].([]model.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockTradeRepositoryMockRecorder) List(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockTradeRepository)(nil).List), arg0)
+}
+
+// MockAlertRepository is a mock of AlertRepository interface.
+type MockAlertRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAlertRepositoryMockRecorder
+}
+
+// MockAlertRepositoryMockRecorder is the mock recorder for MockAlertRepository.
+type MockAlertRepositoryMockRecorder struct {
+	mock *MockAlertRepository
+}
+
+// NewMockAlertRepository creates a new mock instance.
+func NewMockAlertRepository(ctrl *gomock.Controller) *MockAlertRepository {
+	mock := &MockAlertRepository{ctrl: ctrl}
+	mock.recorder = &MockAlertRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAlertRepository) EXPECT() *MockAlertRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAlertRepository) Create(arg0 *model.Alert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAlertRepositoryMockRecorder) Create(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAlertRepository)(nil).Create), arg0)
+}
+
+// Delete mocks base method.
+func (m *MockAlertRepository) Delete(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAlertRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAlertRepository)(nil).Delete), arg0, arg1)
+}
+
+// Get mocks base method.
+func (m *MockAlertRepository) Get(arg0, arg1 uuid.UUID) (*model.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret0, _ := ret[0].(*model.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAlertRepositoryMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAlertRepository)(nil).Get), arg0, arg1)
+}
+
+// GetActive mocks base method.
+func (m *MockAlertRepository) GetActive() ([]model.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActive")
+	ret0, _ := ret[0].([]model.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActive indicates an expected call of GetActive.
+func (mr *MockAlertRepositoryMockRecorder) GetActive() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockAlertRepository)(nil).GetActive))
+}
+
+// List mocks base method.
+func (m *MockAlertRepository) List(arg0 uuid.UUID) ([]model.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].([]model.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAlertRepositoryMockRecorder) List(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAlertRepository)(nil).List), arg0)
+}
+
+// ListEvents mocks base method.
+func (m *MockAlertRepository) ListEvents(arg0 uuid.UUID, arg1 int) ([]model.AlertEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", arg0, arg1)
+	ret0, _ := ret[0].([]model.AlertEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockAlertRepositoryMockRecorder) ListEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockAlertRepository)(nil).ListEvents), arg0, arg1)
+}
+
+// Trigger mocks base method.
+func (m *MockAlertRepository) Trigger(arg0 *model.AlertEvent, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trigger", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Trigger indicates an expected call of Trigger.
+func (mr *MockAlertRepositoryMockRecorder) Trigger(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trigger", reflect.TypeOf((*MockAlertRepository)(nil).Trigger), arg0, arg1)
+}
+
+// Update mocks base method.
+func (m *MockAlertRepository) Update(arg0 *model.Alert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockAlertRepositoryMockRecorder) Update(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockAlertRepository)(nil).Update), arg0)
+}
+
+// MockWebhookRepository is a mock of WebhookRepository interface.
+type MockWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookRepositoryMockRecorder
+}
+
+// MockWebhookRepositoryMockRecorder is the mock recorder for MockWebhookRepository.
+type MockWebhookRepositoryMockRecorder struct {
+	mock *MockWebhookRepository
+}
+
+// NewMockWebhookRepository creates a new mock instance.
+func NewMockWebhookRepository(ctrl *gomock.Controller) *MockWebhookRepository {
+	mock := &MockWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookRepository) EXPECT() *MockWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebhookRepository) Create(arg0 *model.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhookRepositoryMockRecorder) Create(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookRepository)(nil).Create), arg0)
+}
+
+// CreateDelivery mocks base method.
+func (m *MockWebhookRepository) CreateDelivery(arg0 *model.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDelivery", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDelivery indicates an expected call of CreateDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) CreateDelivery(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).CreateDelivery), arg0)
+}
+
+// Delete mocks base method.
+func (m *MockWebhookRepository) Delete(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockWebhookRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWebhookRepository)(nil).Delete), arg0, arg1)
+}
+
+// Get mocks base method.
+func (m *MockWebhookRepository) Get(arg0, arg1 uuid.UUID) (*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret0, _ := ret[0].(*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockWebhookRepositoryMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockWebhookRepository)(nil).Get), arg0, arg1)
+}
+
+// GetActive mocks base method.
+func (m *MockWebhookRepository) GetActive() ([]model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActive")
+	ret0, _ := ret[0].([]model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActive indicates an expected call of GetActive.
+func (mr *MockWebhookRepositoryMockRecorder) GetActive() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockWebhookRepository)(nil).GetActive))
+}
+
+// GetDue mocks base method.
+func (m *MockWebhookRepository) GetDue(arg0 time.Time, arg1 int) ([]model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDue", arg0, arg1)
+	ret0, _ := ret[0].([]model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDue indicates an expected call of GetDue.
+func (mr *MockWebhookRepositoryMockRecorder) GetDue(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDue", reflect.TypeOf((*MockWebhookRepository)(nil).GetDue), arg0, arg1)
+}
+
+// List mocks base method.
+func (m *MockWebhookRepository) List(arg0 uuid.UUID) ([]model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].([]model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockWebhookRepositoryMockRecorder) List(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockWebhookRepository)(nil).List), arg0)
+}
+
+// ListDeadLetters mocks base method.
+func (m *MockWebhookRepository) ListDeadLetters(arg0 uuid.UUID, arg1 int) ([]model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeadLetters", arg0, arg1)
+	ret0, _ := ret[0].([]model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeadLetters indicates an expected call of ListDeadLetters.
+func (mr *MockWebhookRepositoryMockRecorder) ListDeadLetters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeadLetters", reflect.TypeOf((*MockWebhookRepository)(nil).ListDeadLetters), arg0, arg1)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockWebhookRepository) ListDeliveries(arg0, arg1 uuid.UUID, arg2 int) ([]model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) ListDeliveries(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).ListDeliveries), arg0, arg1, arg2)
+}
+
+// Update mocks base method.
+func (m *MockWebhookRepository) Update(arg0 *model.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWebhookRepositoryMockRecorder) Update(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWebhookRepository)(nil).Update), arg0)
+}
+
+// UpdateDelivery mocks base method.
+func (m *MockWebhookRepository) UpdateDelivery(arg0 *model.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDelivery", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDelivery indicates an expected call of UpdateDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) UpdateDelivery(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).UpdateDelivery), arg0)
+}
+
+// MockPaperRepository is a mock of PaperRepository interface.
+type MockPaperRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaperRepositoryMockRecorder
+}
+
+// MockPaperRepositoryMockRecorder is the mock recorder for MockPaperRepository.
+type MockPaperRepositoryMockRecorder struct {
+	mock *MockPaperRepository
+}
+
+// NewMockPaperRepository creates a new mock instance.
+func NewMockPaperRepository(ctrl *gomock.Controller) *MockPaperRepository {
+	mock := &MockPaperRepository{ctrl: ctrl}
+	mock.recorder = &MockPaperRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaperRepository) EXPECT() *MockPaperRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Account mocks base method.
+func (m *MockPaperRepository) Account(arg0 uuid.UUID, arg1 float64) (*model.PaperAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Account", arg0, arg1)
+	ret0, _ := ret[0].(*model.PaperAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Account indicates an expected call of Account.
+func (mr *MockPaperRepositoryMockRecorder) Account(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Account", reflect.TypeOf((*MockPaperRepository)(nil).Account), arg0, arg1)
+}
+
+// CloseOrder mocks base method.
+func (m *MockPaperRepository) CloseOrder(arg0 *model.PaperOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseOrder", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseOrder indicates an expected call of CloseOrder.
+func (mr *MockPaperRepositoryMockRecorder) CloseOrder(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseOrder", reflect.TypeOf((*MockPaperRepository)(nil).CloseOrder), arg0)
+}
+
+// CountOpenOrders mocks base method.
+func (m *MockPaperRepository) CountOpenOrders(arg0 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOpenOrders", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOpenOrders indicates an expected call of CountOpenOrders.
+func (mr *MockPaperRepositoryMockRecorder) CountOpenOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOpenOrders", reflect.TypeOf((*MockPaperRepository)(nil).CountOpenOrders), arg0)
+}
+
+// CreateOrder mocks base method.
+func (m *MockPaperRepository) CreateOrder(arg0 *model.PaperOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrder indicates an expected call of CreateOrder.
+func (mr *MockPaperRepositoryMockRecorder) CreateOrder(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockPaperRepository)(nil).CreateOrder), arg0)
+}
+
+// Fill mocks base method.
+func (m *MockPaperRepository) Fill(arg0 *model.PaperOrder, arg1 *model.PaperFill) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fill", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fill indicates an expected call of Fill.
+func (mr *MockPaperRepositoryMockRecorder) Fill(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fill", reflect.TypeOf((*MockPaperRepository)(nil).Fill), arg0, arg1)
+}
+
+// GetOpenOrders mocks base method.
+func (m *MockPaperRepository) GetOpenOrders() ([]model.PaperOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenOrders")
+	ret0, _ := ret[0].([]model.PaperOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenOrders indicates an expected call of GetOpenOrders.
+func (mr *MockPaperRepositoryMockRecorder) GetOpenOrders() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenOrders", reflect.TypeOf((*MockPaperRepository)(nil).GetOpenOrders))
+}
+
+// GetOrder mocks base method.
+func (m *MockPaperRepository) GetOrder(arg0, arg1 uuid.UUID) (*model.PaperOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", arg0, arg1)
+	ret0, _ := ret[0].(*model.PaperOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockPaperRepositoryMockRecorder) GetOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockPaperRepository)(nil).GetOrder), arg0, arg1)
+}
+
+// ListFills mocks base method.
+func (m *MockPaperRepository) ListFills(arg0 uuid.UUID, arg1 int) ([]model.PaperFill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFills", arg0, arg1)
+	ret0, _ := ret[0].([]model.PaperFill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFills indicates an expected call of ListFills.
+func (mr *MockPaperRepositoryMockRecorder) ListFills(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFills", reflect.TypeOf((*MockPaperRepository)(nil).ListFills), arg0, arg1)
+}
+
+// ListOrders mocks base method.
+func (m *MockPaperRepository) ListOrders(arg0 uuid.UUID, arg1 bool, arg2 int) ([]model.PaperOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.PaperOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockPaperRepositoryMockRecorder) ListOrders(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockPaperRepository)(nil).ListOrders), arg0, arg1, arg2)
+}
+
+// ListPositions mocks base method.
+func (m *MockPaperRepository) ListPositions(arg0 uuid.UUID) ([]model.PaperPosition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPositions", arg0)
+	ret0, _ := ret[0].([]model.PaperPosition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPositions indicates an expected call of ListPositions.
+func (mr *MockPaperRepositoryMockRecorder) ListPositions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPositions", reflect.TypeOf((*MockPaperRepository)(nil).ListPositions), arg0)
+}
+
+// Reset mocks base method.
+func (m *MockPaperRepository) Reset(arg0 uuid.UUID, arg1 float64, arg2 time.Time) ([]model.PaperOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.PaperOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockPaperRepositoryMockRecorder) Reset(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockPaperRepository)(nil).Reset), arg0, arg1, arg2)
+}
+
+// MockWatchlistRepository is a mock of WatchlistRepository interface.
+type MockWatchlistRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWatchlistRepositoryMockRecorder
+}
+
+// MockWatchlistRepositoryMockRecorder is the mock recorder for MockWatchlistRepository.
+type MockWatchlistRepositoryMockRecorder struct {
+	mock *MockWatchlistRepository
+}
+
+// NewMockWatchlistRepository creates a new mock instance.
+func NewMockWatchlistRepository(ctrl *gomock.Controller) *MockWatchlistRepository {
+	mock := &MockWatchlistRepository{ctrl: ctrl}
+	mock.recorder = &MockWatchlistRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWatchlistRepository) EXPECT() *MockWatchlistRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWatchlistRepository) Create(arg0 *model.Watchlist) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWatchlistRepositoryMockRecorder) Create(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWatchlistRepository)(nil).Create), arg0)
+}
+
+// Delete mocks base method.
+func (m *MockWatchlistRepository) Delete(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockWatchlistRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWatchlistRepository)(nil).Delete), arg0, arg1)
+}
+
+// Get mocks base method.
+func (m *MockWatchlistRepository) Get(arg0, arg1 uuid.UUID) (*model.Watchlist, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret0, _ := ret[0].(*model.Watchlist)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockWatchlistRepositoryMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockWatchlistRepository)(nil).Get), arg0, arg1)
+}
+
+// GetShared mocks base method.
+func (m *MockWatchlistRepository) GetShared(arg0 string) (*model.Watchlist, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShared", arg0)
+	ret0, _ := ret[0].(*model.Watchlist)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShared indicates an expected call of GetShared.
+func (mr *MockWatchlistRepositoryMockRecorder) GetShared(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShared", reflect.TypeOf((*MockWatchlistRepository)(nil).GetShared), arg0)
+}
+
+// List mocks base method.
+func (m *MockWatchlistRepository) List(arg0 uuid.UUID) ([]model.Watchlist, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].([]model.Watchlist)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockWatchlistRepositoryMockRecorder) List(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockWatchlistRepository)(nil).List), arg0)
+}
+
+// Reorder mocks base method.
+func (m *MockWatchlistRepository) Reorder(arg0 uuid.UUID, arg1 []uuid.UUID, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reorder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reorder indicates an expected call of Reorder.
+func (mr *MockWatchlistRepositoryMockRecorder) Reorder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reorder", reflect.TypeOf((*MockWatchlistRepository)(nil).Reorder), arg0, arg1, arg2)
+}
+
+// Update mocks base method.
+func (m *MockWatchlistRepository) Update(arg0 *model.Watchlist) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWatchlistRepositoryMockRecorder) Update(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWatchlistRepository)(nil).Update), arg0)
+}
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"time"
+
 	uuid "github.com/satori/go.uuid"
 
 	"bitmex-api/pkg/model"
@@ -10,6 +12,11 @@ type UserRepository interface {
 	Get(id uuid.UUID) (*model.User, error)
 	Update(user *model.User) error
 	GetAll() ([]*model.User, error)
+	UpdateSubscriptions(user *model.User) error
+	UpdateMaxSessions(id uuid.UUID, maxSessions *int) error
+	SetEmail(id uuid.UUID, email, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(tokenHash string, now time.Time) (*model.User, error)
+	UpdateEmailAlerts(id uuid.UUID, enabled bool) error
 }
 
 type AuthRepository interface {
@@ -19,3 +26,58 @@ type AuthRepository interface {
 	Delete(id uuid.UUID) error
 	ChangePassword(id uuid.UUID, pass string) error
 }
+
+type TradeRepository interface {
+	CreateBatch(trades []model.Trade) error
+	List(query model.TradeQuery) ([]model.Trade, error)
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type AlertRepository interface {
+	Create(alert *model.Alert) error
+	Get(userID, id uuid.UUID) (*model.Alert, error)
+	List(userID uuid.UUID) ([]model.Alert, error)
+	GetActive() ([]model.Alert, error)
+	Update(alert *model.Alert) error
+	Delete(userID, id uuid.UUID) error
+	Trigger(event *model.AlertEvent, deactivate bool) error
+	ListEvents(userID uuid.UUID, limit int) ([]model.AlertEvent, error)
+}
+
+type WebhookRepository interface {
+	Create(webhook *model.Webhook) error
+	Get(userID, id uuid.UUID) (*model.Webhook, error)
+	List(userID uuid.UUID) ([]model.Webhook, error)
+	GetActive() ([]model.Webhook, error)
+	Update(webhook *model.Webhook) error
+	Delete(userID, id uuid.UUID) error
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	GetDue(before time.Time, limit int) ([]model.WebhookDelivery, error)
+	ListDeliveries(userID, webhookID uuid.UUID, limit int) ([]model.WebhookDelivery, error)
+	ListDeadLetters(userID uuid.UUID, limit int) ([]model.WebhookDelivery, error)
+}
+
+type PaperRepository interface {
+	Account(userID uuid.UUID, initialBalance float64) (*model.PaperAccount, error)
+	CreateOrder(order *model.PaperOrder) error
+	GetOrder(userID, id uuid.UUID) (*model.PaperOrder, error)
+	ListOrders(userID uuid.UUID, open bool, limit int) ([]model.PaperOrder, error)
+	CountOpenOrders(userID uuid.UUID) (int64, error)
+	GetOpenOrders() ([]model.PaperOrder, error)
+	CloseOrder(order *model.PaperOrder) error
+	Fill(order *model.PaperOrder, fill *model.PaperFill) error
+	ListPositions(userID uuid.UUID) ([]model.PaperPosition, error)
+	ListFills(userID uuid.UUID, limit int) ([]model.PaperFill, error)
+	Reset(userID uuid.UUID, balance float64, at time.Time) ([]model.PaperOrder, error)
+}
+
+type WatchlistRepository interface {
+	Create(watchlist *model.Watchlist) error
+	Get(userID, id uuid.UUID) (*model.Watchlist, error)
+	GetShared(token string) (*model.Watchlist, error)
+	List(userID uuid.UUID) ([]model.Watchlist, error)
+	Update(watchlist *model.Watchlist) error
+	Reorder(userID uuid.UUID, ids []uuid.UUID, at time.Time) error
+	Delete(userID, id uuid.UUID) error
+}
@@ -0,0 +1,116 @@
+package postgresstore
+
+import (
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+
+	"bitmex-api/pkg/model"
+)
+
+type AlertRepository struct {
+	store *PostgresStore
+}
+
+func NewAlertRepository(store *PostgresStore) *AlertRepository {
+	return &AlertRepository{store: store}
+}
+
+func (r *AlertRepository) Create(alert *model.Alert) error {
+	return r.store.DB.Create(alert).Error
+}
+
+// Get returns alert of the user or model.ErrRecordNotFound.
+func (r *AlertRepository) Get(userID, id uuid.UUID) (*model.Alert, error) {
+	var alert *model.Alert
+
+	result := r.store.DB.Where("id=? and user_id=?", id, userID).Find(&alert)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, model.ErrRecordNotFound
+	}
+
+	return alert, nil
+}
+
+func (r *AlertRepository) List(userID uuid.UUID) ([]model.Alert, error) {
+	alerts := make([]model.Alert, 0)
+
+	err := r.store.DB.Where("user_id=?", userID).Order("created_at, id").Find(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// GetActive returns active alerts of all users.
+func (r *AlertRepository) GetActive() ([]model.Alert, error) {
+	var alerts []model.Alert
+
+	err := r.store.DB.Where("active").Order("created_at, id").Find(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// Update replaces rule of the alert of the user or returns model.ErrRecordNotFound.
+func (r *AlertRepository) Update(alert *model.Alert) error {
+	result := r.store.DB.Model(alert).Where("user_id=?", alert.UserID).
+		Select("symbol", "condition", "price", "percent", "window_ms", "repeat", "active").Updates(alert)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete removes alert of the user or returns model.ErrRecordNotFound, history of the alert is kept.
+func (r *AlertRepository) Delete(userID, id uuid.UUID) error {
+	result := r.store.DB.Where("id=? and user_id=?", id, userID).Delete(&model.Alert{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Trigger records fired alert in alert history and sets its trigger time, deactivate turns the alert off.
+func (r *AlertRepository) Trigger(event *model.AlertEvent, deactivate bool) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"triggered_at": event.Timestamp}
+		if deactivate {
+			updates["active"] = false
+		}
+
+		return tx.Model(&model.Alert{}).Where("id=?", event.AlertID).Updates(updates).Error
+	})
+}
+
+// ListEvents returns the latest limit fired alerts of the user, the newest first.
+func (r *AlertRepository) ListEvents(userID uuid.UUID, limit int) ([]model.AlertEvent, error) {
+	events := make([]model.AlertEvent, 0, limit)
+
+	err := r.store.DB.Where("user_id=?", userID).Order("timestamp desc, id desc").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,106 @@
+package postgresstore_test
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+)
+
+func (s *StoreSuite) createAlerts() []model.Alert {
+	authUser := &model.AuthUser{}
+	err := s.store.DB.Create(&authUser).Error
+	s.Nil(err)
+
+	alerts := s.AlertFixture.List()
+	for i := range alerts {
+		alerts[i].UserID = authUser.ID
+		err = s.store.Alert().Create(&alerts[i])
+		s.Nil(err)
+		s.NotEqual(uuid.Nil, alerts[i].ID)
+	}
+
+	return alerts
+}
+
+func (s *StoreSuite) TestAlertRepository_Get() {
+	alerts := s.createAlerts()
+
+	alert, err := s.store.Alert().Get(alerts[1].UserID, alerts[1].ID)
+	s.Nil(err)
+	s.Equal(alerts[1].Condition, alert.Condition)
+	s.Equal(alerts[1].WindowMs, alert.WindowMs)
+
+	_, err = s.store.Alert().Get(uuid.NewV4(), alerts[1].ID)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+}
+
+func (s *StoreSuite) TestAlertRepository_List() {
+	alerts := s.createAlerts()
+
+	list, err := s.store.Alert().List(alerts[0].UserID)
+	s.Nil(err)
+	s.Len(list, 3)
+	s.Equal(alerts[0].ID, list[0].ID)
+
+	active, err := s.store.Alert().GetActive()
+	s.Nil(err)
+	s.Len(active, 2)
+}
+
+func (s *StoreSuite) TestAlertRepository_Update() {
+	alerts := s.createAlerts()
+
+	alerts[2].Price = 80000
+	alerts[2].Active = true
+	err := s.store.Alert().Update(&alerts[2])
+	s.Nil(err)
+
+	alert, err := s.store.Alert().Get(alerts[2].UserID, alerts[2].ID)
+	s.Nil(err)
+	s.InDelta(80000, alert.Price, 0)
+	s.True(alert.Active)
+
+	alerts[2].UserID = uuid.NewV4()
+	err = s.store.Alert().Update(&alerts[2])
+	s.ErrorIs(err, model.ErrRecordNotFound)
+}
+
+func (s *StoreSuite) TestAlertRepository_Delete() {
+	alerts := s.createAlerts()
+
+	err := s.store.Alert().Delete(alerts[0].UserID, alerts[0].ID)
+	s.Nil(err)
+
+	err = s.store.Alert().Delete(alerts[0].UserID, alerts[0].ID)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+}
+
+func (s *StoreSuite) TestAlertRepository_Trigger() {
+	alerts := s.createAlerts()
+	timestamp := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		err := s.store.Alert().Trigger(&model.AlertEvent{
+			AlertID:        alerts[0].ID,
+			UserID:         alerts[0].UserID,
+			Symbol:         alerts[0].Symbol,
+			Condition:      alerts[0].Condition,
+			Price:          70001,
+			ReferencePrice: alerts[0].Price,
+			Timestamp:      timestamp.Add(time.Duration(i) * time.Second),
+		}, i == 1)
+		s.Nil(err)
+	}
+
+	alert, err := s.store.Alert().Get(alerts[0].UserID, alerts[0].ID)
+	s.Nil(err)
+	s.False(alert.Active)
+	s.True(timestamp.Add(time.Second).Equal(*alert.TriggeredAt))
+
+	events, err := s.store.Alert().ListEvents(alerts[0].UserID, 1)
+	s.Nil(err)
+	s.Len(events, 1)
+	s.True(timestamp.Add(time.Second).Equal(events[0].Timestamp))
+}
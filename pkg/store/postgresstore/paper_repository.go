@@ -0,0 +1,237 @@
+package postgresstore
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"bitmex-api/pkg/model"
+)
+
+// openStatuses are statuses of paper orders waiting for fills.
+var openStatuses = []model.OrderStatus{model.OrderNew, model.OrderPartiallyFilled}
+
+type PaperRepository struct {
+	store *PostgresStore
+}
+
+func NewPaperRepository(store *PostgresStore) *PaperRepository {
+	return &PaperRepository{store: store}
+}
+
+// Account returns paper account of the user, account with initialBalance is created on the first call.
+func (r *PaperRepository) Account(userID uuid.UUID, initialBalance float64) (*model.PaperAccount, error) {
+	now := time.Now().UTC()
+	account := &model.PaperAccount{UserID: userID, Balance: initialBalance, CreatedAt: now, UpdatedAt: now}
+
+	err := r.store.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(account).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.store.DB.Where("user_id=?", userID).First(account).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (r *PaperRepository) CreateOrder(order *model.PaperOrder) error {
+	return r.store.DB.Create(order).Error
+}
+
+// GetOrder returns paper order of the user or model.ErrRecordNotFound.
+func (r *PaperRepository) GetOrder(userID, id uuid.UUID) (*model.PaperOrder, error) {
+	var order *model.PaperOrder
+
+	result := r.store.DB.Where("id=? and user_id=?", id, userID).Find(&order)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, model.ErrRecordNotFound
+	}
+
+	return order, nil
+}
+
+// ListOrders returns the latest limit paper orders of the user, the newest first, open limits them to open orders.
+func (r *PaperRepository) ListOrders(userID uuid.UUID, open bool, limit int) ([]model.PaperOrder, error) {
+	orders := make([]model.PaperOrder, 0, limit)
+
+	query := r.store.DB.Where("user_id=?", userID)
+	if open {
+		query = query.Where("status in ?", openStatuses)
+	}
+
+	err := query.Order("created_at desc, id").Limit(limit).Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (r *PaperRepository) CountOpenOrders(userID uuid.UUID) (int64, error) {
+	var count int64
+
+	err := r.store.DB.Model(&model.PaperOrder{}).Where("user_id=? and status in ?", userID, openStatuses).
+		Count(&count).Error
+
+	return count, err
+}
+
+// GetOpenOrders returns open paper orders of all users in the order they were placed.
+func (r *PaperRepository) GetOpenOrders() ([]model.PaperOrder, error) {
+	var orders []model.PaperOrder
+
+	err := r.store.DB.Where("status in ?", openStatuses).Order("created_at, id").Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// CloseOrder sets status and reason of open paper order of the user and loads the stored order,
+// model.ErrPaperOrderClosed is returned when the order is not open.
+func (r *PaperRepository) CloseOrder(order *model.PaperOrder) error {
+	var orders []*model.PaperOrder
+
+	result := r.store.DB.Model(&orders).Clauses(clause.Returning{}).
+		Where("id=? and user_id=? and status in ?", order.ID, order.UserID, openStatuses).
+		Updates(map[string]interface{}{
+			"status":     order.Status,
+			"reason":     order.Reason,
+			"updated_at": order.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if len(orders) == 0 {
+		return model.ErrPaperOrderClosed
+	}
+
+	*order = *orders[0]
+
+	return nil
+}
+
+// Fill stores the fill, the order after the fill and settles the fill in paper account and position of the user.
+// model.ErrInsufficientBalance or model.ErrInsufficientPosition is returned when the account can not settle the fill,
+// model.ErrPaperOrderClosed is returned when the order is not open any more.
+func (r *PaperRepository) Fill(order *model.PaperOrder, fill *model.PaperFill) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		var account model.PaperAccount
+
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id=?", order.UserID).Find(&account)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return model.ErrRecordNotFound
+		}
+
+		position := model.PaperPosition{UserID: order.UserID, Symbol: order.Symbol}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id=? and symbol=?", order.UserID, order.Symbol).Find(&position).Error
+		if err != nil {
+			return err
+		}
+
+		if err = account.Fill(&position, fill); err != nil {
+			return err
+		}
+
+		result = tx.Model(order).Where("status in ?", openStatuses).
+			Select("filled_quantity", "avg_fill_price", "status", "updated_at").Updates(order)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return model.ErrPaperOrderClosed
+		}
+
+		if err = tx.Create(fill).Error; err != nil {
+			return err
+		}
+
+		if err = tx.Model(&account).Select("balance", "updated_at").Updates(&account).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&position).Error
+	})
+}
+
+// ListPositions returns paper positions of the user including closed ones with their realized profit.
+func (r *PaperRepository) ListPositions(userID uuid.UUID) ([]model.PaperPosition, error) {
+	positions := make([]model.PaperPosition, 0)
+
+	err := r.store.DB.Where("user_id=?", userID).Order("symbol").Find(&positions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// ListFills returns the latest limit fills of paper orders of the user, the newest first.
+func (r *PaperRepository) ListFills(userID uuid.UUID, limit int) ([]model.PaperFill, error) {
+	fills := make([]model.PaperFill, 0, limit)
+
+	err := r.store.DB.Where("user_id=?", userID).Order("timestamp desc, id desc").Limit(limit).Find(&fills).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return fills, nil
+}
+
+// Reset cancels open paper orders of the user, removes the positions and sets the balance,
+// the cancelled orders are returned.
+func (r *PaperRepository) Reset(userID uuid.UUID, balance float64, at time.Time) ([]model.PaperOrder, error) {
+	var cancelled []*model.PaperOrder
+
+	err := r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&cancelled).Clauses(clause.Returning{}).
+			Where("user_id=? and status in ?", userID, openStatuses).
+			Updates(map[string]interface{}{
+				"status":     model.OrderCancelled,
+				"reason":     model.PaperResetReason,
+				"updated_at": at,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		if err = tx.Where("user_id=?", userID).Delete(&model.PaperPosition{}).Error; err != nil {
+			return err
+		}
+
+		account := &model.PaperAccount{UserID: userID, Balance: balance, CreatedAt: at, UpdatedAt: at}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"balance", "updated_at"}),
+		}).Create(account).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]model.PaperOrder, 0, len(cancelled))
+	for _, order := range cancelled {
+		orders = append(orders, *order)
+	}
+
+	return orders, nil
+}
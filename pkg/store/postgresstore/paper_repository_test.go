@@ -0,0 +1,149 @@
+package postgresstore_test
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+)
+
+func (s *StoreSuite) createPaperOrders() []model.PaperOrder {
+	authUser := &model.AuthUser{}
+	err := s.store.DB.Create(&authUser).Error
+	s.Nil(err)
+
+	account, err := s.store.Paper().Account(authUser.ID, 100000)
+	s.Nil(err)
+	s.InDelta(100000, account.Balance, 0)
+
+	orders := s.PaperFixture.Orders()
+	for i := range orders {
+		orders[i].UserID = authUser.ID
+		err = s.store.Paper().CreateOrder(&orders[i])
+		s.Nil(err)
+		s.NotEqual(uuid.Nil, orders[i].ID)
+	}
+
+	return orders
+}
+
+func (s *StoreSuite) TestPaperRepository_Orders() {
+	orders := s.createPaperOrders()
+
+	order, err := s.store.Paper().GetOrder(orders[1].UserID, orders[1].ID)
+	s.Nil(err)
+	s.Equal(model.StopOrder, order.Type)
+	s.InDelta(60000, order.StopPrice, 0)
+
+	_, err = s.store.Paper().GetOrder(uuid.NewV4(), orders[1].ID)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+
+	list, err := s.store.Paper().ListOrders(orders[0].UserID, false, 10)
+	s.Nil(err)
+	s.Len(list, 3)
+	s.Equal(orders[2].ID, list[0].ID)
+
+	list, err = s.store.Paper().ListOrders(orders[0].UserID, true, 10)
+	s.Nil(err)
+	s.Len(list, 2)
+
+	count, err := s.store.Paper().CountOpenOrders(orders[0].UserID)
+	s.Nil(err)
+	s.Equal(int64(2), count)
+
+	open, err := s.store.Paper().GetOpenOrders()
+	s.Nil(err)
+	s.Len(open, 2)
+	s.Equal(orders[0].ID, open[0].ID)
+}
+
+func (s *StoreSuite) TestPaperRepository_CloseOrder() {
+	orders := s.createPaperOrders()
+
+	order := orders[1]
+	order.Status, order.Reason = model.OrderCancelled, "test"
+	err := s.store.Paper().CloseOrder(&order)
+	s.Nil(err)
+	s.Equal(model.OrderCancelled, order.Status)
+	s.InDelta(60000, order.StopPrice, 0)
+
+	err = s.store.Paper().CloseOrder(&order)
+	s.ErrorIs(err, model.ErrPaperOrderClosed)
+
+	err = s.store.Paper().CloseOrder(&orders[2])
+	s.ErrorIs(err, model.ErrPaperOrderClosed)
+}
+
+func (s *StoreSuite) TestPaperRepository_Fill() {
+	orders := s.createPaperOrders()
+	buy, sell := orders[0], orders[1]
+
+	fill := s.PaperFixture.Fill(buy, 2, 69000)
+	buy.Apply(&fill)
+	err := s.store.Paper().Fill(&buy, &fill)
+	s.Nil(err)
+	s.NotZero(fill.ID)
+
+	order, err := s.store.Paper().GetOrder(buy.UserID, buy.ID)
+	s.Nil(err)
+	s.Equal(model.OrderFilled, order.Status)
+	s.InDelta(69000, order.AvgFillPrice, 0)
+
+	account, err := s.store.Paper().Account(buy.UserID, 100000)
+	s.Nil(err)
+	s.InDelta(100000-2*69000, account.Balance, 0)
+
+	// filled order takes no more fills
+	err = s.store.Paper().Fill(&buy, &fill)
+	s.ErrorIs(err, model.ErrPaperOrderClosed)
+
+	// sell is limited by the position
+	fill = s.PaperFixture.Fill(sell, 3, 59000)
+	sell.Apply(&fill)
+	err = s.store.Paper().Fill(&sell, &fill)
+	s.ErrorIs(err, model.ErrInsufficientPosition)
+
+	sell = orders[1]
+	fill = s.PaperFixture.Fill(sell, 1, 59000)
+	sell.Apply(&fill)
+	err = s.store.Paper().Fill(&sell, &fill)
+	s.Nil(err)
+
+	positions, err := s.store.Paper().ListPositions(buy.UserID)
+	s.Nil(err)
+	s.Len(positions, 1)
+	s.InDelta(1, positions[0].Quantity, 0)
+	s.InDelta(69000, positions[0].AvgPrice, 0)
+	s.InDelta(-10000, positions[0].RealizedPnl, 0)
+
+	fills, err := s.store.Paper().ListFills(buy.UserID, 10)
+	s.Nil(err)
+	s.Len(fills, 2)
+}
+
+func (s *StoreSuite) TestPaperRepository_Reset() {
+	orders := s.createPaperOrders()
+
+	fill := s.PaperFixture.Fill(orders[0], 1, 69000)
+	orders[0].Apply(&fill)
+	err := s.store.Paper().Fill(&orders[0], &fill)
+	s.Nil(err)
+
+	cancelled, err := s.store.Paper().Reset(orders[0].UserID, 50000, time.Now().UTC())
+	s.Nil(err)
+	s.Len(cancelled, 2)
+	s.Equal(model.PaperResetReason, cancelled[0].Reason)
+
+	account, err := s.store.Paper().Account(orders[0].UserID, 100000)
+	s.Nil(err)
+	s.InDelta(50000, account.Balance, 0)
+
+	positions, err := s.store.Paper().ListPositions(orders[0].UserID)
+	s.Nil(err)
+	s.Empty(positions)
+
+	count, err := s.store.Paper().CountOpenOrders(orders[0].UserID)
+	s.Nil(err)
+	s.Zero(count)
+}
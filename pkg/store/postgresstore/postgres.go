@@ -14,8 +14,13 @@ import (
 type PostgresStore struct {
 	DB *gorm.DB
 
-	UserRepository *UserRepository
-	AuthRepository *AuthRepository
+	UserRepository      *UserRepository
+	AuthRepository      *AuthRepository
+	TradeRepository     *TradeRepository
+	AlertRepository     *AlertRepository
+	WebhookRepository   *WebhookRepository
+	PaperRepository     *PaperRepository
+	WatchlistRepository *WatchlistRepository
 }
 
 //nolint:nosprintfhostport
@@ -60,3 +65,43 @@ func (s *PostgresStore) Auth() *AuthRepository {
 
 	return s.AuthRepository
 }
+
+func (s *PostgresStore) Trade() *TradeRepository {
+	if s.TradeRepository == nil {
+		s.TradeRepository = NewTradeRepository(s)
+	}
+
+	return s.TradeRepository
+}
+
+func (s *PostgresStore) Alert() *AlertRepository {
+	if s.AlertRepository == nil {
+		s.AlertRepository = NewAlertRepository(s)
+	}
+
+	return s.AlertRepository
+}
+
+func (s *PostgresStore) Webhook() *WebhookRepository {
+	if s.WebhookRepository == nil {
+		s.WebhookRepository = NewWebhookRepository(s)
+	}
+
+	return s.WebhookRepository
+}
+
+func (s *PostgresStore) Paper() *PaperRepository {
+	if s.PaperRepository == nil {
+		s.PaperRepository = NewPaperRepository(s)
+	}
+
+	return s.PaperRepository
+}
+
+func (s *PostgresStore) Watchlist() *WatchlistRepository {
+	if s.WatchlistRepository == nil {
+		s.WatchlistRepository = NewWatchlistRepository(s)
+	}
+
+	return s.WatchlistRepository
+}
@@ -17,8 +17,13 @@ type StoreSuite struct {
 	suite.Suite
 	store *postgresstore.PostgresStore
 
-	AuthUserFixture *postgresstore.FixtureAuthUser
-	UserFixture     *postgresstore.FixtureUser
+	AuthUserFixture  *postgresstore.FixtureAuthUser
+	UserFixture      *postgresstore.FixtureUser
+	TradeFixture     *postgresstore.FixtureTrade
+	AlertFixture     *postgresstore.FixtureAlert
+	WebhookFixture   *postgresstore.FixtureWebhook
+	PaperFixture     *postgresstore.FixturePaper
+	WatchlistFixture *postgresstore.FixtureWatchlist
 }
 
 func TestSuite(t *testing.T) {
@@ -39,13 +44,28 @@ func (s *StoreSuite) SetupSuite() {
 
 	s.AuthUserFixture = postgresstore.NewFixtureAuthUser()
 	s.UserFixture = postgresstore.NewFixtureUser()
+	s.TradeFixture = postgresstore.NewFixtureTrade()
+	s.AlertFixture = postgresstore.NewFixtureAlert()
+	s.WebhookFixture = postgresstore.NewFixtureWebhook()
+	s.PaperFixture = postgresstore.NewFixturePaper()
+	s.WatchlistFixture = postgresstore.NewFixtureWatchlist()
 
 	s.cleanDB()
 }
 
 func (s *StoreSuite) cleanDB() {
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Watchlist{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.PaperFill{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.PaperOrder{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.PaperPosition{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.PaperAccount{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.WebhookDelivery{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Webhook{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.AlertEvent{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Alert{})
 	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.User{})
 	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.AuthUser{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Trade{})
 
 }
 
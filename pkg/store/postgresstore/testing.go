@@ -1,7 +1,13 @@
 package postgresstore
 
 import (
+	"time"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+
 	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/subscription"
 	"bitmex-api/pkg/utils"
 )
 
@@ -43,6 +49,8 @@ func (f *FixtureUser) One() model.User {
 		Surname: "Surname",
 		Phone:   "+3801231231",
 		Address: "Kiev",
+
+		SubscriptionDelivery: subscription.Delivery{Mode: subscription.RealtimeDelivery},
 	}
 }
 
@@ -57,3 +65,195 @@ func (f *FixtureUser) List() []model.User {
 		}),
 	}
 }
+
+type FixtureTrade struct{}
+
+func NewFixtureTrade() *FixtureTrade {
+	return &FixtureTrade{}
+}
+
+func (f *FixtureTrade) One() model.Trade {
+	return model.Trade{
+		Symbol:     "XBTUSD",
+		Side:       "Buy",
+		Size:       100,
+		Price:      42000,
+		TrdMatchID: "trade0",
+		Timestamp:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *FixtureTrade) List() []model.Trade {
+	return []model.Trade{
+		f.One(),
+		utils.Mod(f.One(), func(v *model.Trade) {
+			v.TrdMatchID = "trade1"
+			v.Timestamp = v.Timestamp.Add(time.Minute)
+		}),
+		utils.Mod(f.One(), func(v *model.Trade) {
+			v.TrdMatchID = "trade2"
+			v.Timestamp = v.Timestamp.Add(2 * time.Minute)
+		}),
+	}
+}
+
+type FixtureAlert struct{}
+
+func NewFixtureAlert() *FixtureAlert {
+	return &FixtureAlert{}
+}
+
+func (f *FixtureAlert) One() model.Alert {
+	return model.Alert{
+		Symbol:    "XBTUSD",
+		Condition: model.CrossesAbove,
+		Price:     70000,
+		Active:    true,
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *FixtureAlert) List() []model.Alert {
+	return []model.Alert{
+		f.One(),
+		utils.Mod(f.One(), func(v *model.Alert) {
+			v.Condition = model.MovesPercent
+			v.Price = 0
+			v.Percent = 2
+			v.WindowMs = 300000
+			v.CreatedAt = v.CreatedAt.Add(time.Minute)
+		}),
+		utils.Mod(f.One(), func(v *model.Alert) {
+			v.Active = false
+			v.CreatedAt = v.CreatedAt.Add(2 * time.Minute)
+		}),
+	}
+}
+
+type FixtureWebhook struct{}
+
+func NewFixtureWebhook() *FixtureWebhook {
+	return &FixtureWebhook{}
+}
+
+func (f *FixtureWebhook) One() model.Webhook {
+	return model.Webhook{
+		URL:       "https://example.com/hook",
+		Secret:    "secret",
+		Events:    pq.StringArray{string(model.WebhookAlertEvent)},
+		Active:    true,
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *FixtureWebhook) List() []model.Webhook {
+	return []model.Webhook{
+		f.One(),
+		utils.Mod(f.One(), func(v *model.Webhook) {
+			v.Events = pq.StringArray{string(model.WebhookAlertEvent), string(model.WebhookTradeEvent)}
+			v.Symbols = pq.StringArray{"XBTUSD"}
+			v.TradeIntervalMs = 5000
+			v.CreatedAt = v.CreatedAt.Add(time.Minute)
+		}),
+		utils.Mod(f.One(), func(v *model.Webhook) {
+			v.Active = false
+			v.CreatedAt = v.CreatedAt.Add(2 * time.Minute)
+		}),
+	}
+}
+
+func (f *FixtureWebhook) Delivery(webhook model.Webhook) model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:        uuid.NewV4(),
+		WebhookID: webhook.ID,
+		UserID:    webhook.UserID,
+		Event:     model.WebhookAlertEvent,
+		Payload:   []byte(`{"event": "alert"}`),
+		Status:    model.WebhookDeliveryPending,
+		CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+type FixturePaper struct{}
+
+func NewFixturePaper() *FixturePaper {
+	return &FixturePaper{}
+}
+
+func (f *FixturePaper) Order() model.PaperOrder {
+	return model.PaperOrder{
+		Symbol:    "XBTUSD",
+		Side:      model.Buy,
+		Type:      model.LimitOrder,
+		Quantity:  2,
+		Price:     70000,
+		Status:    model.OrderNew,
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *FixturePaper) Orders() []model.PaperOrder {
+	return []model.PaperOrder{
+		f.Order(),
+		utils.Mod(f.Order(), func(v *model.PaperOrder) {
+			v.Side = model.Sell
+			v.Type = model.StopOrder
+			v.Price = 0
+			v.StopPrice = 60000
+			v.CreatedAt = v.CreatedAt.Add(time.Minute)
+		}),
+		utils.Mod(f.Order(), func(v *model.PaperOrder) {
+			v.Status = model.OrderFilled
+			v.FilledQuantity = 2
+			v.AvgFillPrice = 69000
+			v.CreatedAt = v.CreatedAt.Add(2 * time.Minute)
+		}),
+	}
+}
+
+// Fill is fill of the quantity of the order at the price.
+func (f *FixturePaper) Fill(order model.PaperOrder, quantity, price float64) model.PaperFill {
+	return model.PaperFill{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Symbol:    order.Symbol,
+		Side:      order.Side,
+		Quantity:  quantity,
+		Price:     price,
+		Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+type FixtureWatchlist struct{}
+
+func NewFixtureWatchlist() *FixtureWatchlist {
+	return &FixtureWatchlist{}
+}
+
+func (f *FixtureWatchlist) One() model.Watchlist {
+	return model.Watchlist{
+		Name:      "perps",
+		Symbols:   pq.StringArray{"XBTUSD", "ETHUSD"},
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *FixtureWatchlist) List() []model.Watchlist {
+	return []model.Watchlist{
+		f.One(),
+		utils.Mod(f.One(), func(v *model.Watchlist) {
+			v.Name = "quarterlies"
+			v.Symbols = pq.StringArray{"XBTZ24"}
+			v.Position = 1
+			v.CreatedAt = v.CreatedAt.Add(time.Minute)
+		}),
+		utils.Mod(f.One(), func(v *model.Watchlist) {
+			v.Name = "empty"
+			v.Symbols = pq.StringArray{}
+			v.Position = 2
+			v.CreatedAt = v.CreatedAt.Add(2 * time.Minute)
+		}),
+	}
+}
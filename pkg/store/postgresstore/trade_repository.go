@@ -0,0 +1,61 @@
+package postgresstore
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"bitmex-api/pkg/model"
+)
+
+type TradeRepository struct {
+	store *PostgresStore
+}
+
+func NewTradeRepository(store *PostgresStore) *TradeRepository {
+	return &TradeRepository{store: store}
+}
+
+// CreateBatch inserts trades skipping the ones already stored, BitMex may repeat them after resubscribe.
+func (r *TradeRepository) CreateBatch(trades []model.Trade) error {
+	if len(trades) == 0 {
+		return nil
+	}
+
+	return r.store.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "trd_match_id"}},
+		DoNothing: true,
+	}).Create(&trades).Error
+}
+
+func (r *TradeRepository) List(query model.TradeQuery) ([]model.Trade, error) {
+	db := r.store.DB.Table("trades").Where("symbol=?", query.Symbol)
+
+	if !query.From.IsZero() {
+		db = db.Where("timestamp>=?", query.From)
+	}
+
+	if !query.To.IsZero() {
+		db = db.Where("timestamp<?", query.To)
+	}
+
+	if query.After != nil {
+		db = db.Where("(timestamp, id)>(?, ?)", query.After.Timestamp, query.After.ID)
+	}
+
+	trades := make([]model.Trade, 0, query.Limit)
+
+	err := db.Order("timestamp, id").Limit(query.Limit).Find(&trades).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+// DeleteBefore removes trades older than before and returns number of removed rows.
+func (r *TradeRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.store.DB.Where("timestamp<?", before).Delete(&model.Trade{})
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,58 @@
+package postgresstore_test
+
+import (
+	"time"
+
+	"bitmex-api/pkg/model"
+)
+
+func (s *StoreSuite) TestTradeRepository_CreateBatch() {
+	trades := s.TradeFixture.List()
+
+	err := s.store.Trade().CreateBatch(trades)
+	s.Nil(err)
+
+	err = s.store.Trade().CreateBatch(s.TradeFixture.List()[:1])
+	s.Nil(err)
+
+	var count int64
+	err = s.store.DB.Model(&model.Trade{}).Count(&count).Error
+	s.Nil(err)
+	s.Equal(int64(len(trades)), count)
+}
+
+func (s *StoreSuite) TestTradeRepository_List() {
+	trades := s.TradeFixture.List()
+
+	err := s.store.Trade().CreateBatch(trades)
+	s.Nil(err)
+
+	page, err := s.store.Trade().List(model.TradeQuery{
+		Symbol: "XBTUSD",
+		From:   trades[1].Timestamp,
+		Limit:  1,
+	})
+	s.Nil(err)
+	s.Len(page, 1)
+	s.Equal(trades[1].TrdMatchID, page[0].TrdMatchID)
+
+	page, err = s.store.Trade().List(model.TradeQuery{
+		Symbol: "XBTUSD",
+		After:  &model.TradeCursor{Timestamp: page[0].Timestamp, ID: page[0].ID},
+		Limit:  10,
+	})
+	s.Nil(err)
+	s.Len(page, 1)
+	s.Equal(trades[2].TrdMatchID, page[0].TrdMatchID)
+}
+
+func (s *StoreSuite) TestTradeRepository_DeleteBefore() {
+	trades := s.TradeFixture.List()
+
+	err := s.store.Trade().CreateBatch(trades)
+	s.Nil(err)
+
+	deleted, err := s.store.Trade().DeleteBefore(trades[0].Timestamp.Add(90 * time.Second))
+	s.Nil(err)
+	s.Equal(int64(2), deleted)
+}
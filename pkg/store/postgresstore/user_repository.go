@@ -1,7 +1,10 @@
 package postgresstore
 
 import (
+	"time"
+
 	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm/clause"
 
 	"bitmex-api/pkg/model"
 )
@@ -37,3 +40,94 @@ func (r *UserRepository) GetAll() ([]*model.User, error) {
 func (r *UserRepository) Update(user *model.User) error {
 	return r.store.DB.Table("users").Where("user_id=?", user.UserID).Updates(&user).Error
 }
+
+// UpdateSubscriptions writes stored subscriptions of the user including zero values,
+// e.g. Subscription=false after the user unsubscribed from trades.
+func (r *UserRepository) UpdateSubscriptions(user *model.User) error {
+	result := r.store.DB.Table("users").Where("user_id=?", user.UserID).Updates(map[string]interface{}{
+		"subscription":          user.Subscription,
+		"subscription_symbols":  user.SubscriptionSymbols,
+		"subscription_topics":   user.SubscriptionTopics,
+		"subscription_delivery": user.SubscriptionDelivery,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// UpdateMaxSessions sets session limit of the user, nil resets it to the server default.
+func (r *UserRepository) UpdateMaxSessions(id uuid.UUID, maxSessions *int) error {
+	result := r.store.DB.Table("users").Where("user_id=?", id).Update("max_sessions", maxSessions)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetEmail replaces email of the user with unverified one waiting for the token of tokenHash
+// or returns model.ErrRecordNotFound.
+func (r *UserRepository) SetEmail(id uuid.UUID, email, tokenHash string, expiresAt time.Time) error {
+	result := r.store.DB.Table("users").Where("user_id=?", id).Updates(map[string]interface{}{
+		"email":                  email,
+		"email_verified":         false,
+		"email_token_hash":       tokenHash,
+		"email_token_expires_at": expiresAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// VerifyEmail marks email of the user waiting for the token of tokenHash verified and returns the user,
+// model.ErrRecordNotFound is returned when no user waits for the token or it is expired.
+func (r *UserRepository) VerifyEmail(tokenHash string, now time.Time) (*model.User, error) {
+	var users []*model.User
+
+	result := r.store.DB.Model(&users).Clauses(clause.Returning{}).
+		Where("email_token_hash=? and email_token_expires_at>?", tokenHash, now).
+		Updates(map[string]interface{}{
+			"email_verified":         true,
+			"email_token_hash":       nil,
+			"email_token_expires_at": nil,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if len(users) == 0 {
+		return nil, model.ErrRecordNotFound
+	}
+
+	return users[0], nil
+}
+
+// UpdateEmailAlerts turns email of fired price alerts of the user on or off or returns model.ErrRecordNotFound.
+func (r *UserRepository) UpdateEmailAlerts(id uuid.UUID, enabled bool) error {
+	result := r.store.DB.Table("users").Where("user_id=?", id).Update("email_alerts", enabled)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -1,6 +1,13 @@
 package postgresstore_test
 
-import "bitmex-api/pkg/model"
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/ui/subscription"
+)
 
 func (s *StoreSuite) TestUserRepository_Get() {
 	users := s.UserFixture.List()
@@ -33,6 +40,11 @@ func (s *StoreSuite) TestUserRepository_Update() {
 
 	users[0].Name = "editedName"
 	users[0].Address = "editedAddress"
+	users[0].SubscriptionDelivery = subscription.Delivery{
+		Mode:       subscription.ThrottledDelivery,
+		IntervalMs: 500,
+		Aggregate:  true,
+	}
 
 	err := s.store.User().Update(&users[0])
 	s.Nil(err)
@@ -43,3 +55,110 @@ func (s *StoreSuite) TestUserRepository_Update() {
 
 	s.Equal(&users[0], actualUser)
 }
+
+func (s *StoreSuite) TestUserRepository_UpdateSubscriptions() {
+	users := s.UserFixture.List()
+
+	for i := range users {
+		authUser := &model.AuthUser{}
+		err := s.store.DB.Create(&authUser).Error
+		s.Nil(err)
+		users[i].UserID = authUser.ID
+		err = s.store.DB.Create(&users[i]).Error
+		s.Nil(err)
+	}
+
+	users[0].Subscription = true
+	users[0].SubscriptionSymbols = []string{"XBTUSD"}
+	users[0].SubscriptionTopics = []string{"quote:XBTUSD"}
+
+	err := s.store.User().UpdateSubscriptions(&users[0])
+	s.Nil(err)
+
+	// unsubscribe from trades keeping quotes
+	users[0].Subscription = false
+	users[0].SubscriptionSymbols = []string{}
+
+	err = s.store.User().UpdateSubscriptions(&users[0])
+	s.Nil(err)
+
+	user, err := s.store.User().Get(users[0].UserID)
+	s.Nil(err)
+	s.False(user.Subscription)
+	s.Empty(user.SubscriptionSymbols)
+	s.Equal([]string{"quote:XBTUSD"}, []string(user.SubscriptionTopics))
+
+	err = s.store.User().UpdateSubscriptions(&model.User{UserID: uuid.NewV4()})
+	s.ErrorIs(err, model.ErrRecordNotFound)
+}
+
+func (s *StoreSuite) TestUserRepository_UpdateMaxSessions() {
+	users := s.UserFixture.List()
+
+	for i := range users {
+		authUser := &model.AuthUser{}
+		err := s.store.DB.Create(&authUser).Error
+		s.Nil(err)
+		users[i].UserID = authUser.ID
+		err = s.store.DB.Create(&users[i]).Error
+		s.Nil(err)
+	}
+
+	maxSessions := 3
+	err := s.store.User().UpdateMaxSessions(users[0].UserID, &maxSessions)
+	s.Nil(err)
+
+	user, err := s.store.User().Get(users[0].UserID)
+	s.Nil(err)
+	s.Equal(&maxSessions, user.MaxSessions)
+
+	err = s.store.User().UpdateMaxSessions(users[0].UserID, nil)
+	s.Nil(err)
+
+	user, err = s.store.User().Get(users[0].UserID)
+	s.Nil(err)
+	s.Nil(user.MaxSessions)
+}
+
+func (s *StoreSuite) TestUserRepository_Email() {
+	users := s.UserFixture.List()
+
+	for i := range users {
+		authUser := &model.AuthUser{}
+		err := s.store.DB.Create(&authUser).Error
+		s.Nil(err)
+		users[i].UserID = authUser.ID
+		err = s.store.DB.Create(&users[i]).Error
+		s.Nil(err)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err := s.store.User().SetEmail(users[0].UserID, "user@example.com", "hash", now.Add(time.Hour))
+	s.Nil(err)
+
+	err = s.store.User().SetEmail(uuid.NewV4(), "user@example.com", "other", now.Add(time.Hour))
+	s.ErrorIs(err, model.ErrRecordNotFound)
+
+	_, err = s.store.User().VerifyEmail("hash", now.Add(2*time.Hour))
+	s.ErrorIs(err, model.ErrRecordNotFound)
+
+	user, err := s.store.User().VerifyEmail("hash", now)
+	s.Nil(err)
+	s.Equal(users[0].UserID, user.UserID)
+	s.Equal("user@example.com", user.Email)
+	s.True(user.EmailVerified)
+
+	// token is used once
+	_, err = s.store.User().VerifyEmail("hash", now)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+
+	err = s.store.User().UpdateEmailAlerts(users[0].UserID, true)
+	s.Nil(err)
+
+	user, err = s.store.User().Get(users[0].UserID)
+	s.Nil(err)
+	s.True(user.EmailAlerts)
+	s.True(user.EmailVerified)
+	s.Empty(user.EmailTokenHash)
+}
@@ -0,0 +1,108 @@
+package postgresstore
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+
+	"bitmex-api/pkg/model"
+)
+
+type WatchlistRepository struct {
+	store *PostgresStore
+}
+
+func NewWatchlistRepository(store *PostgresStore) *WatchlistRepository {
+	return &WatchlistRepository{store: store}
+}
+
+func (r *WatchlistRepository) Create(watchlist *model.Watchlist) error {
+	return r.store.DB.Create(watchlist).Error
+}
+
+// Get returns watchlist of the user or model.ErrRecordNotFound.
+func (r *WatchlistRepository) Get(userID, id uuid.UUID) (*model.Watchlist, error) {
+	return r.find(r.store.DB.Where("id=? and user_id=?", id, userID))
+}
+
+// GetShared returns watchlist shared with the token or model.ErrRecordNotFound.
+func (r *WatchlistRepository) GetShared(token string) (*model.Watchlist, error) {
+	return r.find(r.store.DB.Where("share_token=?", token))
+}
+
+func (r *WatchlistRepository) find(query *gorm.DB) (*model.Watchlist, error) {
+	var watchlist *model.Watchlist
+
+	result := query.Find(&watchlist)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, model.ErrRecordNotFound
+	}
+
+	return watchlist, nil
+}
+
+// List returns watchlists of the user ordered by position.
+func (r *WatchlistRepository) List(userID uuid.UUID) ([]model.Watchlist, error) {
+	watchlists := make([]model.Watchlist, 0)
+
+	err := r.store.DB.Where("user_id=?", userID).Order("position, created_at, id").Find(&watchlists).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return watchlists, nil
+}
+
+// Update replaces name, symbols and share token of the watchlist of the user or returns model.ErrRecordNotFound.
+func (r *WatchlistRepository) Update(watchlist *model.Watchlist) error {
+	result := r.store.DB.Model(watchlist).Where("user_id=?", watchlist.UserID).
+		Select("name", "symbols", "share_token", "updated_at").Updates(watchlist)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Reorder sets position of every listed watchlist of the user to its index in ids.
+// Nothing is changed and model.ErrRecordNotFound is returned if any of the watchlists is not found.
+func (r *WatchlistRepository) Reorder(userID uuid.UUID, ids []uuid.UUID, at time.Time) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		for position, id := range ids {
+			result := tx.Model(&model.Watchlist{}).Where("id=? and user_id=?", id, userID).
+				Updates(map[string]interface{}{"position": position, "updated_at": at})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return model.ErrRecordNotFound
+			}
+		}
+
+		return nil
+	})
+}
+
+// Delete removes watchlist of the user or returns model.ErrRecordNotFound.
+func (r *WatchlistRepository) Delete(userID, id uuid.UUID) error {
+	result := r.store.DB.Where("id=? and user_id=?", id, userID).Delete(&model.Watchlist{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
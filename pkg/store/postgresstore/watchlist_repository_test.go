@@ -0,0 +1,102 @@
+package postgresstore_test
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+)
+
+func (s *StoreSuite) createWatchlists() []model.Watchlist {
+	authUser := &model.AuthUser{}
+	err := s.store.DB.Create(&authUser).Error
+	s.Nil(err)
+
+	watchlists := s.WatchlistFixture.List()
+	for i := range watchlists {
+		watchlists[i].UserID = authUser.ID
+		err = s.store.Watchlist().Create(&watchlists[i])
+		s.Nil(err)
+		s.NotEqual(uuid.Nil, watchlists[i].ID)
+	}
+
+	return watchlists
+}
+
+func (s *StoreSuite) TestWatchlistRepository_CRUD() {
+	watchlists := s.createWatchlists()
+	userID := watchlists[0].UserID
+
+	watchlist, err := s.store.Watchlist().Get(userID, watchlists[1].ID)
+	s.Nil(err)
+	s.Equal("quarterlies", watchlist.Name)
+	s.Equal([]string{"XBTZ24"}, []string(watchlist.Symbols))
+	s.Nil(watchlist.ShareToken)
+
+	_, err = s.store.Watchlist().Get(uuid.NewV4(), watchlists[1].ID)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+
+	// name is unique per user
+	duplicate := s.WatchlistFixture.One()
+	duplicate.UserID = userID
+	s.NotNil(s.store.Watchlist().Create(&duplicate))
+
+	token := "token"
+	watchlist.Name = "futures"
+	watchlist.Symbols = append(watchlist.Symbols, "XBTH25")
+	watchlist.ShareToken = &token
+	err = s.store.Watchlist().Update(watchlist)
+	s.Nil(err)
+
+	shared, err := s.store.Watchlist().GetShared(token)
+	s.Nil(err)
+	s.Equal(watchlist.ID, shared.ID)
+	s.Equal("futures", shared.Name)
+	s.Len(shared.Symbols, 2)
+
+	watchlist.ShareToken = nil
+	err = s.store.Watchlist().Update(watchlist)
+	s.Nil(err)
+
+	_, err = s.store.Watchlist().GetShared(token)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+
+	err = s.store.Watchlist().Delete(userID, watchlist.ID)
+	s.Nil(err)
+
+	err = s.store.Watchlist().Delete(userID, watchlist.ID)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+
+	list, err := s.store.Watchlist().List(userID)
+	s.Nil(err)
+	s.Len(list, 2)
+}
+
+func (s *StoreSuite) TestWatchlistRepository_Reorder() {
+	watchlists := s.createWatchlists()
+	userID := watchlists[0].UserID
+
+	list, err := s.store.Watchlist().List(userID)
+	s.Nil(err)
+	s.Len(list, 3)
+	s.Equal(watchlists[0].ID, list[0].ID)
+
+	err = s.store.Watchlist().Reorder(userID, []uuid.UUID{watchlists[2].ID, watchlists[0].ID, watchlists[1].ID},
+		time.Now().UTC())
+	s.Nil(err)
+
+	list, err = s.store.Watchlist().List(userID)
+	s.Nil(err)
+	s.Equal(watchlists[2].ID, list[0].ID)
+	s.Equal(watchlists[0].ID, list[1].ID)
+	s.Equal(watchlists[1].ID, list[2].ID)
+
+	// unknown watchlist rolls back the order
+	err = s.store.Watchlist().Reorder(userID, []uuid.UUID{watchlists[1].ID, uuid.NewV4()}, time.Now().UTC())
+	s.ErrorIs(err, model.ErrRecordNotFound)
+
+	list, err = s.store.Watchlist().List(userID)
+	s.Nil(err)
+	s.Equal(watchlists[2].ID, list[0].ID)
+}
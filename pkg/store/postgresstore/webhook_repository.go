@@ -0,0 +1,143 @@
+package postgresstore
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+)
+
+// dueStatuses are statuses of webhook deliveries waiting for their next attempt.
+var dueStatuses = []model.WebhookDeliveryStatus{model.WebhookDeliveryPending, model.WebhookDeliveryRetrying}
+
+type WebhookRepository struct {
+	store *PostgresStore
+}
+
+func NewWebhookRepository(store *PostgresStore) *WebhookRepository {
+	return &WebhookRepository{store: store}
+}
+
+func (r *WebhookRepository) Create(webhook *model.Webhook) error {
+	return r.store.DB.Create(webhook).Error
+}
+
+// Get returns webhook of the user or model.ErrRecordNotFound.
+func (r *WebhookRepository) Get(userID, id uuid.UUID) (*model.Webhook, error) {
+	var webhook *model.Webhook
+
+	result := r.store.DB.Where("id=? and user_id=?", id, userID).Find(&webhook)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, model.ErrRecordNotFound
+	}
+
+	return webhook, nil
+}
+
+func (r *WebhookRepository) List(userID uuid.UUID) ([]model.Webhook, error) {
+	webhooks := make([]model.Webhook, 0)
+
+	err := r.store.DB.Where("user_id=?", userID).Order("created_at, id").Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetActive returns active webhooks of all users.
+func (r *WebhookRepository) GetActive() ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+
+	err := r.store.DB.Where("active").Order("created_at, id").Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// Update replaces URL and events of the webhook of the user or returns model.ErrRecordNotFound, secret is kept.
+func (r *WebhookRepository) Update(webhook *model.Webhook) error {
+	result := r.store.DB.Model(webhook).Where("user_id=?", webhook.UserID).
+		Select("url", "events", "symbols", "trade_interval_ms", "active").Updates(webhook)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete removes webhook of the user with its deliveries or returns model.ErrRecordNotFound.
+func (r *WebhookRepository) Delete(userID, id uuid.UUID) error {
+	result := r.store.DB.Where("id=? and user_id=?", id, userID).Delete(&model.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return r.store.DB.Create(delivery).Error
+}
+
+// UpdateDelivery saves the result of the latest attempt of the delivery.
+func (r *WebhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return r.store.DB.Model(delivery).
+		Select("status", "attempts", "last_status_code", "last_error", "delivered_at", "next_attempt_at").
+		Updates(delivery).Error
+}
+
+// GetDue returns at most limit pending and retrying deliveries of all webhooks whose next attempt is due
+// before the time, the longest waiting first.
+func (r *WebhookRepository) GetDue(before time.Time, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := make([]model.WebhookDelivery, 0, limit)
+
+	err := r.store.DB.Where("status in ? and next_attempt_at<=?", dueStatuses, before).
+		Order("next_attempt_at, id").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ListDeliveries returns the latest limit deliveries of the webhook of the user, the newest first.
+func (r *WebhookRepository) ListDeliveries(userID, webhookID uuid.UUID, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := make([]model.WebhookDelivery, 0, limit)
+
+	err := r.store.DB.Where("webhook_id=? and user_id=?", webhookID, userID).
+		Order("created_at desc, id").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ListDeadLetters returns the latest limit failed deliveries of all webhooks of the user, the newest first.
+func (r *WebhookRepository) ListDeadLetters(userID uuid.UUID, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := make([]model.WebhookDelivery, 0, limit)
+
+	err := r.store.DB.Where("user_id=? and status=?", userID, model.WebhookDeliveryFailed).
+		Order("created_at desc, id").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
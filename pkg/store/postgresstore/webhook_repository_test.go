@@ -0,0 +1,114 @@
+package postgresstore_test
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/model"
+)
+
+func (s *StoreSuite) createWebhooks() []model.Webhook {
+	authUser := &model.AuthUser{}
+	err := s.store.DB.Create(&authUser).Error
+	s.Nil(err)
+
+	webhooks := s.WebhookFixture.List()
+	for i := range webhooks {
+		webhooks[i].UserID = authUser.ID
+		err = s.store.Webhook().Create(&webhooks[i])
+		s.Nil(err)
+		s.NotEqual(uuid.Nil, webhooks[i].ID)
+	}
+
+	return webhooks
+}
+
+func (s *StoreSuite) TestWebhookRepository_Get() {
+	webhooks := s.createWebhooks()
+
+	webhook, err := s.store.Webhook().Get(webhooks[1].UserID, webhooks[1].ID)
+	s.Nil(err)
+	s.Equal(webhooks[1].Events, webhook.Events)
+	s.Equal(webhooks[1].Symbols, webhook.Symbols)
+	s.Equal(webhooks[1].Secret, webhook.Secret)
+
+	_, err = s.store.Webhook().Get(uuid.NewV4(), webhooks[1].ID)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+}
+
+func (s *StoreSuite) TestWebhookRepository_List() {
+	webhooks := s.createWebhooks()
+
+	list, err := s.store.Webhook().List(webhooks[0].UserID)
+	s.Nil(err)
+	s.Len(list, 3)
+	s.Equal(webhooks[0].ID, list[0].ID)
+
+	active, err := s.store.Webhook().GetActive()
+	s.Nil(err)
+	s.Len(active, 2)
+}
+
+func (s *StoreSuite) TestWebhookRepository_UpdateDelete() {
+	webhooks := s.createWebhooks()
+
+	webhooks[2].URL = "https://example.com/other"
+	webhooks[2].Secret = "changed"
+	webhooks[2].Active = true
+	err := s.store.Webhook().Update(&webhooks[2])
+	s.Nil(err)
+
+	webhook, err := s.store.Webhook().Get(webhooks[2].UserID, webhooks[2].ID)
+	s.Nil(err)
+	s.Equal("https://example.com/other", webhook.URL)
+	s.Equal("secret", webhook.Secret)
+	s.True(webhook.Active)
+
+	err = s.store.Webhook().Delete(webhooks[2].UserID, webhooks[2].ID)
+	s.Nil(err)
+
+	err = s.store.Webhook().Delete(webhooks[2].UserID, webhooks[2].ID)
+	s.ErrorIs(err, model.ErrRecordNotFound)
+}
+
+func (s *StoreSuite) TestWebhookRepository_Deliveries() {
+	webhooks := s.createWebhooks()
+
+	delivered := s.WebhookFixture.Delivery(webhooks[0])
+	err := s.store.Webhook().CreateDelivery(&delivered)
+	s.Nil(err)
+
+	failed := s.WebhookFixture.Delivery(webhooks[0])
+	failed.CreatedAt = failed.CreatedAt.Add(time.Second)
+	err = s.store.Webhook().CreateDelivery(&failed)
+	s.Nil(err)
+
+	deliveredAt := delivered.CreatedAt.Add(time.Second)
+	delivered.Status = model.WebhookDeliveryDelivered
+	delivered.Attempts = 1
+	delivered.LastStatusCode = 200
+	delivered.DeliveredAt = &deliveredAt
+	err = s.store.Webhook().UpdateDelivery(&delivered)
+	s.Nil(err)
+
+	failed.Status = model.WebhookDeliveryFailed
+	failed.Attempts = 5
+	failed.LastStatusCode = 500
+	failed.LastError = "unexpected status code 500"
+	err = s.store.Webhook().UpdateDelivery(&failed)
+	s.Nil(err)
+
+	deliveries, err := s.store.Webhook().ListDeliveries(webhooks[0].UserID, webhooks[0].ID, 10)
+	s.Nil(err)
+	s.Len(deliveries, 2)
+	s.Equal(failed.ID, deliveries[0].ID)
+	s.Equal(model.WebhookDeliveryDelivered, deliveries[1].Status)
+	s.JSONEq(`{"event": "alert"}`, string(deliveries[1].Payload))
+
+	deadLetters, err := s.store.Webhook().ListDeadLetters(webhooks[0].UserID, 10)
+	s.Nil(err)
+	s.Len(deadLetters, 1)
+	s.Equal(5, deadLetters[0].Attempts)
+	s.Equal("unexpected status code 500", deadLetters[0].LastError)
+}
@@ -6,8 +6,13 @@ import (
 )
 
 type Store struct {
-	User UserRepository
-	Auth AuthRepository
+	User      UserRepository
+	Auth      AuthRepository
+	Trade     TradeRepository
+	Alert     AlertRepository
+	Webhook   WebhookRepository
+	Paper     PaperRepository
+	Watchlist WatchlistRepository
 }
 
 func NewStore(conf *config.Configs) (*Store, error) {
@@ -17,7 +22,12 @@ func NewStore(conf *config.Configs) (*Store, error) {
 	}
 
 	return &Store{
-		User: postgres.User(),
-		Auth: postgres.Auth(),
+		User:      postgres.User(),
+		Auth:      postgres.Auth(),
+		Trade:     postgres.Trade(),
+		Alert:     postgres.Alert(),
+		Webhook:   postgres.Webhook(),
+		Paper:     postgres.Paper(),
+		Watchlist: postgres.Watchlist(),
 	}, nil
 }
@@ -0,0 +1,65 @@
+// Package streamcodec encodes frames of the user stream. Frames are built as JSON once for every session
+// and transcoded to the encoding the session has negotiated.
+package streamcodec
+
+import "errors"
+
+type Encoding string
+
+const (
+	JSON        Encoding = "json"
+	MessagePack Encoding = "msgpack"
+	Protobuf    Encoding = "protobuf"
+)
+
+// Encodings lists supported encodings, they are offered as WebSocket subprotocols as well.
+//
+//nolint:gochecknoglobals
+var Encodings = []string{string(JSON), string(MessagePack), string(Protobuf)}
+
+var ErrUnknownFrame = errors.New("frame has no protobuf message")
+
+func (e Encoding) Valid() bool {
+	return e == JSON || e == MessagePack || e == Protobuf
+}
+
+// Codec converts JSON frames to the encoding and client commands in the encoding to JSON.
+type Codec interface {
+	Encoding() Encoding
+	// Binary reports whether frames are sent as binary WebSocket messages.
+	Binary() bool
+	Encode(frame []byte) ([]byte, error)
+	Decode(message []byte) ([]byte, error)
+}
+
+// New returns codec of the encoding, JSON codec is returned for unknown encoding.
+//
+//nolint:ireturn
+func New(encoding Encoding) Codec {
+	switch encoding {
+	case MessagePack:
+		return msgpackCodec{}
+	case Protobuf:
+		return protobufCodec{}
+	default:
+		return jsonCodec{}
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encoding() Encoding {
+	return JSON
+}
+
+func (jsonCodec) Binary() bool {
+	return false
+}
+
+func (jsonCodec) Encode(frame []byte) ([]byte, error) {
+	return frame, nil
+}
+
+func (jsonCodec) Decode(message []byte) ([]byte, error) {
+	return message, nil
+}
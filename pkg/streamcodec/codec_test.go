@@ -0,0 +1,168 @@
+package streamcodec
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+
+	"bitmex-api/pkg/marketdatapb"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/model/ui/stream"
+	"bitmex-api/pkg/model/ui/subscription"
+)
+
+func tradeFrame(t *testing.T) []byte {
+	t.Helper()
+
+	frame, err := json.Marshal(stream.Envelope{Type: stream.TradeMessageType, Data: bitmex.TradeDataRecord{
+		Symbol:     "XBTUSD",
+		Side:       "Buy",
+		Size:       100,
+		Price:      42000.5,
+		TrdMatchID: "match",
+		Timestamp:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}})
+	require.NoError(t, err)
+
+	return frame
+}
+
+func TestJSON(t *testing.T) {
+	c := New(JSON)
+
+	frame := tradeFrame(t)
+	encoded, err := c.Encode(frame)
+	require.NoError(t, err)
+	assert.Equal(t, frame, encoded)
+	assert.False(t, c.Binary())
+}
+
+func TestMessagePack(t *testing.T) {
+	c := New(MessagePack)
+	assert.True(t, c.Binary())
+
+	encoded, err := c.Encode(tradeFrame(t))
+	require.NoError(t, err)
+
+	var frame map[string]interface{}
+	require.NoError(t, codec.NewDecoderBytes(encoded, msgpackHandle).Decode(&frame))
+
+	assert.Equal(t, "trade", frame["type"])
+
+	data, ok := frame["data"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "XBTUSD", data["symbol"])
+	assert.EqualValues(t, 100, data["size"])
+	assert.InDelta(t, 42000.5, data["price"], 0)
+	assert.Equal(t, "2024-01-02T03:04:05Z", data["timestamp"])
+
+	command, err := c.Decode(encoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(tradeFrame(t)), string(command))
+}
+
+func TestProtobuf_Encode(t *testing.T) {
+	c := New(Protobuf)
+	assert.True(t, c.Binary())
+
+	encoded, err := c.Encode(tradeFrame(t))
+	require.NoError(t, err)
+
+	frame := &marketdatapb.Frame{}
+	require.NoError(t, proto.Unmarshal(encoded, frame))
+
+	assert.Equal(t, "trade", frame.GetType())
+	assert.Equal(t, "XBTUSD", frame.GetTrade().GetSymbol())
+	assert.Equal(t, int64(100), frame.GetTrade().GetSize())
+	assert.Equal(t, "match", frame.GetTrade().GetTrdMatchId())
+	assert.Equal(t, int64(1704164645), frame.GetTrade().GetTimestamp().GetSeconds())
+
+	ack, err := json.Marshal(stream.AckMessage{
+		Type: stream.AckMessageType,
+		ID:   "1",
+		Op:   stream.ListCommand,
+		Data: map[string]interface{}{"version": 2},
+	})
+	require.NoError(t, err)
+
+	encoded, err = c.Encode(ack)
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(encoded, frame))
+	assert.Equal(t, "1", frame.GetAck().GetId())
+	assert.InDelta(t, 2, frame.GetAck().GetData().GetFields()["version"].GetNumberValue(), 0)
+
+	encoded, err = c.Encode([]byte(`{"code": 429, "message": "too many open sessions"}`))
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(encoded, frame))
+	assert.Equal(t, "error", frame.GetType())
+	assert.Equal(t, int32(429), frame.GetError().GetCode())
+
+	encoded, err = c.Encode([]byte(`{"type": "tokenExpiring", "expiresAt": "2024-01-02T03:04:05Z"}`))
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(encoded, frame))
+	assert.Equal(t, int64(1704164645), frame.GetTokenExpiring().GetExpiresAt().GetSeconds())
+
+	encoded, err = c.Encode([]byte(`{"type": "alert", "data": {"id": 7, "alertID": "a", "price": 70001}}`))
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(encoded, frame))
+	assert.Equal(t, int64(7), frame.GetAlert().GetId())
+	assert.Equal(t, "a", frame.GetAlert().GetAlertId())
+
+	encoded, err = c.Encode([]byte(`{"type": "execution", "data": {"order": {"id": "o", "status": "filled",
+		"filledQuantity": 2}, "fill": {"id": 3, "orderID": "o", "price": 70001}}}`))
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(encoded, frame))
+	assert.Equal(t, "filled", frame.GetExecution().GetOrder().GetStatus())
+	assert.InDelta(t, 2, frame.GetExecution().GetOrder().GetFilledQuantity(), 0)
+	assert.Equal(t, "o", frame.GetExecution().GetFill().GetOrderId())
+
+	_, err = c.Encode([]byte(`{"type": "unknown"}`))
+	assert.ErrorIs(t, err, ErrUnknownFrame)
+}
+
+func TestProtobuf_Batch(t *testing.T) {
+	batch := append(append([]byte("["), tradeFrame(t)...), append([]byte(","), append(tradeFrame(t), ']')...)...)
+
+	encoded, err := New(Protobuf).Encode(batch)
+	require.NoError(t, err)
+
+	frame := &marketdatapb.Frame{}
+	require.NoError(t, proto.Unmarshal(encoded, frame))
+
+	assert.Equal(t, BatchType, frame.GetType())
+	require.Len(t, frame.GetBatch().GetFrames(), 2)
+	assert.Equal(t, "XBTUSD", frame.GetBatch().GetFrames()[1].GetTrade().GetSymbol())
+}
+
+func TestProtobuf_Decode(t *testing.T) {
+	watchlist := uuid.NewV4()
+	message, err := proto.Marshal(&marketdatapb.Command{
+		Id:        "1",
+		Op:        "subscribe",
+		Symbols:   []string{"XBTUSD"},
+		Channels:  []string{"quote"},
+		Delivery:  &marketdatapb.Delivery{Mode: "throttled", IntervalMs: 500},
+		Watchlist: watchlist.String(),
+	})
+	require.NoError(t, err)
+
+	decoded, err := New(Protobuf).Decode(message)
+	require.NoError(t, err)
+
+	var command stream.Command
+	require.NoError(t, json.Unmarshal(decoded, &command))
+	assert.Equal(t, stream.Command{
+		ID:        "1",
+		Op:        stream.SubscribeCommand,
+		Symbols:   []string{"XBTUSD"},
+		Channels:  []subscription.Channel{subscription.QuoteChannel},
+		Delivery:  &subscription.Delivery{Mode: subscription.ThrottledDelivery, IntervalMs: 500},
+		Watchlist: &watchlist,
+	}, command)
+}
@@ -0,0 +1,81 @@
+package streamcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+//nolint:gochecknoglobals
+var msgpackHandle = func() *codec.MsgpackHandle {
+	handle := &codec.MsgpackHandle{}
+	handle.WriteExt = true
+	handle.Canonical = true
+	handle.RawToString = true
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	return handle
+}()
+
+// msgpackCodec keeps the structure of JSON frames, integers stay integers and timestamps stay RFC 3339 strings.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Encoding() Encoding {
+	return MessagePack
+}
+
+func (msgpackCodec) Binary() bool {
+	return true
+}
+
+func (msgpackCodec) Encode(frame []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(frame))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, msgpackHandle).Encode(numbers(value)); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (msgpackCodec) Decode(message []byte) ([]byte, error) {
+	var value interface{}
+	if err := codec.NewDecoderBytes(message, msgpackHandle).Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// numbers replaces json.Number with int64 or float64 in decoded JSON value.
+func numbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		f, _ := v.Float64()
+
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = numbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	}
+
+	return value
+}
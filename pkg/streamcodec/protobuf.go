@@ -0,0 +1,154 @@
+package streamcodec
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"bitmex-api/pkg/marketdatapb"
+	"bitmex-api/pkg/model/ui/stream"
+)
+
+// BatchType is type of Frame carrying Batch, batches are plain arrays in JSON.
+const BatchType = "batch"
+
+//nolint:gochecknoglobals
+var frameJSON = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// protobufCodec sends marketdatapb.Frame, JSON names of its messages match JSON frames of payload version 2.
+type protobufCodec struct{}
+
+func (protobufCodec) Encoding() Encoding {
+	return Protobuf
+}
+
+func (protobufCodec) Binary() bool {
+	return true
+}
+
+func (protobufCodec) Encode(frame []byte) ([]byte, error) {
+	message, err := protobufFrame(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(message)
+}
+
+// protobufFrame converts JSON frame, JSON array of frames is converted to Batch.
+//
+//nolint:cyclop
+func protobufFrame(frame []byte) (*marketdatapb.Frame, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(frame), []byte("[")) {
+		return protobufBatch(frame)
+	}
+
+	var envelope struct {
+		Type stream.MessageType `json:"type"`
+		Data json.RawMessage    `json:"data"`
+	}
+	if err := json.Unmarshal(frame, &envelope); err != nil {
+		return nil, err
+	}
+
+	message := &marketdatapb.Frame{Type: string(envelope.Type)}
+
+	var err error
+
+	// data messages are wrapped in envelope, control messages are flat
+	switch envelope.Type {
+	case stream.TradeMessageType:
+		payload := &marketdatapb.Trade{}
+		err = frameJSON.Unmarshal(envelope.Data, payload)
+		message.Payload = &marketdatapb.Frame_Trade{Trade: payload}
+	case stream.QuoteMessageType:
+		payload := &marketdatapb.Quote{}
+		err = frameJSON.Unmarshal(envelope.Data, payload)
+		message.Payload = &marketdatapb.Frame_Quote{Quote: payload}
+	case stream.OrderBookMessageType:
+		payload := &marketdatapb.OrderBook{}
+		err = frameJSON.Unmarshal(envelope.Data, payload)
+		message.Payload = &marketdatapb.Frame_OrderBook{OrderBook: payload}
+	case stream.CandleMessageType:
+		payload := &marketdatapb.Candle{}
+		err = frameJSON.Unmarshal(envelope.Data, payload)
+		message.Payload = &marketdatapb.Frame_Candle{Candle: payload}
+	case stream.TradeWindowMessageType:
+		payload := &marketdatapb.TradeWindow{}
+		err = frameJSON.Unmarshal(envelope.Data, payload)
+		message.Payload = &marketdatapb.Frame_TradeWindow{TradeWindow: payload}
+	case stream.SnapshotMessageType:
+		payload := &marketdatapb.Snapshot{}
+		err = frameJSON.Unmarshal(envelope.Data, payload)
+		message.Payload = &marketdatapb.Frame_Snapshot{Snapshot: payload}
+	case stream.AlertMessageType:
+		payload := &marketdatapb.AlertEvent{}
+		err = frameJSON.Unmarshal(envelope.Data, payload)
+		message.Payload = &marketdatapb.Frame_Alert{Alert: payload}
+	case stream.ExecutionMessageType:
+		payload := &marketdatapb.ExecutionReport{}
+		err = frameJSON.Unmarshal(envelope.Data, payload)
+		message.Payload = &marketdatapb.Frame_Execution{Execution: payload}
+	case stream.StatusMessageType:
+		payload := &marketdatapb.Status{}
+		err = frameJSON.Unmarshal(frame, payload)
+		message.Payload = &marketdatapb.Frame_Status{Status: payload}
+	case stream.AckMessageType:
+		payload := &marketdatapb.Ack{}
+		err = frameJSON.Unmarshal(frame, payload)
+		message.Payload = &marketdatapb.Frame_Ack{Ack: payload}
+	// errors rejecting the connection have no type
+	case stream.ErrorMessageType, "":
+		payload := &marketdatapb.Error{}
+		err = frameJSON.Unmarshal(frame, payload)
+		message.Type = string(stream.ErrorMessageType)
+		message.Payload = &marketdatapb.Frame_Error{Error: payload}
+	case stream.DisconnectMessageType:
+		payload := &marketdatapb.Disconnect{}
+		err = frameJSON.Unmarshal(frame, payload)
+		message.Payload = &marketdatapb.Frame_Disconnect{Disconnect: payload}
+	case stream.TokenExpiringMessageType:
+		payload := &marketdatapb.TokenExpiring{}
+		err = frameJSON.Unmarshal(frame, payload)
+		message.Payload = &marketdatapb.Frame_TokenExpiring{TokenExpiring: payload}
+	default:
+		return nil, ErrUnknownFrame
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func protobufBatch(frame []byte) (*marketdatapb.Frame, error) {
+	var frames []json.RawMessage
+	if err := json.Unmarshal(frame, &frames); err != nil {
+		return nil, err
+	}
+
+	batch := &marketdatapb.Batch{Frames: make([]*marketdatapb.Frame, 0, len(frames))}
+
+	for _, item := range frames {
+		message, err := protobufFrame(item)
+		if err != nil {
+			return nil, err
+		}
+
+		batch.Frames = append(batch.Frames, message)
+	}
+
+	return &marketdatapb.Frame{Type: BatchType, Payload: &marketdatapb.Frame_Batch{Batch: batch}}, nil
+}
+
+func (protobufCodec) Decode(message []byte) ([]byte, error) {
+	command := &marketdatapb.Command{}
+	if err := proto.Unmarshal(message, command); err != nil {
+		return nil, err
+	}
+
+	return protojson.Marshal(command)
+}
@@ -0,0 +1,121 @@
+// Package tradehistory persists trade ticks in batches and enforces their retention.
+package tradehistory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/store"
+)
+
+type Writer struct {
+	repo   store.TradeRepository
+	config *config.TradesConfig
+	trades chan model.Trade
+}
+
+func NewWriter(repo store.TradeRepository, config *config.TradesConfig) *Writer {
+	return &Writer{
+		repo:   repo,
+		config: config,
+		trades: make(chan model.Trade, config.BufferSize),
+	}
+}
+
+// Add queues trade for insert. The trade is dropped when the queue is full, so stream delivery never waits for DB.
+func (w *Writer) Add(record bitmex.TradeDataRecord) {
+	select {
+	case w.trades <- model.Trade{
+		Symbol:          record.Symbol,
+		Side:            record.Side,
+		Size:            record.Size,
+		Price:           record.Price,
+		TickDirection:   record.TickDirection,
+		TrdMatchID:      record.TrdMatchID,
+		GrossValue:      record.GrossValue,
+		HomeNotional:    record.HomeNotional,
+		ForeignNotional: record.ForeignNotional,
+		Timestamp:       record.Timestamp,
+	}:
+	default:
+		logger.Errorf("trade history queue is full, trade dropped", record.TrdMatchID)
+	}
+}
+
+// Run inserts queued trades once BatchSize is collected or every FlushInterval
+// and removes trades older than Retention every RetentionInterval. Queued trades are flushed on shutdown.
+func (w *Writer) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	flush := time.NewTicker(w.config.FlushInterval.Duration)
+	defer flush.Stop()
+
+	retention := time.NewTicker(w.config.RetentionInterval.Duration)
+	defer retention.Stop()
+
+	batch := make([]model.Trade, 0, w.config.BatchSize)
+
+	for {
+		select {
+		case <-ctx.Done():
+			batch = w.drain(batch)
+			w.flush(batch)
+			logger.Infof("trade history writer done")
+
+			return
+		case trade := <-w.trades:
+			batch = append(batch, trade)
+			if len(batch) >= w.config.BatchSize {
+				batch = w.flush(batch)
+			}
+		case <-flush.C:
+			batch = w.flush(batch)
+		case now := <-retention.C:
+			w.deleteExpired(now)
+		}
+	}
+}
+
+func (w *Writer) drain(batch []model.Trade) []model.Trade {
+	for {
+		select {
+		case trade := <-w.trades:
+			batch = append(batch, trade)
+		default:
+			return batch
+		}
+	}
+}
+
+// flush writes batch and returns it emptied, failed batch is logged and dropped.
+func (w *Writer) flush(batch []model.Trade) []model.Trade {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := w.repo.CreateBatch(batch); err != nil {
+		logger.Errorf("trade history insert error", err)
+	}
+
+	return make([]model.Trade, 0, w.config.BatchSize)
+}
+
+func (w *Writer) deleteExpired(now time.Time) {
+	if w.config.Retention.Duration <= 0 {
+		return
+	}
+
+	deleted, err := w.repo.DeleteBefore(now.Add(-w.config.Retention.Duration))
+	if err != nil {
+		logger.Errorf("trade history retention error", err)
+
+		return
+	}
+
+	logger.Infof("trade history retention removed %d trades", deleted)
+}
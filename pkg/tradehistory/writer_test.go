@@ -0,0 +1,90 @@
+package tradehistory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/store/mockpostgresstore"
+)
+
+func testConfig() *config.TradesConfig {
+	return &config.TradesConfig{
+		BatchSize:         2,
+		BufferSize:        10,
+		FlushInterval:     config.Duration{Duration: time.Hour},
+		Retention:         config.Duration{Duration: time.Hour},
+		RetentionInterval: config.Duration{Duration: 10 * time.Millisecond},
+	}
+}
+
+func TestWriter_Run(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	repo := mockpostgresstore.NewMockTradeRepository(mockCtrl)
+
+	batches := make(chan []model.Trade, 10)
+	repo.EXPECT().CreateBatch(gomock.Any()).DoAndReturn(func(trades []model.Trade) error {
+		batches <- trades
+
+		return nil
+	}).Times(2)
+
+	deleted := make(chan time.Time, 10)
+	repo.EXPECT().DeleteBefore(gomock.Any()).DoAndReturn(func(before time.Time) (int64, error) {
+		deleted <- before
+
+		return 0, nil
+	}).MinTimes(1)
+
+	writer := NewWriter(repo, testConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go writer.Run(ctx, wg)
+
+	writer.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", TrdMatchID: "1"})
+	writer.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", TrdMatchID: "2"})
+	writer.Add(bitmex.TradeDataRecord{Symbol: "XBTUSD", TrdMatchID: "3"})
+
+	select {
+	case batch := <-batches:
+		assert.Len(t, batch, 2)
+	case <-time.After(time.Second):
+		t.Fatal("full batch was not flushed")
+	}
+
+	select {
+	case before := <-deleted:
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Second)
+	case <-time.After(time.Second):
+		t.Fatal("retention did not run")
+	}
+
+	cancel()
+	wg.Wait()
+
+	batch := <-batches
+	assert.Equal(t, []model.Trade{{Symbol: "XBTUSD", TrdMatchID: "3"}}, batch)
+}
+
+func TestWriter_AddDropsWhenFull(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	conf := testConfig()
+	conf.BufferSize = 1
+
+	writer := NewWriter(mockpostgresstore.NewMockTradeRepository(mockCtrl), conf)
+
+	writer.Add(bitmex.TradeDataRecord{TrdMatchID: "1"})
+	writer.Add(bitmex.TradeDataRecord{TrdMatchID: "2"})
+
+	assert.Len(t, writer.trades, 1)
+}
@@ -0,0 +1,460 @@
+// Package webhook delivers events of users to their webhooks as signed JSON POST with retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/logger"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/store"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	// SignaturePrefix precedes hex encoded signature in SignatureHeader.
+	SignaturePrefix = "sha256="
+
+	// flushInterval is how often throttled trades waiting for their interval are checked.
+	flushInterval = 100 * time.Millisecond
+	// maxResponseSize is the part of the response body read to reuse the connection.
+	maxResponseSize = 4096
+)
+
+var (
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+	ErrWebhookInactive  = errors.New("webhook is removed or inactive")
+)
+
+// Payload is the body of webhook request.
+type Payload struct {
+	ID        uuid.UUID          `json:"id"`
+	Event     model.WebhookEvent `json:"event"`
+	Timestamp time.Time          `json:"timestamp"`
+	Data      interface{}        `json:"data"`
+}
+
+// Sign returns hex encoded HMAC-SHA256 of timestamp and body joined with dot.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher keeps active webhooks and delivers their events by Workers from the queue of QueueSize.
+// Every delivery is stored before it is queued, delivery out of MaxAttempts is failed and kept as dead letter.
+// Delivery which does not fit the queue or is not attempted by shutdown stays pending in the store,
+// failed delivery waits for its next attempt there, due deliveries are loaded every RetryInterval.
+type Dispatcher struct {
+	repo   store.WebhookRepository
+	config *config.WebhooksConfig
+	client *http.Client
+	queue  chan *model.WebhookDelivery
+
+	webhooks map[uuid.UUID]model.Webhook
+	trades   map[string]map[uuid.UUID]*throttle
+	// created are deliveries of events waiting for Run to store them, so callers never wait for the store
+	created []*model.WebhookDelivery
+	// stored signals Run that created deliveries are waiting
+	stored chan struct{}
+	// queued are IDs of deliveries in the queue or being attempted
+	queued map[uuid.UUID]bool
+
+	mu sync.Mutex
+}
+
+// throttle keeps the latest trade of a symbol which waits for trade interval of webhook.
+type throttle struct {
+	sent    time.Time
+	pending *bitmex.TradeDataRecord
+}
+
+func NewDispatcher(repo store.WebhookRepository, config *config.WebhooksConfig) *Dispatcher {
+	return &Dispatcher{
+		repo:     repo,
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout.Duration},
+		queue:    make(chan *model.WebhookDelivery, config.QueueSize),
+		webhooks: make(map[uuid.UUID]model.Webhook),
+		trades:   make(map[string]map[uuid.UUID]*throttle),
+		stored:   make(chan struct{}, 1),
+		queued:   make(map[uuid.UUID]bool),
+	}
+}
+
+// Load puts active webhooks.
+func (d *Dispatcher) Load(webhooks []model.Webhook) {
+	for _, webhook := range webhooks {
+		d.Put(webhook)
+	}
+}
+
+// Put adds webhook or replaces webhook with the same ID, inactive webhook is removed.
+func (d *Dispatcher) Put(webhook model.Webhook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.remove(webhook.ID)
+
+	if !webhook.Active {
+		return
+	}
+
+	d.webhooks[webhook.ID] = webhook
+
+	if !webhook.Has(model.WebhookTradeEvent) {
+		return
+	}
+
+	for _, symbol := range webhook.Symbols {
+		if d.trades[symbol] == nil {
+			d.trades[symbol] = make(map[uuid.UUID]*throttle)
+		}
+
+		d.trades[symbol][webhook.ID] = &throttle{}
+	}
+}
+
+func (d *Dispatcher) Remove(id uuid.UUID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.remove(id)
+}
+
+func (d *Dispatcher) remove(id uuid.UUID) {
+	webhook, ok := d.webhooks[id]
+	if !ok {
+		return
+	}
+
+	delete(d.webhooks, id)
+
+	for _, symbol := range webhook.Symbols {
+		delete(d.trades[symbol], id)
+
+		if len(d.trades[symbol]) == 0 {
+			delete(d.trades, symbol)
+		}
+	}
+}
+
+// Alert queues fired alert to alert webhooks of its user.
+func (d *Dispatcher) Alert(event model.AlertEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, webhook := range d.webhooks {
+		if webhook.UserID == event.UserID && webhook.Has(model.WebhookAlertEvent) {
+			d.enqueue(webhook, model.WebhookAlertEvent, event)
+		}
+	}
+}
+
+// Trade queues the trade to trade webhooks of its symbol whose interval is over,
+// other webhooks get the latest trade of the symbol once their interval is over.
+func (d *Dispatcher) Trade(record bitmex.TradeDataRecord) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+
+	for id, t := range d.trades[record.Symbol] {
+		webhook := d.webhooks[id]
+
+		if now.Sub(t.sent) < webhook.TradeInterval() {
+			pending := record
+			t.pending = &pending
+
+			continue
+		}
+
+		t.sent, t.pending = now, nil
+		d.enqueue(webhook, model.WebhookTradeEvent, record)
+	}
+}
+
+// flushTrades queues pending trades whose interval is over.
+func (d *Dispatcher) flushTrades(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, throttles := range d.trades {
+		for id, t := range throttles {
+			webhook := d.webhooks[id]
+
+			if t.pending == nil || now.Sub(t.sent) < webhook.TradeInterval() {
+				continue
+			}
+
+			d.enqueue(webhook, model.WebhookTradeEvent, *t.pending)
+			t.sent, t.pending = now, nil
+		}
+	}
+}
+
+// enqueue adds pending delivery of the event to the deliveries Run stores and queues, caller holds mu.
+func (d *Dispatcher) enqueue(webhook model.Webhook, event model.WebhookEvent, data interface{}) {
+	now := time.Now().UTC()
+	id := uuid.NewV4()
+
+	payload, err := json.Marshal(Payload{ID: id, Event: event, Timestamp: now, Data: data})
+	if err != nil {
+		logger.Errorf("webhook payload marshal error", err)
+
+		return
+	}
+
+	d.created = append(d.created, &model.WebhookDelivery{
+		ID:            id,
+		WebhookID:     webhook.ID,
+		UserID:        webhook.UserID,
+		Event:         event,
+		Payload:       payload,
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	})
+
+	select {
+	case d.stored <- struct{}{}:
+	default:
+	}
+}
+
+// push queues the delivery unless it is queued already and reports whether the queue had room, caller holds mu.
+func (d *Dispatcher) push(delivery *model.WebhookDelivery) bool {
+	if d.queued[delivery.ID] {
+		return true
+	}
+
+	select {
+	case d.queue <- delivery:
+		d.queued[delivery.ID] = true
+
+		return true
+	default:
+		return false
+	}
+}
+
+// Run starts Workers, flushes throttled trades, stores created deliveries and queues due ones until ctx is done.
+// Due deliveries are loaded at once, so deliveries left pending or retrying by the previous run are resumed.
+// Deliveries created by shutdown are stored to be resumed by the next run.
+func (d *Dispatcher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	workers := &sync.WaitGroup{}
+	workers.Add(d.config.Workers)
+
+	for i := 0; i < d.config.Workers; i++ {
+		go d.work(ctx, workers)
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	retry := time.NewTicker(d.config.RetryInterval.Duration)
+	defer retry.Stop()
+
+	d.queueDue(time.Now().UTC())
+
+	for {
+		select {
+		case <-ctx.Done():
+			workers.Wait()
+			d.storeCreated()
+			logger.Infof("webhook dispatcher done")
+
+			return
+		case <-d.stored:
+			d.storeCreated()
+		case now := <-ticker.C:
+			d.flushTrades(now)
+			d.storeCreated()
+		case now := <-retry.C:
+			d.queueDue(now.UTC())
+		}
+	}
+}
+
+// storeCreated stores created deliveries and queues them as long as the queue has room,
+// the rest stay pending in the store until they are loaded as due.
+func (d *Dispatcher) storeCreated() {
+	d.mu.Lock()
+	created := d.created
+	d.created = nil
+	d.mu.Unlock()
+
+	stored := created[:0]
+
+	for _, delivery := range created {
+		if err := d.repo.CreateDelivery(delivery); err != nil {
+			logger.Errorf("webhook delivery create error", err)
+
+			continue
+		}
+
+		stored = append(stored, delivery)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, delivery := range stored {
+		if !d.push(delivery) {
+			logger.Errorf("webhook queue is full, delivery left pending", delivery.ID)
+		}
+	}
+}
+
+// queueDue queues pending and retrying deliveries of the store whose next attempt is due as long as
+// the queue has room, the rest stay in the store until the next call.
+func (d *Dispatcher) queueDue(now time.Time) {
+	d.mu.Lock()
+	queued := len(d.queued)
+	d.mu.Unlock()
+
+	free := cap(d.queue) - len(d.queue)
+	if free <= 0 {
+		return
+	}
+
+	deliveries, err := d.repo.GetDue(now, free+queued)
+	if err != nil {
+		logger.Errorf("webhook retries load error", err)
+
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i := range deliveries {
+		if !d.push(&deliveries[i]) {
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-d.queue:
+			d.deliver(ctx, delivery)
+		}
+	}
+}
+
+// deliver makes an attempt of the delivery, failed delivery is left retrying in the store
+// with the next attempt after backoff until MaxAttempts.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	defer func() {
+		d.mu.Lock()
+		delete(d.queued, delivery.ID)
+		d.mu.Unlock()
+	}()
+
+	d.mu.Lock()
+	webhook, ok := d.webhooks[delivery.WebhookID]
+	d.mu.Unlock()
+
+	var err error
+	if ok {
+		delivery.Attempts++
+		delivery.LastStatusCode, err = d.post(ctx, webhook, delivery)
+	} else {
+		err = ErrWebhookInactive
+	}
+
+	now := time.Now().UTC()
+	delivery.LastError, delivery.NextAttemptAt = "", nil
+
+	switch {
+	case err == nil:
+		delivery.Status = model.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+	case !ok || delivery.Attempts >= d.config.MaxAttempts:
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.Status = model.WebhookDeliveryRetrying
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = &next
+	}
+
+	if err = d.repo.UpdateDelivery(delivery); err != nil {
+		logger.Errorf("webhook delivery update error", err)
+	}
+}
+
+// backoff doubles Backoff with every failed attempt up to MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.config.Backoff.Duration
+	for i := 1; i < attempts && backoff < d.config.MaxBackoff.Duration; i++ {
+		backoff *= 2
+	}
+
+	if backoff > d.config.MaxBackoff.Duration {
+		return d.config.MaxBackoff.Duration
+	}
+
+	return backoff
+}
+
+// post sends signed payload of the delivery and returns response status code, non 2xx status is an error.
+func (d *Dispatcher) post(ctx context.Context, webhook model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, SignaturePrefix+Sign(webhook.Secret, timestamp, delivery.Payload))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.CopyN(io.Discard, resp.Body, maxResponseSize)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("%w %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,491 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitmex-api/pkg/config"
+	"bitmex-api/pkg/model"
+	"bitmex-api/pkg/model/bitmex"
+	"bitmex-api/pkg/store/mockpostgresstore"
+)
+
+func testConfig() *config.WebhooksConfig {
+	return &config.WebhooksConfig{
+		Workers:       2,
+		QueueSize:     10,
+		Timeout:       config.Duration{Duration: time.Second},
+		MaxAttempts:   3,
+		Backoff:       config.Duration{Duration: 10 * time.Millisecond},
+		MaxBackoff:    config.Duration{Duration: 15 * time.Millisecond},
+		RetryInterval: config.Duration{Duration: 5 * time.Millisecond},
+	}
+}
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is local webhook endpoint answering with statuses in turn and then with 200.
+func receiver(t *testing.T, statuses ...int) (*httptest.Server, chan request) {
+	t.Helper()
+
+	requests := make(chan request, 10)
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		requests <- request{header: r.Header, body: body}
+
+		if call := int(calls.Add(1)); call <= len(statuses) {
+			w.WriteHeader(statuses[call-1])
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requests
+}
+
+// deliveries is in-memory delivery log for store mock.
+type deliveries struct {
+	deliveries map[uuid.UUID]model.WebhookDelivery
+
+	mu sync.Mutex
+}
+
+func (d *deliveries) put(delivery model.WebhookDelivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deliveries[delivery.ID] = delivery
+}
+
+func (d *deliveries) due(before time.Time, limit int) []model.WebhookDelivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var found []model.WebhookDelivery
+
+	for _, delivery := range d.deliveries {
+		if len(found) < limit && waiting(delivery) && !delivery.NextAttemptAt.After(before) {
+			found = append(found, delivery)
+		}
+	}
+
+	return found
+}
+
+func (d *deliveries) all() []model.WebhookDelivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	all := make([]model.WebhookDelivery, 0, len(d.deliveries))
+	for _, delivery := range d.deliveries {
+		all = append(all, delivery)
+	}
+
+	return all
+}
+
+func waiting(delivery model.WebhookDelivery) bool {
+	return delivery.Status == model.WebhookDeliveryPending || delivery.Status == model.WebhookDeliveryRetrying
+}
+
+// run starts dispatcher with store mock sending the result of every delivery attempt to updates.
+func run(t *testing.T, webhooks ...model.Webhook) (*Dispatcher, chan model.WebhookDelivery) {
+	t.Helper()
+
+	return runStored(t, nil, webhooks...)
+}
+
+// runStored starts dispatcher with store mock keeping stored deliveries and sending the result
+// of every delivery attempt to updates.
+func runStored(
+	t *testing.T,
+	stored []model.WebhookDelivery,
+	webhooks ...model.Webhook,
+) (*Dispatcher, chan model.WebhookDelivery) {
+	t.Helper()
+
+	repo, _, updates := mockStore(t, stored)
+
+	dispatcher := NewDispatcher(repo, testConfig())
+	dispatcher.Load(webhooks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go dispatcher.Run(ctx, wg)
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	return dispatcher, updates
+}
+
+// mockStore returns store mock keeping stored deliveries in the log and sending the result
+// of every delivery attempt to updates.
+func mockStore(
+	t *testing.T,
+	stored []model.WebhookDelivery,
+) (*mockpostgresstore.MockWebhookRepository, *deliveries, chan model.WebhookDelivery) {
+	t.Helper()
+
+	mockCtrl := gomock.NewController(t)
+	repo := mockpostgresstore.NewMockWebhookRepository(mockCtrl)
+
+	log := &deliveries{deliveries: make(map[uuid.UUID]model.WebhookDelivery)}
+	for _, delivery := range stored {
+		log.put(delivery)
+	}
+
+	updates := make(chan model.WebhookDelivery, 100)
+	repo.EXPECT().CreateDelivery(gomock.Any()).DoAndReturn(func(delivery *model.WebhookDelivery) error {
+		assert.Equal(t, model.WebhookDeliveryPending, delivery.Status)
+		assert.NotNil(t, delivery.NextAttemptAt)
+		log.put(*delivery)
+
+		return nil
+	}).AnyTimes()
+	repo.EXPECT().UpdateDelivery(gomock.Any()).DoAndReturn(func(delivery *model.WebhookDelivery) error {
+		log.put(*delivery)
+		updates <- *delivery
+
+		return nil
+	}).AnyTimes()
+	repo.EXPECT().GetDue(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(before time.Time, limit int) ([]model.WebhookDelivery, error) {
+			return log.due(before, limit), nil
+		}).AnyTimes()
+
+	return repo, log, updates
+}
+
+func alertWebhook(url string) model.Webhook {
+	return model.Webhook{
+		ID:     uuid.NewV4(),
+		UserID: uuid.NewV4(),
+		URL:    url,
+		Secret: "secret",
+		Events: pq.StringArray{string(model.WebhookAlertEvent)},
+		Active: true,
+	}
+}
+
+func next(t *testing.T, updates chan model.WebhookDelivery) model.WebhookDelivery {
+	t.Helper()
+
+	select {
+	case delivery := <-updates:
+		return delivery
+	case <-time.After(time.Second):
+		t.Fatal("delivery attempt was not made")
+	}
+
+	return model.WebhookDelivery{}
+}
+
+func TestDispatcher_Alert(t *testing.T) {
+	server, requests := receiver(t)
+	webhook := alertWebhook(server.URL)
+	dispatcher, updates := run(t, webhook)
+
+	event := model.AlertEvent{ID: 1, AlertID: uuid.NewV4(), UserID: webhook.UserID, Symbol: "XBTUSD", Price: 70001}
+	dispatcher.Alert(event)
+	dispatcher.Alert(model.AlertEvent{ID: 2, UserID: uuid.NewV4()})
+
+	delivery := next(t, updates)
+	assert.Equal(t, model.WebhookDeliveryDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.LastStatusCode)
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	received := <-requests
+	timestamp := received.header.Get(TimestampHeader)
+	assert.Equal(t, SignaturePrefix+Sign("secret", timestamp, received.body), received.header.Get(SignatureHeader))
+	assert.Equal(t, "alert", received.header.Get(EventHeader))
+	assert.Equal(t, delivery.ID.String(), received.header.Get(DeliveryHeader))
+	assert.Equal(t, []byte(delivery.Payload), received.body)
+
+	var payload struct {
+		ID    uuid.UUID          `json:"id"`
+		Event model.WebhookEvent `json:"event"`
+		Data  model.AlertEvent   `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(received.body, &payload))
+	assert.Equal(t, delivery.ID, payload.ID)
+	assert.Equal(t, model.WebhookAlertEvent, payload.Event)
+	assert.Equal(t, event.AlertID, payload.Data.AlertID)
+
+	// alert of another user is not delivered
+	select {
+	case <-requests:
+		t.Fatal("alert of another user was delivered")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	server, requests := receiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	webhook := alertWebhook(server.URL)
+	dispatcher, updates := run(t, webhook)
+
+	dispatcher.Alert(model.AlertEvent{UserID: webhook.UserID})
+
+	delivery := next(t, updates)
+	assert.Equal(t, model.WebhookDeliveryRetrying, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+	assert.Equal(t, "unexpected status code 500", delivery.LastError)
+	require.NotNil(t, delivery.NextAttemptAt)
+
+	delivery = next(t, updates)
+	assert.Equal(t, model.WebhookDeliveryRetrying, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+
+	delivery = next(t, updates)
+	assert.Equal(t, model.WebhookDeliveryDelivered, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Empty(t, delivery.LastError)
+	assert.Nil(t, delivery.NextAttemptAt)
+
+	first, second := <-requests, <-requests
+	assert.Equal(t, first.body, second.body)
+	assert.Equal(t, first.header.Get(DeliveryHeader), second.header.Get(DeliveryHeader))
+}
+
+func TestDispatcher_StoredRetry(t *testing.T) {
+	server, requests := receiver(t)
+	webhook := alertWebhook(server.URL)
+
+	// delivery left retrying by the previous run is resumed on start
+	due := time.Now().UTC().Add(-time.Minute)
+	stored := model.WebhookDelivery{
+		ID:            uuid.NewV4(),
+		WebhookID:     webhook.ID,
+		UserID:        webhook.UserID,
+		Event:         model.WebhookAlertEvent,
+		Payload:       []byte(`{"event":"alert"}`),
+		Status:        model.WebhookDeliveryRetrying,
+		Attempts:      1,
+		NextAttemptAt: &due,
+	}
+	_, updates := runStored(t, []model.WebhookDelivery{stored}, webhook)
+
+	delivery := next(t, updates)
+	assert.Equal(t, stored.ID, delivery.ID)
+	assert.Equal(t, model.WebhookDeliveryDelivered, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+
+	received := <-requests
+	assert.Equal(t, stored.ID.String(), received.header.Get(DeliveryHeader))
+	assert.JSONEq(t, `{"event":"alert"}`, string(received.body))
+
+	// delivered retry is not loaded again
+	select {
+	case <-requests:
+		t.Fatal("retry was delivered twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDispatcher_RetryQueueFull(t *testing.T) {
+	server, requests := receiver(t)
+	webhook := alertWebhook(server.URL)
+
+	// more retries are due than the queue holds, the rest wait in the store for the next load
+	due := time.Now().UTC().Add(-time.Minute)
+	stored := make([]model.WebhookDelivery, 25)
+
+	for i := range stored {
+		stored[i] = model.WebhookDelivery{
+			ID:            uuid.NewV4(),
+			WebhookID:     webhook.ID,
+			UserID:        webhook.UserID,
+			Event:         model.WebhookAlertEvent,
+			Payload:       []byte(`{}`),
+			Status:        model.WebhookDeliveryRetrying,
+			Attempts:      1,
+			NextAttemptAt: &due,
+		}
+	}
+
+	_, updates := runStored(t, stored, webhook)
+
+	delivered := make(map[uuid.UUID]bool)
+	for range stored {
+		delivery := next(t, updates)
+		assert.Equal(t, model.WebhookDeliveryDelivered, delivery.Status)
+		delivered[delivery.ID] = true
+		<-requests
+	}
+
+	assert.Len(t, delivered, len(stored))
+}
+
+func TestDispatcher_QueueFull(t *testing.T) {
+	server, requests := receiver(t)
+	webhook := alertWebhook(server.URL)
+	dispatcher, updates := run(t, webhook)
+
+	// events over the queue size wait pending in the store for the next load
+	for i := 1; i <= 25; i++ {
+		dispatcher.Alert(model.AlertEvent{ID: int64(i), UserID: webhook.UserID})
+	}
+
+	delivered := make(map[uuid.UUID]bool)
+	for i := 0; i < 25; i++ {
+		delivery := next(t, updates)
+		assert.Equal(t, model.WebhookDeliveryDelivered, delivery.Status)
+		delivered[delivery.ID] = true
+		<-requests
+	}
+
+	assert.Len(t, delivered, 25)
+}
+
+func TestDispatcher_Shutdown(t *testing.T) {
+	// endpoint answers only once the request is cancelled
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	webhook := alertWebhook(server.URL)
+	repo, log, _ := mockStore(t, nil)
+
+	dispatcher := NewDispatcher(repo, testConfig())
+	dispatcher.Put(webhook)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go dispatcher.Run(ctx, wg)
+
+	for i := 1; i <= 5; i++ {
+		dispatcher.Alert(model.AlertEvent{ID: int64(i), UserID: webhook.UserID})
+	}
+
+	cancel()
+	wg.Wait()
+
+	// deliveries not made by shutdown are left in the store for the next run
+	all := log.all()
+	assert.Len(t, all, 5)
+
+	for _, delivery := range all {
+		assert.True(t, waiting(delivery), delivery.Status)
+	}
+}
+
+func TestDispatcher_DeadLetter(t *testing.T) {
+	server, _ := receiver(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	webhook := alertWebhook(server.URL)
+	dispatcher, updates := run(t, webhook)
+
+	dispatcher.Alert(model.AlertEvent{UserID: webhook.UserID})
+
+	next(t, updates)
+	next(t, updates)
+
+	delivery := next(t, updates)
+	assert.Equal(t, model.WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+	assert.Nil(t, delivery.NextAttemptAt)
+}
+
+func TestDispatcher_Removed(t *testing.T) {
+	var dispatcher *Dispatcher
+
+	webhook := alertWebhook("")
+
+	// webhook is removed while its first attempt is in flight
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		dispatcher.Remove(webhook.ID)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	webhook.URL = server.URL
+	dispatcher, updates := run(t, webhook)
+
+	dispatcher.Alert(model.AlertEvent{UserID: webhook.UserID})
+	assert.Equal(t, model.WebhookDeliveryRetrying, next(t, updates).Status)
+
+	delivery := next(t, updates)
+	assert.Equal(t, model.WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, ErrWebhookInactive.Error(), delivery.LastError)
+}
+
+func TestDispatcher_Trade(t *testing.T) {
+	server, requests := receiver(t)
+	webhook := alertWebhook(server.URL)
+	webhook.Events = pq.StringArray{string(model.WebhookTradeEvent)}
+	webhook.Symbols = pq.StringArray{"XBTUSD"}
+	webhook.TradeIntervalMs = 200
+	dispatcher, updates := run(t, webhook)
+
+	dispatcher.Trade(bitmex.TradeDataRecord{Symbol: "XBTUSD", TrdMatchID: "1"})
+	dispatcher.Trade(bitmex.TradeDataRecord{Symbol: "XBTUSD", TrdMatchID: "2"})
+	dispatcher.Trade(bitmex.TradeDataRecord{Symbol: "XBTUSD", TrdMatchID: "3"})
+	dispatcher.Trade(bitmex.TradeDataRecord{Symbol: "ETHUSD", TrdMatchID: "4"})
+
+	var payload struct {
+		Event model.WebhookEvent     `json:"event"`
+		Data  bitmex.TradeDataRecord `json:"data"`
+	}
+
+	// the first trade is sent at once, the latest one after the interval
+	start := time.Now()
+
+	for _, id := range []string{"1", "3"} {
+		assert.Equal(t, model.WebhookDeliveryDelivered, next(t, updates).Status)
+		require.NoError(t, json.Unmarshal((<-requests).body, &payload))
+		assert.Equal(t, model.WebhookTradeEvent, payload.Event)
+		assert.Equal(t, id, payload.Data.TrdMatchID)
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	select {
+	case <-requests:
+		t.Fatal("throttled trade was delivered twice")
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	dispatcher := NewDispatcher(nil, &config.WebhooksConfig{
+		QueueSize:  1,
+		Backoff:    config.Duration{Duration: time.Second},
+		MaxBackoff: config.Duration{Duration: 5 * time.Second},
+	})
+
+	assert.Equal(t, time.Second, dispatcher.backoff(1))
+	assert.Equal(t, 2*time.Second, dispatcher.backoff(2))
+	assert.Equal(t, 4*time.Second, dispatcher.backoff(3))
+	assert.Equal(t, 5*time.Second, dispatcher.backoff(4))
+	assert.Equal(t, 5*time.Second, dispatcher.backoff(30))
+}